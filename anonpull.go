@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// anonymousPullStates are the accepted verify_anonymous_pull values.
+var anonymousPullStates = map[string]bool{"enabled": true, "disabled": false}
+
+// anonymousPullState names the anonymous pull setting as reported in outputs.
+func anonymousPullState(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+// reconcileAnonymousPull compares the registry's anonymous pull setting with
+// expected ("enabled" or "disabled"). With set, a mismatch is corrected with
+// az acr update and the setting read back; otherwise it is returned as an
+// error. The final state is returned either way.
+func reconcileAnonymousPull(ctx context.Context, client *ACRClient, expected string, set bool) (string, error) {
+	enabled, err := client.AnonymousPullEnabled(ctx)
+	if err != nil {
+		return "", err
+	}
+	if anonymousPullState(enabled) == expected {
+		return expected, nil
+	}
+	if !set {
+		return anonymousPullState(enabled), fmt.Errorf("anonymous pull is %s on %s but verify_anonymous_pull expects %s",
+			anonymousPullState(enabled), client.GetRegistryURL(), expected)
+	}
+
+	if err := client.SetAnonymousPull(ctx, anonymousPullStates[expected]); err != nil {
+		return anonymousPullState(enabled), err
+	}
+	if enabled, err = client.AnonymousPullEnabled(ctx); err != nil {
+		return "", err
+	}
+	if anonymousPullState(enabled) != expected {
+		return anonymousPullState(enabled), fmt.Errorf("anonymous pull is still %s on %s after updating it", anonymousPullState(enabled), client.GetRegistryURL())
+	}
+	fmt.Printf("Anonymous pull %s on %s\n", expected, client.GetRegistryURL())
+	return expected, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestReconcileAnonymousPull(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		expected string
+		set      bool
+		state    string
+		wantErr  bool
+		updated  bool
+	}{
+		{name: "matches", current: "true", expected: "enabled", state: "enabled"},
+		{name: "mismatch", current: "false", expected: "enabled", state: "disabled", wantErr: true},
+		{name: "mismatch corrected", current: "true", expected: "disabled", set: true, state: "disabled", updated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := tt.current
+			runner := &fakeRunner{
+				respond: func(cmd Command) ([]byte, error) {
+					if cmd.Args[1] == "update" {
+						current = cmd.Args[len(cmd.Args)-1]
+						return nil, nil
+					}
+					return []byte(current + "\n"), nil
+				},
+			}
+			client := NewACRClient("myregistry")
+			client.SetRunner(runner)
+
+			state, err := reconcileAnonymousPull(context.Background(), client, tt.expected, tt.set)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if state != tt.state {
+				t.Errorf("expected state %q, got %q", tt.state, state)
+			}
+			updated := false
+			for _, cmd := range runner.commands {
+				updated = updated || cmd.Args[1] == "update"
+			}
+			if updated != tt.updated {
+				t.Errorf("expected update %v, got %v", tt.updated, updated)
+			}
+		})
+	}
+}
+
+func TestReconcileAnonymousPull_UpdateNotApplied(t *testing.T) {
+	client := NewACRClient("myregistry")
+	client.SetRunner(&fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			return []byte("false\n"), nil
+		},
+	})
+
+	_, err := reconcileAnonymousPull(context.Background(), client, "enabled", true)
+	if err == nil || !strings.Contains(err.Error(), "still disabled") {
+		t.Errorf("expected an error when the update does not take effect, got %v", err)
+	}
+}
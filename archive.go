@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ArchivedLayout is an image copied into an OCI image layout by archive_oci_layout.
+type ArchivedLayout struct {
+	// Path is the layout reference, the layout directory and tag as dir:tag.
+	Path   string `json:"path"`
+	Digest string `json:"digest"`
+}
+
+// ociLayoutDir returns the layout directory for an image path under root.
+func ociLayoutDir(root, imagePath string) string {
+	return filepath.Join(root, filepath.FromSlash(imagePath))
+}
+
+// checkWritableDir verifies that dir, or its nearest existing ancestor when it
+// does not exist yet, is a directory the plugin can create files in.
+func checkWritableDir(dir string) error {
+	existing := filepath.Clean(dir)
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", existing)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return fmt.Errorf("no existing parent directory for %s", dir)
+		}
+		existing = parent
+	}
+
+	probe, err := os.CreateTemp(existing, ".relicta-acr-write-check-")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", existing, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOCILayoutDir(t *testing.T) {
+	if got := ociLayoutDir("/mnt/archive", "team/app"); got != filepath.Join("/mnt/archive", "team", "app") {
+		t.Errorf("unexpected layout dir %q", got)
+	}
+}
+
+func TestCheckWritableDir(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		wantErr bool
+	}{
+		{name: "existing", dir: root},
+		{name: "created later", dir: filepath.Join(root, "archive", "images")},
+		{name: "file", dir: file, wantErr: true},
+		{name: "below a file", dir: filepath.Join(file, "images"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkWritableDir(tt.dir)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	entries, _ := os.ReadDir(root)
+	if len(entries) != 1 {
+		t.Errorf("expected the write check to leave nothing behind, got %v", entries)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// attachments are the artifacts the attach stage added, keyed by image path.
+type attachments struct {
+	SBOM         map[string]string
+	ReleaseNotes map[string]string
+	BaseImage    map[string]string
+	Provenance   map[string]string
+	Layouts      map[string]ArchivedLayout
+}
+
+// attach adds the configured SBOM, release notes, base image record and SLSA
+// provenance to each pushed image, then archives it as an OCI image layout. A
+// dry run only reports what it would attach.
+func (r *pushRun) attach(ctx context.Context, data *templateData, release *plugin.ReleaseContext, baseImage BaseImage, started time.Time) (*attachments, error) {
+	a := &attachments{
+		SBOM:         map[string]string{},
+		ReleaseNotes: map[string]string{},
+		BaseImage:    map[string]string{},
+		Provenance:   map[string]string{},
+		Layouts:      map[string]ArchivedLayout{},
+	}
+
+	// Attach the SBOM to each pushed image
+	if r.cfg.SBOM.File != "" && !r.cfg.DryRun {
+		sbomDone := r.steps.begin("attaching sbom")
+		oras := NewOrasClient()
+		oras.SetRunner(r.runner)
+		for _, target := range r.targets {
+			imagePath := target.Path()
+			digest, ok, err := r.subjectDigest(ctx, imagePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to attach SBOM: %w", err)
+			}
+			if !ok {
+				continue
+			}
+			subject := fmt.Sprintf("%s/%s@%s", r.registryURL, imagePath, digest)
+			artifactDigest, err := oras.Attach(ctx, subject, r.cfg.SBOM.File, r.cfg.SBOM.MediaType)
+			if err != nil {
+				return nil, fmt.Errorf("failed to attach SBOM: %w", err)
+			}
+			fmt.Printf("Attached SBOM to %s\n", subject)
+			a.SBOM[imagePath] = artifactDigest
+		}
+		sbomDone(stepCompleted)
+	} else if r.cfg.SBOM.File != "" {
+		fmt.Printf("[dry-run] Would attach SBOM %s\n", r.cfg.SBOM.File)
+		r.steps.begin("attaching sbom")(stepSimulated)
+	}
+
+	// Attach the release notes to each pushed image
+	if r.cfg.ReleaseNotes.Enabled && !r.cfg.DryRun {
+		notesDone := r.steps.begin("attaching release notes")
+		notes, err := renderReleaseNotes(r.cfg.ReleaseNotes, data)
+		if err != nil {
+			return nil, err
+		}
+		oras := NewOrasClient()
+		oras.SetRunner(r.runner)
+		for _, target := range r.targets {
+			imagePath := target.Path()
+			digest, ok, err := r.subjectDigest(ctx, imagePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to attach release notes: %w", err)
+			}
+			if !ok {
+				continue
+			}
+			subject := fmt.Sprintf("%s/%s@%s", r.registryURL, imagePath, digest)
+			artifactDigest, err := oras.AttachContent(ctx, subject, releaseNotesFileName, []byte(notes), r.cfg.ReleaseNotes.MediaType)
+			if err != nil {
+				return nil, fmt.Errorf("failed to attach release notes: %w", err)
+			}
+			fmt.Printf("Attached release notes to %s\n", subject)
+			a.ReleaseNotes[imagePath] = artifactDigest
+		}
+		notesDone(stepCompleted)
+	} else if r.cfg.ReleaseNotes.Enabled {
+		fmt.Printf("[dry-run] Would attach release notes\n")
+		r.steps.begin("attaching release notes")(stepSimulated)
+	}
+
+	// Attach the base image record to each pushed image
+	if r.cfg.AttachBaseImage && baseImage.Name != "" && !r.cfg.DryRun {
+		baseDone := r.steps.begin("attaching base image")
+		record, err := baseImage.marshalRecord()
+		if err != nil {
+			return nil, err
+		}
+		oras := NewOrasClient()
+		oras.SetRunner(r.runner)
+		for _, target := range r.targets {
+			imagePath := target.Path()
+			digest, ok, err := r.subjectDigest(ctx, imagePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to attach base image: %w", err)
+			}
+			if !ok {
+				continue
+			}
+			subject := fmt.Sprintf("%s/%s@%s", r.registryURL, imagePath, digest)
+			artifactDigest, err := oras.AttachContent(ctx, subject, baseImageFileName, record, baseImageMediaType)
+			if err != nil {
+				return nil, fmt.Errorf("failed to attach base image: %w", err)
+			}
+			fmt.Printf("Attached base image %s to %s\n", baseImage.Name, subject)
+			a.BaseImage[imagePath] = artifactDigest
+		}
+		baseDone(stepCompleted)
+	} else if r.cfg.AttachBaseImage && r.cfg.DryRun {
+		fmt.Printf("[dry-run] Would attach the base image record\n")
+		r.steps.begin("attaching base image")(stepSimulated)
+	}
+
+	// Attest SLSA provenance for each pushed image
+	if r.cfg.Provenance.Enabled && !r.cfg.DryRun {
+		provenanceDone := r.steps.begin("attesting provenance")
+		cosign := NewCosignClient()
+		cosign.SetRunner(r.runner)
+		for _, target := range r.targets {
+			imagePath := target.Path()
+			digest, ok, err := r.subjectDigest(ctx, imagePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to attest provenance: %w", err)
+			}
+			if !ok {
+				continue
+			}
+			subject := fmt.Sprintf("%s/%s@%s", r.registryURL, imagePath, digest)
+			statement := buildProvenance(r.cfg.Provenance, r.registryURL+"/"+imagePath, digest, release, data.RunID, started)
+			predicate, err := statement.marshalPredicate()
+			if err != nil {
+				return nil, err
+			}
+			if err := cosign.Attest(ctx, subject, predicate, "slsaprovenance1", r.cfg.Provenance.Key); err != nil {
+				return nil, fmt.Errorf("failed to attest provenance: %w", err)
+			}
+			fmt.Printf("Attested provenance for %s\n", subject)
+
+			// cosign stores the attestation under a tag derived from the digest
+			attestation, err := r.client.ManifestDigest(ctx, imagePath+":"+attestationTag(digest))
+			if err != nil {
+				warnf("could not resolve provenance attestation of %s: %v", subject, err)
+			}
+			a.Provenance[imagePath] = attestation
+		}
+		provenanceDone(stepCompleted)
+	} else if r.cfg.Provenance.Enabled {
+		fmt.Printf("[dry-run] Would attest SLSA provenance\n")
+		r.steps.begin("attesting provenance")(stepSimulated)
+	}
+
+	// Archive each pushed image as an OCI image layout
+	if r.cfg.ArchiveOCILayout != "" && !r.cfg.DryRun && len(r.tags) > 0 {
+		archiveDone := r.steps.begin("archiving oci layout")
+		oras := NewOrasClient()
+		oras.SetRunner(r.runner)
+		for _, target := range r.targets {
+			imagePath := target.Path()
+			digest, ok, err := r.subjectDigest(ctx, imagePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to archive: %w", err)
+			}
+			if !ok {
+				continue
+			}
+			dir := ociLayoutDir(r.cfg.ArchiveOCILayout, imagePath)
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("failed to create OCI layout directory: %w", err)
+			}
+			layout := dir + ":" + r.tags[0]
+			written, err := oras.CopyToOCILayout(ctx, fmt.Sprintf("%s/%s@%s", r.registryURL, imagePath, digest), layout)
+			if err != nil {
+				return nil, fmt.Errorf("failed to archive %s: %w", imagePath, err)
+			}
+			fmt.Printf("Archived %s to %s\n", imagePath, layout)
+			a.Layouts[imagePath] = ArchivedLayout{Path: layout, Digest: written}
+		}
+		archiveDone(stepCompleted)
+	} else if r.cfg.ArchiveOCILayout != "" {
+		fmt.Printf("[dry-run] Would archive to OCI layout %s\n", r.cfg.ArchiveOCILayout)
+		r.steps.begin("archiving oci layout")(stepSimulated)
+	}
+	return a, nil
+}
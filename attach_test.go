@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestPushRun_Attach(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	notes := "sha256:" + strings.Repeat("c", 64)
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			return []byte("Digest: " + notes + "\n"), nil
+		},
+	}
+	run := newTestPushRun(map[string]any{
+		"registry":      "myregistry",
+		"image":         "myapp",
+		"source_image":  "myapp:latest",
+		"release_notes": map[string]any{"text": "Fixes for {{.Version}}"},
+	}, []string{"1.0.0"}, runner)
+	run.imageDigests["myapp"] = digest
+	run.pushedByImage["myapp"] = []string{"myregistry.azurecr.io/myapp:1.0.0"}
+
+	release := &plugin.ReleaseContext{Version: "1.0.0"}
+	attached, err := run.attach(context.Background(), newTemplateData(release), release, BaseImage{}, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if attached.ReleaseNotes["myapp"] != notes {
+		t.Errorf("expected release notes %s, got %v", notes, attached.ReleaseNotes)
+	}
+	if len(attached.SBOM) != 0 || len(attached.Provenance) != 0 || len(attached.Layouts) != 0 {
+		t.Errorf("expected only release notes to be attached, got %+v", attached)
+	}
+	if len(runner.commands) != 1 || !slices.Contains(runner.commands[0].Args, "myregistry.azurecr.io/myapp@"+digest) {
+		t.Errorf("expected one attach to the pushed digest, got %+v", runner.commands)
+	}
+}
+
+func TestPushRun_AttachDryRun(t *testing.T) {
+	runner := &fakeRunner{}
+	run := newTestPushRun(map[string]any{
+		"registry":      "myregistry",
+		"image":         "myapp",
+		"source_image":  "myapp:latest",
+		"dry_run":       true,
+		"release_notes": map[string]any{"text": "notes"},
+	}, []string{"1.0.0"}, runner)
+
+	release := &plugin.ReleaseContext{Version: "1.0.0"}
+	if _, err := run.attach(context.Background(), newTemplateData(release), release, BaseImage{}, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runner.assertCommands(t, []Command{})
+	steps := run.steps.Steps()
+	if len(steps) != 1 || steps[0].Name != "attaching release notes" || steps[0].Status != stepSimulated {
+		t.Errorf("expected a simulated release notes step, got %+v", steps)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Audit actions recorded for registry mutations.
+const (
+	auditPush    = "push"
+	auditPromote = "promote"
+	auditImport  = "import"
+	auditUntag   = "untag"
+	auditDelete  = "delete"
+)
+
+// AuditLog appends one JSON line per registry mutation as it happens, so a
+// run that dies midway still leaves a record of what it changed.
+type AuditLog struct {
+	mu    sync.Mutex
+	file  *os.File
+	actor string
+}
+
+// auditEntry is a single recorded mutation.
+type auditEntry struct {
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+	Actor    string    `json:"actor"`
+	Registry string    `json:"registry"`
+	Image    string    `json:"image"`
+	Digest   string    `json:"digest,omitempty"`
+}
+
+// OpenAuditLog opens an audit log file for appending, attributing entries to actor.
+func OpenAuditLog(path, actor string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &AuditLog{file: file, actor: actor}, nil
+}
+
+// Close closes the audit log file.
+func (a *AuditLog) Close() error {
+	if a == nil {
+		return nil
+	}
+	return a.file.Close()
+}
+
+// Record appends a mutation and syncs it to disk before returning.
+func (a *AuditLog) Record(action, registry, image, digest string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	line, err := json.Marshal(auditEntry{
+		Time:     time.Now().UTC(),
+		Action:   action,
+		Actor:    a.actor,
+		Registry: registry,
+		Image:    image,
+		Digest:   digest,
+	})
+	if err != nil {
+		return
+	}
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		warnf("failed to write audit entry: %v", err)
+		return
+	}
+	if err := a.file.Sync(); err != nil {
+		warnf("failed to flush audit log: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog_Record(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	audit, err := OpenAuditLog(path, "ci@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	audit.Record(auditPush, "myregistry.azurecr.io", "myregistry.azurecr.io/app:1.0.0", "sha256:abc")
+
+	// Entries are on disk before Close
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(string(data), "\n") != 1 {
+		t.Fatalf("expected one line before close, got %q", data)
+	}
+
+	audit.Record(auditDelete, "myregistry.azurecr.io", "myregistry.azurecr.io/app:0.9.0", "")
+	if err := audit.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, _ = os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+
+	var entry auditEntry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entry.Action != auditPush || entry.Actor != "ci@example.com" || entry.Digest != "sha256:abc" || entry.Time.IsZero() {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if strings.Contains(lines[1], "digest") {
+		t.Errorf("expected an unknown digest to be omitted, got %s", lines[1])
+	}
+}
+
+func TestAuditLog_Nil(t *testing.T) {
+	var audit *AuditLog
+	audit.Record(auditPush, "r", "i", "d")
+	if err := audit.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// OCI annotation keys that builders set on the image config to name the base image.
+const (
+	baseImageNameLabel   = "org.opencontainers.image.base.name"
+	baseImageDigestLabel = "org.opencontainers.image.base.digest"
+)
+
+// baseImageFileName and baseImageMediaType describe the attached base image record.
+const (
+	baseImageFileName  = "base-image.json"
+	baseImageMediaType = "application/vnd.relicta.base-image+json"
+)
+
+// BaseImage is the image a pushed image was built FROM.
+type BaseImage struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+}
+
+// marshalRecord encodes the base image as the attached JSON record.
+func (b BaseImage) marshalRecord() ([]byte, error) {
+	record, err := json.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode base image: %w", err)
+	}
+	return record, nil
+}
+
+// baseImageFromLabels reads the base image from the OCI labels of an image
+// config. Both fields are empty when the builder did not record them.
+func baseImageFromLabels(labels map[string]string) BaseImage {
+	return BaseImage{
+		Name:   labels[baseImageNameLabel],
+		Digest: labels[baseImageDigestLabel],
+	}
+}
+
+// parseImageLabels decodes the labels printed by docker image inspect, which
+// are "null" when the image has none.
+func parseImageLabels(output []byte) (map[string]string, error) {
+	labels := map[string]string{}
+	if err := json.Unmarshal(output, &labels); err != nil {
+		return nil, fmt.Errorf("invalid image labels: %w", err)
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	return labels, nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestParseImageLabels(t *testing.T) {
+	labels, err := parseImageLabels([]byte(`{"org.opencontainers.image.base.name":"docker.io/library/alpine:3.20","org.opencontainers.image.base.digest":"sha256:abc"}` + "\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := BaseImage{Name: "docker.io/library/alpine:3.20", Digest: "sha256:abc"}
+	if got := baseImageFromLabels(labels); got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	labels, err = parseImageLabels([]byte("null\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := baseImageFromLabels(labels); got != (BaseImage{}) {
+		t.Errorf("expected an empty base image, got %+v", got)
+	}
+
+	if _, err := parseImageLabels([]byte("not json")); err == nil {
+		t.Error("expected an error for invalid output")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CancelledError reports a run stopped by context cancellation, with the image
+// references pushed before it stopped and those that were still pending.
+type CancelledError struct {
+	Completed []string
+	Pending   []string
+	Err       error
+}
+
+// Error implements error.
+func (e *CancelledError) Error() string {
+	return fmt.Sprintf("release cancelled after pushing %d of %d image(s); completed %v, pending %v: %v",
+		len(e.Completed), len(e.Completed)+len(e.Pending), e.Completed, e.Pending, e.Err)
+}
+
+// Unwrap returns the context error that stopped the run.
+func (e *CancelledError) Unwrap() error {
+	return e.Err
+}
+
+// newCancelledError splits the push matrix into completed and pending references.
+func newCancelledError(err error, all, completed []string) *CancelledError {
+	done := make(map[string]bool, len(completed))
+	for _, ref := range completed {
+		done[ref] = true
+	}
+	pending := []string{}
+	for _, ref := range all {
+		if !done[ref] {
+			pending = append(pending, ref)
+		}
+	}
+	return &CancelledError{Completed: append([]string{}, completed...), Pending: pending, Err: err}
+}
+
+// errPushCapReached stops the push workers once max_pushes attempts were made.
+var errPushCapReached = errors.New("max_pushes reached")
+
+// PushCapError reports a run stopped by max_pushes, with the image references
+// pushed before the cap and those left unpushed.
+type PushCapError struct {
+	Limit     int
+	Completed []string
+	Pending   []string
+}
+
+// Error implements error.
+func (e *PushCapError) Error() string {
+	return fmt.Sprintf("max_pushes cap of %d reached after pushing %d of %d image(s); completed %v, not pushed %v",
+		e.Limit, len(e.Completed), len(e.Completed)+len(e.Pending), e.Completed, e.Pending)
+}
+
+// Unwrap returns errPushCapReached.
+func (e *PushCapError) Unwrap() error {
+	return errPushCapReached
+}
+
+// newPushCapError splits the push matrix into completed and pending references.
+func newPushCapError(limit int, all, completed []string) *PushCapError {
+	split := newCancelledError(errPushCapReached, all, completed)
+	return &PushCapError{Limit: limit, Completed: split.Completed, Pending: split.Pending}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestNewCancelledError(t *testing.T) {
+	err := newCancelledError(context.Canceled, []string{"app:1.0.0", "app:latest", "worker:1.0.0"}, []string{"app:1.0.0"})
+
+	if !slices.Equal(err.Completed, []string{"app:1.0.0"}) {
+		t.Errorf("unexpected completed: %v", err.Completed)
+	}
+	if !slices.Equal(err.Pending, []string{"app:latest", "worker:1.0.0"}) {
+		t.Errorf("unexpected pending: %v", err.Pending)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Error("expected error to unwrap to context.Canceled")
+	}
+}
+
+func TestACRPlugin_Execute_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel as soon as the first push finishes
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			if cmd.Name == "docker" && cmd.Args[0] == "push" {
+				cancel()
+			}
+			if cmd.Name == "docker" && cmd.Args[0] == "image" {
+				return []byte(presentSourceInspect), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	_, err := p.Execute(ctx, plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+			"tags":         []any{"1.0.0", "latest"},
+			"auth":         map[string]any{"method": "token", "token": "access-token"},
+		},
+		Context: plugin.ReleaseContext{Version: "1.0.0"},
+	})
+
+	var cancelled *CancelledError
+	if !errors.As(err, &cancelled) {
+		t.Fatalf("expected CancelledError, got %v", err)
+	}
+	if !slices.Equal(cancelled.Completed, []string{"myregistry.azurecr.io/myapp:1.0.0"}) {
+		t.Errorf("unexpected completed: %v", cancelled.Completed)
+	}
+	if !slices.Equal(cancelled.Pending, []string{"myregistry.azurecr.io/myapp:latest"}) {
+		t.Errorf("unexpected pending: %v", cancelled.Pending)
+	}
+}
+
+func TestNewPushCapError(t *testing.T) {
+	err := newPushCapError(1, []string{"app:1.0.0", "app:latest"}, []string{"app:1.0.0"})
+
+	if !slices.Equal(err.Completed, []string{"app:1.0.0"}) || !slices.Equal(err.Pending, []string{"app:latest"}) {
+		t.Errorf("unexpected split: completed %v, pending %v", err.Completed, err.Pending)
+	}
+	if !errors.Is(err, errPushCapReached) {
+		t.Error("expected error to unwrap to errPushCapReached")
+	}
+}
+
+func TestACRPlugin_Execute_MaxPushes(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			if cmd.Name == "docker" && cmd.Args[0] == "image" {
+				return []byte(presentSourceInspect), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+			"tags":         []any{"1.0.0", "1.0", "1", "latest", "stable", "edge"},
+			"max_pushes":   2,
+			"auth":         map[string]any{"method": "token", "token": "access-token"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	_, err := p.Execute(context.Background(), req)
+	var capErr *PushCapError
+	if !errors.As(err, &capErr) {
+		t.Fatalf("expected a PushCapError, got %v", err)
+	}
+	if !slices.Equal(capErr.Completed, []string{"myregistry.azurecr.io/myapp:1.0.0", "myregistry.azurecr.io/myapp:1.0"}) {
+		t.Errorf("unexpected completed: %v", capErr.Completed)
+	}
+	if len(capErr.Pending) != 4 {
+		t.Errorf("expected 4 pending references, got %v", capErr.Pending)
+	}
+
+	pushes := 0
+	for _, cmd := range runner.commands {
+		if cmd.Name == "docker" && cmd.Args[0] == "push" {
+			pushes++
+		}
+	}
+	if pushes != 2 {
+		t.Errorf("expected 2 push attempts, got %d", pushes)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"regexp"
+)
+
+// defaultCIVars lists the environment variables consulted for each CI template
+// variable, in order, covering GitHub Actions, GitLab CI, Azure Pipelines and
+// generic Jenkins-style names.
+var defaultCIVars = map[string][]string{
+	"build_number": {"GITHUB_RUN_NUMBER", "CI_PIPELINE_IID", "BUILD_BUILDNUMBER", "BUILD_NUMBER"},
+	"run_id":       {"GITHUB_RUN_ID", "CI_JOB_ID", "BUILD_BUILDID", "RUN_ID"},
+	"pipeline_id":  {"CI_PIPELINE_ID", "SYSTEM_DEFINITIONID", "PIPELINE_ID"},
+}
+
+// ciFieldPattern matches a reference to one of the CI template fields.
+var ciFieldPattern = regexp.MustCompile(`\.(BuildNumber|RunID|PipelineID)\b`)
+
+// resolveCIVars reads the CI template variables from the environment.
+// overrides replaces the environment variable list for a ci_vars key.
+func resolveCIVars(overrides map[string][]string) map[string]string {
+	resolved := map[string]string{}
+	for key, defaults := range defaultCIVars {
+		names := defaults
+		if custom, ok := overrides[key]; ok {
+			names = custom
+		}
+		for _, name := range names {
+			if value := os.Getenv(name); value != "" {
+				resolved[key] = value
+				break
+			}
+		}
+	}
+	return resolved
+}
+
+// referencesEmptyCIVar reports whether a tag template uses a CI variable that
+// did not resolve.
+func referencesEmptyCIVar(tmpl string, data *templateData) bool {
+	values := map[string]string{
+		"BuildNumber": data.BuildNumber,
+		"RunID":       data.RunID,
+		"PipelineID":  data.PipelineID,
+	}
+	for _, m := range ciFieldPattern.FindAllStringSubmatch(tmpl, -1) {
+		if values[m[1]] == "" {
+			return true
+		}
+	}
+	return false
+}
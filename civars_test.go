@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestResolveCIVars(t *testing.T) {
+	for _, names := range defaultCIVars {
+		for _, name := range names {
+			t.Setenv(name, "")
+		}
+	}
+	t.Setenv("CI_PIPELINE_IID", "457")
+	t.Setenv("BUILD_NUMBER", "999")
+	t.Setenv("MY_RUN", "run-1")
+
+	resolved := resolveCIVars(map[string][]string{"run_id": {"MY_RUN"}})
+
+	if resolved["build_number"] != "457" {
+		t.Errorf("expected earlier default to win, got %q", resolved["build_number"])
+	}
+	if resolved["run_id"] != "run-1" {
+		t.Errorf("expected override to be used, got %q", resolved["run_id"])
+	}
+	if _, ok := resolved["pipeline_id"]; ok {
+		t.Errorf("expected unset pipeline_id, got %q", resolved["pipeline_id"])
+	}
+}
+
+func TestProcessTemplate_CIVars(t *testing.T) {
+	p := &ACRPlugin{}
+	data := newTemplateData(&plugin.ReleaseContext{Version: "1.0.0"})
+	data.BuildNumber = "457"
+
+	if got := p.processTemplate("build-{{.BuildNumber}}", data); got != "build-457" {
+		t.Errorf("expected build-457, got %q", got)
+	}
+	if got := p.processTemplate("run-{{.RunID}}", data); got != "" {
+		t.Errorf("expected tag with unset RunID to be dropped, got %q", got)
+	}
+}
@@ -2,8 +2,9 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os/exec"
+	"strconv"
 	"strings"
 )
 
@@ -15,20 +16,92 @@ type AuthConfig struct {
 	TenantID     string
 	Username     string
 	Password     string
+	Token        string
+
+	// VerifyHelper checks the docker config for a credential helper when the
+	// method is credential_helper
+	VerifyHelper bool
 }
 
+// tokenUsername is the docker login user ACR expects with an access token.
+const tokenUsername = "00000000-0000-0000-0000-000000000000"
+
 // ACRClient provides ACR operations.
 type ACRClient struct {
-	registry string
+	registry     string
+	configDir    string
+	subscription string
+	userAgent    string
+	preserveCase bool
+	sourceCreds  SourceCredentials
+	runner       CommandRunner
+}
+
+// SourceCredentials authenticate az acr import against a source registry
+// other than the target, such as an ACR in another subscription.
+type SourceCredentials struct {
+	Username string
+	Password string
 }
 
 // NewACRClient creates a new ACR client.
 func NewACRClient(registry string) *ACRClient {
 	return &ACRClient{
 		registry: registry,
+		runner:   &ExecRunner{},
 	}
 }
 
+// SetPreserveCase keeps the registry's casing in GetRegistryURL instead of
+// lowercasing it.
+func (c *ACRClient) SetPreserveCase(preserve bool) {
+	c.preserveCase = preserve
+}
+
+// SetSourceCredentials sets the credentials Import passes for sources outside
+// this registry.
+func (c *ACRClient) SetSourceCredentials(creds SourceCredentials) {
+	c.sourceCreds = creds
+}
+
+// SetRunner replaces the runner used for az and docker commands.
+func (c *ACRClient) SetRunner(r CommandRunner) {
+	c.runner = r
+}
+
+// SetTranscript records every command this client runs.
+func (c *ACRClient) SetTranscript(t *Transcript) {
+	c.runner = &ExecRunner{Transcript: t}
+}
+
+// SetAzureConfigDir sets the AZURE_CONFIG_DIR used for every az invocation.
+func (c *ACRClient) SetAzureConfigDir(dir string) {
+	c.configDir = dir
+}
+
+// SetSubscription selects the Azure subscription used to resolve the registry.
+func (c *ACRClient) SetSubscription(subscription string) {
+	c.subscription = subscription
+}
+
+// SetUserAgent appends an identifier to the User-Agent az sends to Azure.
+func (c *ACRClient) SetUserAgent(userAgent string) {
+	c.userAgent = userAgent
+}
+
+// azCommand builds an az command honoring the configured Azure config directory
+// and user agent.
+func (c *ACRClient) azCommand(args ...string) Command {
+	cmd := Command{Name: "az", Args: args}
+	if c.configDir != "" {
+		cmd.Env = append(cmd.Env, "AZURE_CONFIG_DIR="+c.configDir)
+	}
+	if c.userAgent != "" {
+		cmd.Env = append(cmd.Env, "AZURE_HTTP_USER_AGENT="+c.userAgent)
+	}
+	return cmd
+}
+
 // Authenticate authenticates with ACR.
 func (c *ACRClient) Authenticate(ctx context.Context, auth *AuthConfig) error {
 	if auth == nil {
@@ -43,7 +116,13 @@ func (c *ACRClient) Authenticate(ctx context.Context, auth *AuthConfig) error {
 	case "admin":
 		return c.authenticateAdmin(ctx, auth)
 	case "managed_identity":
-		return c.authenticateManagedIdentity(ctx)
+		return c.authenticateManagedIdentity(ctx, auth)
+	case "existing":
+		return c.authenticateExisting(ctx)
+	case "token":
+		return c.authenticateToken(ctx, auth)
+	case "credential_helper":
+		return c.authenticateCredentialHelper(auth)
 	default:
 		return fmt.Errorf("unknown auth method: %s", auth.Method)
 	}
@@ -51,8 +130,12 @@ func (c *ACRClient) Authenticate(ctx context.Context, auth *AuthConfig) error {
 
 // authenticateAzureCLI uses Azure CLI for authentication.
 func (c *ACRClient) authenticateAzureCLI(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx, "az", "acr", "login", "--name", c.registry)
-	output, err := cmd.CombinedOutput()
+	if err := c.selectSubscription(ctx); err != nil {
+		return err
+	}
+
+	cmd := c.azCommand("acr", "login", "--name", c.registry)
+	output, err := c.runner.Run(ctx, cmd)
 	if err != nil {
 		return fmt.Errorf("az acr login failed: %w\n%s", err, string(output))
 	}
@@ -62,13 +145,13 @@ func (c *ACRClient) authenticateAzureCLI(ctx context.Context) error {
 // authenticateServicePrincipal uses service principal for authentication.
 func (c *ACRClient) authenticateServicePrincipal(ctx context.Context, auth *AuthConfig) error {
 	// Login to Azure first
-	loginCmd := exec.CommandContext(ctx, "az", "login",
+	loginCmd := c.azCommand("login",
 		"--service-principal",
 		"-u", auth.ClientID,
 		"-p", auth.ClientSecret,
 		"--tenant", auth.TenantID,
 	)
-	output, err := loginCmd.CombinedOutput()
+	output, err := c.runner.Run(ctx, loginCmd)
 	if err != nil {
 		return fmt.Errorf("azure login failed: %w\n%s", err, string(output))
 	}
@@ -79,14 +162,13 @@ func (c *ACRClient) authenticateServicePrincipal(ctx context.Context, auth *Auth
 
 // authenticateAdmin uses admin credentials for authentication.
 func (c *ACRClient) authenticateAdmin(ctx context.Context, auth *AuthConfig) error {
-	cmd := exec.CommandContext(ctx, "docker", "login",
-		c.GetRegistryURL(),
-		"-u", auth.Username,
-		"--password-stdin",
-	)
-	cmd.Stdin = strings.NewReader(auth.Password)
+	cmd := Command{
+		Name:  "docker",
+		Args:  []string{"login", c.GetRegistryURL(), "-u", auth.Username, "--password-stdin"},
+		Stdin: auth.Password,
+	}
 
-	output, err := cmd.CombinedOutput()
+	output, err := c.runner.Run(ctx, cmd)
 	if err != nil {
 		return fmt.Errorf("docker login failed: %w\n%s", err, string(output))
 	}
@@ -94,22 +176,347 @@ func (c *ACRClient) authenticateAdmin(ctx context.Context, auth *AuthConfig) err
 	return nil
 }
 
+// authenticateToken logs docker in with a pre-obtained ACR access token,
+// without involving az.
+func (c *ACRClient) authenticateToken(ctx context.Context, auth *AuthConfig) error {
+	cmd := Command{
+		Name:  "docker",
+		Args:  []string{"login", c.GetRegistryURL(), "-u", tokenUsername, "--password-stdin"},
+		Stdin: auth.Token,
+	}
+
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("docker login with access token failed: %w\n%s", err, string(output))
+	}
+	return nil
+}
+
 // authenticateManagedIdentity uses managed identity for authentication.
-func (c *ACRClient) authenticateManagedIdentity(ctx context.Context) error {
+// A client ID selects a user-assigned identity; otherwise the system-assigned
+// identity is used.
+func (c *ACRClient) authenticateManagedIdentity(ctx context.Context, auth *AuthConfig) error {
+	if auth.ClientID != "" {
+		loginCmd := c.azCommand(managedIdentityLoginArgs(auth.ClientID)...)
+		output, err := c.runner.Run(ctx, loginCmd)
+		if err != nil {
+			return fmt.Errorf("azure login with managed identity %s failed: %w\n%s", auth.ClientID, err, string(output))
+		}
+	}
+
+	if err := c.selectSubscription(ctx); err != nil {
+		return err
+	}
+
 	// Use az acr login which automatically uses managed identity
-	cmd := exec.CommandContext(ctx, "az", "acr", "login", "--name", c.registry)
-	output, err := cmd.CombinedOutput()
+	cmd := c.azCommand("acr", "login", "--name", c.registry)
+	output, err := c.runner.Run(ctx, cmd)
 	if err != nil {
 		return fmt.Errorf("az acr login with managed identity failed: %w\n%s", err, string(output))
 	}
 	return nil
 }
 
-// GetRegistryURL returns the full ACR URL.
+// managedIdentityLoginArgs returns the az arguments to log in as a user-assigned identity.
+func managedIdentityLoginArgs(clientID string) []string {
+	return []string{"login", "--identity", "--username", clientID}
+}
+
+// authenticateExisting relies on an az session established outside the plugin.
+func (c *ACRClient) authenticateExisting(ctx context.Context) error {
+	if err := c.selectSubscription(ctx); err != nil {
+		return err
+	}
+
+	cmd := c.azCommand("acr", "login", "--name", c.registry)
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		if isNoAzureSession(string(output)) {
+			return fmt.Errorf("no active Azure session; run 'az login' before releasing or use another auth method")
+		}
+		return fmt.Errorf("az acr login failed: %w\n%s", err, string(output))
+	}
+	return nil
+}
+
+// authenticateCredentialHelper skips login: the docker credential helper
+// resolves registry credentials on push. With VerifyHelper it checks that the
+// docker config names a helper for this registry.
+func (c *ACRClient) authenticateCredentialHelper(auth *AuthConfig) error {
+	if !auth.VerifyHelper {
+		return nil
+	}
+	path, err := dockerConfigPath()
+	if err != nil {
+		return fmt.Errorf("failed to locate docker config: %w", err)
+	}
+	_, err = configuredCredentialHelper(path, c.GetRegistryURL())
+	return err
+}
+
+// isNoAzureSession reports whether az output indicates that nobody is logged in.
+func isNoAzureSession(output string) bool {
+	lower := strings.ToLower(output)
+	return strings.Contains(lower, "please run 'az login'") ||
+		strings.Contains(lower, "please run \"az login\"") ||
+		strings.Contains(lower, "no subscription found")
+}
+
+// selectSubscription switches the az session to the configured subscription.
+func (c *ACRClient) selectSubscription(ctx context.Context) error {
+	if c.subscription == "" {
+		return nil
+	}
+	cmd := c.azCommand("account", "set", "--subscription", c.subscription)
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("az account set --subscription %s failed: %w\n%s", c.subscription, err, string(output))
+	}
+	return nil
+}
+
+// ActiveSubscription returns the ID of the subscription the az session uses.
+func (c *ACRClient) ActiveSubscription(ctx context.Context) (string, error) {
+	cmd := c.azCommand("account", "show", "--query", "id", "--output", "tsv")
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("az account show failed: %w\n%s", err, string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ActiveIdentity returns the user or service principal name of the az session.
+// Only the name is queried, so no token or secret is ever read.
+func (c *ACRClient) ActiveIdentity(ctx context.Context) (string, error) {
+	cmd := c.azCommand("account", "show", "--query", "user.name", "--output", "tsv")
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("az account show failed: %w\n%s", err, string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Import copies an image into this registry server-side without pulling it.
+// source is a fully qualified reference; target is a path:tag within the registry.
+// Sources in another registry are authenticated with the source credentials.
+func (c *ACRClient) Import(ctx context.Context, source, target string) error {
+	args := []string{"acr", "import",
+		"--name", c.registry,
+		"--source", source,
+		"--image", target,
+		"--force",
+	}
+	if c.sourceCreds.Username != "" && isCrossRegistrySource(source, c.registry) {
+		args = append(args, "--username", c.sourceCreds.Username, "--password", c.sourceCreds.Password)
+	}
+	cmd := c.azCommand(args...)
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("az acr import failed: %w\n%s", err, string(output))
+	}
+	return nil
+}
+
+// ManifestDigest returns the manifest digest of a path:tag or path@digest in the registry.
+func (c *ACRClient) ManifestDigest(ctx context.Context, image string) (string, error) {
+	cmd := c.azCommand("acr", "repository", "show",
+		"--name", c.registry,
+		"--image", image,
+		"--query", "digest",
+		"--output", "tsv",
+	)
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("az acr repository show failed: %w\n%s", err, string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ListRegistries returns the names of the registries the az session can see.
+func (c *ACRClient) ListRegistries(ctx context.Context) ([]string, error) {
+	cmd := c.azCommand("acr", "list", "--query", "[].name", "--output", "tsv")
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("az acr list failed: %w\n%s", err, string(output))
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// ManifestSize returns the size in bytes ACR reports for a path:tag.
+func (c *ACRClient) ManifestSize(ctx context.Context, image string) (int64, error) {
+	cmd := c.azCommand("acr", "repository", "show",
+		"--name", c.registry,
+		"--image", image,
+		"--query", "imageSize",
+		"--output", "tsv",
+	)
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		return 0, fmt.Errorf("az acr repository show failed: %w\n%s", err, string(output))
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected image size %q from az acr repository show", strings.TrimSpace(string(output)))
+	}
+	return size, nil
+}
+
+// ManifestTags returns the tags still pointing at a manifest, given as
+// repository@digest.
+func (c *ACRClient) ManifestTags(ctx context.Context, manifest string) ([]string, error) {
+	cmd := c.azCommand("acr", "manifest", "show-metadata",
+		"--registry", c.registry,
+		"--name", manifest,
+		"--query", "tags",
+		"--output", "tsv",
+	)
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("az acr manifest show-metadata failed: %w\n%s", err, string(output))
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// ListTags returns the tags of a repository in the registry.
+func (c *ACRClient) ListTags(ctx context.Context, repository string) ([]string, error) {
+	cmd := c.azCommand("acr", "repository", "show-tags",
+		"--name", c.registry,
+		"--repository", repository,
+		"--output", "tsv",
+	)
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("az acr repository show-tags failed: %w\n%s", err, string(output))
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// Untag removes a tag from the registry, keeping the manifest it points to.
+func (c *ACRClient) Untag(ctx context.Context, image string) error {
+	cmd := c.azCommand("acr", "repository", "untag",
+		"--name", c.registry,
+		"--image", image,
+	)
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("az acr repository untag failed: %w\n%s", err, string(output))
+	}
+	return nil
+}
+
+// RegistryInfo is registry metadata reported for compliance.
+type RegistryInfo struct {
+	SKU      string `json:"sku"`
+	Location string `json:"location"`
+
+	// Encryption is "enabled" when the registry uses a customer-managed key.
+	Encryption string `json:"encryption"`
+	KeyID      string `json:"key_id,omitempty"`
+}
+
+// ShowRegistry returns the registry's SKU, location and encryption status.
+func (c *ACRClient) ShowRegistry(ctx context.Context) (*RegistryInfo, error) {
+	cmd := c.azCommand("acr", "show", "--name", c.registry, "--output", "json")
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		if strings.Contains(string(output), "AuthorizationFailed") {
+			return nil, fmt.Errorf("missing permission Microsoft.ContainerRegistry/registries/read on %s", c.registry)
+		}
+		return nil, fmt.Errorf("az acr show failed: %w\n%s", err, string(output))
+	}
+	return parseRegistryInfo(output)
+}
+
+// parseRegistryInfo extracts registry metadata from az acr show output.
+func parseRegistryInfo(output []byte) (*RegistryInfo, error) {
+	var raw struct {
+		Location string `json:"location"`
+		SKU      struct {
+			Name string `json:"name"`
+		} `json:"sku"`
+		Encryption *struct {
+			Status             string `json:"status"`
+			KeyVaultProperties *struct {
+				KeyIdentifier string `json:"keyIdentifier"`
+			} `json:"keyVaultProperties"`
+		} `json:"encryption"`
+	}
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse az acr show output: %w", err)
+	}
+
+	info := &RegistryInfo{SKU: raw.SKU.Name, Location: raw.Location, Encryption: "disabled"}
+	if raw.Encryption != nil && raw.Encryption.Status != "" {
+		info.Encryption = strings.ToLower(raw.Encryption.Status)
+		if raw.Encryption.KeyVaultProperties != nil {
+			info.KeyID = raw.Encryption.KeyVaultProperties.KeyIdentifier
+		}
+	}
+	return info, nil
+}
+
+// AnonymousPullEnabled reports whether the registry allows unauthenticated pulls.
+func (c *ACRClient) AnonymousPullEnabled(ctx context.Context) (bool, error) {
+	cmd := c.azCommand("acr", "show",
+		"--name", c.registry,
+		"--query", "anonymousPullEnabled",
+		"--output", "tsv",
+	)
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		if strings.Contains(string(output), "AuthorizationFailed") {
+			return false, fmt.Errorf("missing permission Microsoft.ContainerRegistry/registries/read on %s", c.registry)
+		}
+		return false, fmt.Errorf("az acr show failed: %w\n%s", err, string(output))
+	}
+	return strings.TrimSpace(string(output)) == "true", nil
+}
+
+// SetAnonymousPull enables or disables unauthenticated pulls on the registry.
+func (c *ACRClient) SetAnonymousPull(ctx context.Context, enabled bool) error {
+	cmd := c.azCommand("acr", "update",
+		"--name", c.registry,
+		"--anonymous-pull-enabled", strconv.FormatBool(enabled),
+	)
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		if strings.Contains(string(output), "AuthorizationFailed") {
+			return fmt.Errorf("missing permission Microsoft.ContainerRegistry/registries/write on %s", c.registry)
+		}
+		return fmt.Errorf("az acr update failed: %w\n%s", err, string(output))
+	}
+	return nil
+}
+
+// SetUntaggedRetention enables the untagged-manifest retention policy, which
+// purges manifests that have been untagged for the given number of days. Only
+// Premium registries support it.
+func (c *ACRClient) SetUntaggedRetention(ctx context.Context, days int) error {
+	cmd := c.azCommand("acr", "config", "retention", "update",
+		"--registry", c.registry,
+		"--status", "enabled",
+		"--days", strconv.Itoa(days),
+		"--type", "UntaggedManifests",
+	)
+	output, err := c.runner.Run(ctx, cmd)
+	if err != nil {
+		if strings.Contains(string(output), "AuthorizationFailed") {
+			return fmt.Errorf("missing permission Microsoft.ContainerRegistry/registries/write on %s", c.registry)
+		}
+		return fmt.Errorf("az acr config retention update failed: %w\n%s", err, string(output))
+	}
+	return nil
+}
+
+// GetRegistryURL returns the full ACR URL. Registry hosts are case-insensitive,
+// so it is lowercased unless SetPreserveCase was called.
 func (c *ACRClient) GetRegistryURL() string {
-	// If registry already has .azurecr.io, return as-is
-	if strings.HasSuffix(c.registry, ".azurecr.io") {
-		return c.registry
+	url := c.registry
+	if !strings.HasSuffix(strings.ToLower(url), ".azurecr.io") {
+		url = fmt.Sprintf("%s.azurecr.io", url)
+	}
+	if !c.preserveCase {
+		url = strings.ToLower(url)
 	}
-	return fmt.Sprintf("%s.azurecr.io", c.registry)
+	return url
 }
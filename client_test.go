@@ -1,6 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -31,9 +37,10 @@ func TestNewACRClient(t *testing.T) {
 
 func TestACRClient_GetRegistryURL(t *testing.T) {
 	tests := []struct {
-		name     string
-		registry string
-		expected string
+		name         string
+		registry     string
+		preserveCase bool
+		expected     string
 	}{
 		{
 			name:     "simple name",
@@ -50,11 +57,34 @@ func TestACRClient_GetRegistryURL(t *testing.T) {
 			registry: "contoso",
 			expected: "contoso.azurecr.io",
 		},
+		{
+			name:     "mixed case name",
+			registry: "MyRegistry",
+			expected: "myregistry.azurecr.io",
+		},
+		{
+			name:     "mixed case suffix",
+			registry: "MyRegistry.AzureCR.io",
+			expected: "myregistry.azurecr.io",
+		},
+		{
+			name:         "mixed case preserved",
+			registry:     "MyRegistry",
+			preserveCase: true,
+			expected:     "MyRegistry.azurecr.io",
+		},
+		{
+			name:         "mixed case suffix preserved",
+			registry:     "MyRegistry.AzureCR.io",
+			preserveCase: true,
+			expected:     "MyRegistry.AzureCR.io",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			client := NewACRClient(tt.registry)
+			client.SetPreserveCase(tt.preserveCase)
 			result := client.GetRegistryURL()
 
 			if result != tt.expected {
@@ -107,3 +137,424 @@ func TestAuthConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestACRClient_AzCommandConfigDir(t *testing.T) {
+	client := NewACRClient("myregistry")
+
+	cmd := client.azCommand("acr", "login")
+	if cmd.Env != nil {
+		t.Error("expected inherited environment without a config dir")
+	}
+
+	client.SetAzureConfigDir("/tmp/azure-run")
+	cmd = client.azCommand("acr", "login")
+	if !slices.Contains(cmd.Env, "AZURE_CONFIG_DIR=/tmp/azure-run") {
+		t.Error("expected AZURE_CONFIG_DIR in command environment")
+	}
+}
+
+func TestACRClient_AzCommandUserAgent(t *testing.T) {
+	client := NewACRClient("myregistry")
+	client.SetUserAgent("relicta-plugin-acr/1.2.3")
+
+	cmd := client.azCommand("acr", "login")
+	if !slices.Contains(cmd.Env, "AZURE_HTTP_USER_AGENT=relicta-plugin-acr/1.2.3") {
+		t.Errorf("expected AZURE_HTTP_USER_AGENT in command environment, got %v", cmd.Env)
+	}
+}
+
+func TestACRClient_Authenticate_Commands(t *testing.T) {
+	tests := []struct {
+		name     string
+		auth     *AuthConfig
+		expected []Command
+	}{
+		{
+			name:     "default",
+			auth:     nil,
+			expected: []Command{{Name: "az", Args: []string{"acr", "login", "--name", "myregistry"}}},
+		},
+		{
+			name:     "azure_cli",
+			auth:     &AuthConfig{Method: "azure_cli"},
+			expected: []Command{{Name: "az", Args: []string{"acr", "login", "--name", "myregistry"}}},
+		},
+		{
+			name: "service_principal",
+			auth: &AuthConfig{
+				Method:       "service_principal",
+				ClientID:     "client-id",
+				ClientSecret: "client-secret",
+				TenantID:     "tenant-id",
+			},
+			expected: []Command{
+				{Name: "az", Args: []string{
+					"login", "--service-principal",
+					"-u", "client-id",
+					"-p", "client-secret",
+					"--tenant", "tenant-id",
+				}},
+				{Name: "az", Args: []string{"acr", "login", "--name", "myregistry"}},
+			},
+		},
+		{
+			name: "admin",
+			auth: &AuthConfig{Method: "admin", Username: "admin", Password: "password"},
+			expected: []Command{{
+				Name:  "docker",
+				Args:  []string{"login", "myregistry.azurecr.io", "-u", "admin", "--password-stdin"},
+				Stdin: "password",
+			}},
+		},
+		{
+			name: "token",
+			auth: &AuthConfig{Method: "token", Token: "access-token"},
+			expected: []Command{{
+				Name:  "docker",
+				Args:  []string{"login", "myregistry.azurecr.io", "-u", "00000000-0000-0000-0000-000000000000", "--password-stdin"},
+				Stdin: "access-token",
+			}},
+		},
+		{
+			name:     "managed_identity",
+			auth:     &AuthConfig{Method: "managed_identity"},
+			expected: []Command{{Name: "az", Args: []string{"acr", "login", "--name", "myregistry"}}},
+		},
+		{
+			name: "managed_identity with client id",
+			auth: &AuthConfig{Method: "managed_identity", ClientID: "identity-id"},
+			expected: []Command{
+				{Name: "az", Args: []string{"login", "--identity", "--username", "identity-id"}},
+				{Name: "az", Args: []string{"acr", "login", "--name", "myregistry"}},
+			},
+		},
+		{
+			name:     "existing",
+			auth:     &AuthConfig{Method: "existing"},
+			expected: []Command{{Name: "az", Args: []string{"acr", "login", "--name", "myregistry"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			client := NewACRClient("myregistry")
+			client.SetRunner(runner)
+
+			if err := client.Authenticate(context.Background(), tt.auth); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			runner.assertCommands(t, tt.expected)
+		})
+	}
+}
+
+func TestACRClient_Authenticate_Subscription(t *testing.T) {
+	runner := &fakeRunner{}
+	client := NewACRClient("myregistry")
+	client.SetRunner(runner)
+	client.SetSubscription("prod")
+
+	if err := client.Authenticate(context.Background(), &AuthConfig{Method: "azure_cli"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runner.assertCommands(t, []Command{
+		{Name: "az", Args: []string{"account", "set", "--subscription", "prod"}},
+		{Name: "az", Args: []string{"acr", "login", "--name", "myregistry"}},
+	})
+}
+
+func TestACRClient_Authenticate_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		auth        *AuthConfig
+		output      string
+		errContains string
+	}{
+		{
+			name:        "unknown method",
+			auth:        &AuthConfig{Method: "bogus"},
+			errContains: "unknown auth method: bogus",
+		},
+		{
+			name:        "service principal login failure",
+			auth:        &AuthConfig{Method: "service_principal"},
+			output:      "AADSTS7000215: Invalid client secret provided.",
+			errContains: "azure login failed",
+		},
+		{
+			name:        "existing without session",
+			auth:        &AuthConfig{Method: "existing"},
+			output:      "ERROR: Please run 'az login' to setup account.",
+			errContains: "no active Azure session",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				respond: func(Command) ([]byte, error) {
+					return []byte(tt.output), errors.New("exit status 1")
+				},
+			}
+			client := NewACRClient("myregistry")
+			client.SetRunner(runner)
+
+			err := client.Authenticate(context.Background(), tt.auth)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("expected error containing %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
+
+func TestManagedIdentityLoginArgs(t *testing.T) {
+	args := managedIdentityLoginArgs("11111111-2222-3333-4444-555555555555")
+	expected := []string{"login", "--identity", "--username", "11111111-2222-3333-4444-555555555555"}
+	if !slices.Equal(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+}
+
+func TestIsNoAzureSession(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected bool
+	}{
+		{name: "not logged in", output: "ERROR: Please run 'az login' to setup account.", expected: true},
+		{name: "no subscriptions", output: "ERROR: No subscription found. Run 'az account set' to select a subscription.", expected: true},
+		{name: "registry missing", output: "ERROR: The resource with name 'myregistry' and type 'Microsoft.ContainerRegistry/registries' could not be found", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNoAzureSession(tt.output); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestACRClient_TagCommands(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			if cmd.Args[2] == "show-tags" {
+				return []byte("1.0.0\n1.1.0\nlatest\n"), nil
+			}
+			if cmd.Args[2] == "show" {
+				return []byte("52428800\n"), nil
+			}
+			return nil, nil
+		},
+	}
+	client := NewACRClient("myregistry")
+	client.SetRunner(runner)
+
+	tags, err := client.ListTags(context.Background(), "team/app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(tags, []string{"1.0.0", "1.1.0", "latest"}) {
+		t.Errorf("unexpected tags %v", tags)
+	}
+
+	size, err := client.ManifestSize(context.Background(), "team/app:1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if size != 52428800 {
+		t.Errorf("expected size 52428800, got %d", size)
+	}
+
+	runner.assertCommands(t, []Command{
+		{Name: "az", Args: []string{"acr", "repository", "show-tags", "--name", "myregistry", "--repository", "team/app", "--output", "tsv"}},
+		{Name: "az", Args: []string{"acr", "repository", "show", "--name", "myregistry", "--image", "team/app:1.0.0", "--query", "imageSize", "--output", "tsv"}},
+	})
+}
+
+func TestACRClient_Untag(t *testing.T) {
+	runner := &fakeRunner{}
+	client := NewACRClient("myregistry")
+	client.SetRunner(runner)
+
+	if err := client.Untag(context.Background(), "team/app:1.0.0-tmp-0a1b2c3d4e5f"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runner.assertCommands(t, []Command{
+		{Name: "az", Args: []string{"acr", "repository", "untag", "--name", "myregistry", "--image", "team/app:1.0.0-tmp-0a1b2c3d4e5f"}},
+	})
+}
+
+func TestACRClient_ActiveIdentity(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			return []byte("ci@example.com\n"), nil
+		},
+	}
+	client := NewACRClient("myregistry")
+	client.SetRunner(runner)
+
+	identity, err := client.ActiveIdentity(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identity != "ci@example.com" {
+		t.Errorf("expected ci@example.com, got %q", identity)
+	}
+	runner.assertCommands(t, []Command{
+		{Name: "az", Args: []string{"account", "show", "--query", "user.name", "--output", "tsv"}},
+	})
+}
+
+func TestACRClient_Import_SourceCredentials(t *testing.T) {
+	runner := &fakeRunner{}
+	client := NewACRClient("myregistry")
+	client.SetRunner(runner)
+	client.SetSourceCredentials(SourceCredentials{Username: "puller", Password: "secret"})
+
+	ctx := context.Background()
+	if err := client.Import(ctx, "shared.azurecr.io/base/app:1.0", "app:1.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.Import(ctx, "myregistry.azurecr.io/app@sha256:abc", "app:latest"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runner.assertCommands(t, []Command{
+		{Name: "az", Args: []string{"acr", "import", "--name", "myregistry", "--source", "shared.azurecr.io/base/app:1.0", "--image", "app:1.0", "--force", "--username", "puller", "--password", "secret"}},
+		{Name: "az", Args: []string{"acr", "import", "--name", "myregistry", "--source", "myregistry.azurecr.io/app@sha256:abc", "--image", "app:latest", "--force"}},
+	})
+}
+
+func TestACRClient_Authenticate_CredentialHelper(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	runner := &fakeRunner{}
+	client := NewACRClient("myregistry")
+	client.SetRunner(runner)
+
+	ctx := context.Background()
+	if err := client.Authenticate(ctx, &AuthConfig{Method: "credential_helper"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.Authenticate(ctx, &AuthConfig{Method: "credential_helper", VerifyHelper: true}); err == nil {
+		t.Error("expected verification to fail without a docker config")
+	}
+
+	config := `{"credHelpers": {"myregistry.azurecr.io": "acr-env"}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Authenticate(ctx, &AuthConfig{Method: "credential_helper", VerifyHelper: true}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	runner.assertCommands(t, nil)
+}
+
+func TestParseRegistryInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected RegistryInfo
+	}{
+		{
+			name:     "platform-managed keys",
+			output:   `{"location":"westeurope","sku":{"name":"Standard","tier":"Standard"},"encryption":{"status":"disabled","keyVaultProperties":null}}`,
+			expected: RegistryInfo{SKU: "Standard", Location: "westeurope", Encryption: "disabled"},
+		},
+		{
+			name: "customer-managed key",
+			output: `{"location":"eastus","sku":{"name":"Premium"},"encryption":{"status":"enabled",` +
+				`"keyVaultProperties":{"keyIdentifier":"https://vault.vault.azure.net/keys/acr"}}}`,
+			expected: RegistryInfo{SKU: "Premium", Location: "eastus", Encryption: "enabled", KeyID: "https://vault.vault.azure.net/keys/acr"},
+		},
+		{
+			name:     "no encryption block",
+			output:   `{"location":"eastus","sku":{"name":"Basic"}}`,
+			expected: RegistryInfo{SKU: "Basic", Location: "eastus", Encryption: "disabled"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := parseRegistryInfo([]byte(tt.output))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *info != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, *info)
+			}
+		})
+	}
+}
+
+func TestACRClient_AnonymousPull(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			return []byte("true\n"), nil
+		},
+	}
+	client := NewACRClient("myregistry")
+	client.SetRunner(runner)
+
+	enabled, err := client.AnonymousPullEnabled(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !enabled {
+		t.Error("expected anonymous pull to be enabled")
+	}
+	if err := client.SetAnonymousPull(context.Background(), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runner.assertCommands(t, []Command{
+		{Name: "az", Args: []string{"acr", "show", "--name", "myregistry", "--query", "anonymousPullEnabled", "--output", "tsv"}},
+		{Name: "az", Args: []string{"acr", "update", "--name", "myregistry", "--anonymous-pull-enabled", "false"}},
+	})
+}
+
+func TestACRClient_ManifestTagsAndRetention(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			if slices.Contains(cmd.Args, "show-metadata") {
+				return []byte("1.1\tstable\n"), nil
+			}
+			return nil, nil
+		},
+	}
+	client := NewACRClient("myregistry")
+	client.SetRunner(runner)
+
+	tags, err := client.ManifestTags(context.Background(), "team/app@sha256:abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(tags, []string{"1.1", "stable"}) {
+		t.Errorf("unexpected tags %v", tags)
+	}
+	if err := client.SetUntaggedRetention(context.Background(), 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runner.assertCommands(t, []Command{
+		{Name: "az", Args: []string{"acr", "manifest", "show-metadata", "--registry", "myregistry", "--name", "team/app@sha256:abc", "--query", "tags", "--output", "tsv"}},
+		{Name: "az", Args: []string{"acr", "config", "retention", "update", "--registry", "myregistry", "--status", "enabled", "--days", "7", "--type", "UntaggedManifests"}},
+	})
+}
+
+func TestACRClient_ShowRegistry_Unauthorized(t *testing.T) {
+	client := NewACRClient("myregistry")
+	client.SetRunner(&fakeRunner{
+		respond: func(Command) ([]byte, error) {
+			return []byte("(AuthorizationFailed) The client does not have authorization"), errors.New("exit status 1")
+		},
+	})
+
+	_, err := client.ShowRegistry(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "registries/read") {
+		t.Errorf("expected permission error, got %v", err)
+	}
+}
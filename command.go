@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTranscriptOutput caps the command output stored per transcript entry.
+const maxTranscriptOutput = 4096
+
+// secretFlags are command-line flags whose following argument is always redacted.
+var secretFlags = map[string]bool{
+	"-p":              true,
+	"--password":      true,
+	"--client-secret": true,
+}
+
+// Transcript appends one JSON line per external command to a file.
+type Transcript struct {
+	mu      sync.Mutex
+	file    *os.File
+	secrets []string
+}
+
+// transcriptEntry is a single recorded command.
+type transcriptEntry struct {
+	Command    []string  `json:"command"`
+	Start      time.Time `json:"start"`
+	DurationMS int64     `json:"duration_ms"`
+	ExitCode   int       `json:"exit_code"`
+	Output     string    `json:"output"`
+}
+
+// OpenTranscript opens a transcript file for appending.
+func OpenTranscript(path string) (*Transcript, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript file: %w", err)
+	}
+	return &Transcript{file: file}, nil
+}
+
+// Redact registers secret values that must never appear in the transcript.
+func (t *Transcript) Redact(secrets ...string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, s := range secrets {
+		if s != "" {
+			t.secrets = append(t.secrets, s)
+		}
+	}
+}
+
+// Close closes the transcript file.
+func (t *Transcript) Close() error {
+	if t == nil {
+		return nil
+	}
+	return t.file.Close()
+}
+
+// record writes a command and its result to the transcript.
+func (t *Transcript) record(args []string, start time.Time, output []byte, err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := transcriptEntry{
+		Command:    t.redactArgs(args),
+		Start:      start.UTC(),
+		DurationMS: time.Since(start).Milliseconds(),
+		ExitCode:   exitCode(err),
+		Output:     t.redact(truncate(string(output), maxTranscriptOutput)),
+	}
+
+	line, marshalErr := json.Marshal(entry)
+	if marshalErr != nil {
+		return
+	}
+	if _, writeErr := t.file.Write(append(line, '\n')); writeErr != nil {
+		warnf("failed to write transcript entry: %v", writeErr)
+	}
+}
+
+// redactArgs redacts secret flag values and registered secrets from arguments.
+// It must be called before redacting the output of the same command.
+func (t *Transcript) redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		if i > 0 && secretFlags[args[i-1]] {
+			// Remember the value so it is also redacted from output
+			if arg != "" {
+				t.secrets = append(t.secrets, arg)
+			}
+			redacted[i] = "***"
+			continue
+		}
+		redacted[i] = t.redact(arg)
+	}
+	return redacted
+}
+
+// redact replaces registered secrets in s.
+func (t *Transcript) redact(s string) string {
+	for _, secret := range t.secrets {
+		s = strings.ReplaceAll(s, secret, "***")
+	}
+	return s
+}
+
+// Command is an external command invocation.
+type Command struct {
+	Name string
+	Args []string
+
+	// Env holds variables added to the inherited environment.
+	Env []string
+
+	// Stdin is written to the command's standard input when set.
+	Stdin string
+
+	// Input streams to the command's standard input, taking precedence over Stdin.
+	Input io.Reader
+
+	// Dir is the working directory; empty means the current directory.
+	Dir string
+}
+
+// CommandRunner runs external commands and returns their combined output.
+type CommandRunner interface {
+	Run(ctx context.Context, cmd Command) ([]byte, error)
+}
+
+// ExecRunner runs commands on the host, recording them in an optional transcript.
+type ExecRunner struct {
+	Transcript *Transcript
+
+	// Env holds variables added to every command's environment.
+	Env []string
+}
+
+// Run implements CommandRunner.
+func (r *ExecRunner) Run(ctx context.Context, c Command) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	if env := append(append([]string{}, r.Env...), c.Env...); len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if c.Input != nil {
+		cmd.Stdin = c.Input
+	} else if c.Stdin != "" {
+		cmd.Stdin = strings.NewReader(c.Stdin)
+	}
+	cmd.Dir = c.Dir
+	return runCommand(r.Transcript, cmd)
+}
+
+// runCommand runs cmd and returns its combined output, recording it in the
+// transcript when one is set.
+func runCommand(t *Transcript, cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	t.record(cmd.Args, start, output, err)
+	return output, err
+}
+
+// exitCode returns the process exit code for a command error.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// truncate shortens s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "...(truncated)"
+}
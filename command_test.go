@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTranscript_RecordsRedactedCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+
+	transcript, err := OpenTranscript(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	transcript.Redact("s3cret")
+
+	cmd := exec.CommandContext(context.Background(), "echo", "login", "-p", "hunter2", "token=s3cret")
+	if _, err := runCommand(transcript, cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := transcript.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(string(data), "hunter2") || strings.Contains(string(data), "s3cret") {
+		t.Errorf("expected secrets to be redacted, got %s", data)
+	}
+
+	var entry transcriptEntry
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &entry); err != nil {
+		t.Fatalf("expected one JSON line: %v", err)
+	}
+
+	if entry.Command[0] != "echo" || entry.Command[3] != "***" {
+		t.Errorf("unexpected recorded command: %v", entry.Command)
+	}
+	if entry.ExitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", entry.ExitCode)
+	}
+}
+
+func TestRunCommand_NilTranscript(t *testing.T) {
+	cmd := exec.CommandContext(context.Background(), "echo", "hello")
+	output, err := runCommand(nil, cmd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != "hello" {
+		t.Errorf("expected output 'hello', got %q", output)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("short", 10); got != "short" {
+		t.Errorf("expected unchanged string, got %q", got)
+	}
+	if got := truncate("0123456789", 4); !strings.HasPrefix(got, "0123") || !strings.HasSuffix(got, "(truncated)") {
+		t.Errorf("expected truncated string, got %q", got)
+	}
+}
+
+// fakeRunner records commands instead of running them.
+type fakeRunner struct {
+	commands []Command
+	respond  func(Command) ([]byte, error)
+}
+
+// Run implements CommandRunner.
+func (f *fakeRunner) Run(_ context.Context, cmd Command) ([]byte, error) {
+	f.commands = append(f.commands, cmd)
+	if f.respond != nil {
+		return f.respond(cmd)
+	}
+	return nil, nil
+}
+
+// assertCommands checks the recorded commands against expected.
+func (f *fakeRunner) assertCommands(t *testing.T, expected []Command) {
+	t.Helper()
+	if len(f.commands) != len(expected) {
+		t.Fatalf("expected %d commands, got %d: %+v", len(expected), len(f.commands), f.commands)
+	}
+	for i, want := range expected {
+		got := f.commands[i]
+		if got.Name != want.Name || !slices.Equal(got.Args, want.Args) || got.Stdin != want.Stdin {
+			t.Errorf("command %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+}
+
+func TestExecRunner_Run(t *testing.T) {
+	runner := &ExecRunner{}
+
+	output, err := runner.Run(context.Background(), Command{Name: "cat", Stdin: "from stdin"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(output) != "from stdin" {
+		t.Errorf("expected stdin to be passed through, got %q", output)
+	}
+
+	output, err = runner.Run(context.Background(), Command{
+		Name: "sh",
+		Args: []string{"-c", "printf %s \"$RELICTA_TEST_VAR\""},
+		Env:  []string{"RELICTA_TEST_VAR=set"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(output) != "set" {
+		t.Errorf("expected extra environment variable, got %q", output)
+	}
+}
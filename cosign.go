@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// CosignClient provides cosign CLI operations.
+type CosignClient struct {
+	runner CommandRunner
+}
+
+// NewCosignClient creates a new cosign client.
+func NewCosignClient() *CosignClient {
+	return &CosignClient{runner: &ExecRunner{}}
+}
+
+// SetRunner replaces the runner used for cosign commands.
+func (c *CosignClient) SetRunner(r CommandRunner) {
+	c.runner = r
+}
+
+// Attest signs predicate as an attestation of predicateType and attaches it to
+// subject. An empty key signs keylessly.
+func (c *CosignClient) Attest(ctx context.Context, subject string, predicate []byte, predicateType, key string) error {
+	file, err := os.CreateTemp("", "relicta-acr-predicate-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create predicate file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(predicate); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write predicate file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write predicate file: %w", err)
+	}
+
+	args := []string{"attest", "--yes", "--type", predicateType, "--predicate", file.Name()}
+	if key != "" {
+		args = append(args, "--key", key)
+	}
+	args = append(args, subject)
+
+	output, err := c.runner.Run(ctx, Command{Name: "cosign", Args: args})
+	if err != nil {
+		return fmt.Errorf("cosign attest failed: %w\n%s", err, string(output))
+	}
+	return nil
+}
+
+// attestationTag returns the tag cosign stores attestations for digest under.
+func attestationTag(digest string) string {
+	return "sha256-" + digestHex(digest) + ".att"
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestCosignClient_Attest(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		expected []string
+	}{
+		{name: "keyless", expected: []string{"myregistry.azurecr.io/app@sha256:abc"}},
+		{name: "key", key: "azurekms://vault/key", expected: []string{"--key", "azurekms://vault/key", "myregistry.azurecr.io/app@sha256:abc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var predicate string
+			runner := &fakeRunner{
+				respond: func(cmd Command) ([]byte, error) {
+					data, err := os.ReadFile(cmd.Args[5])
+					predicate = string(data)
+					return nil, err
+				},
+			}
+			client := NewCosignClient()
+			client.SetRunner(runner)
+
+			err := client.Attest(context.Background(), "myregistry.azurecr.io/app@sha256:abc", []byte(`{"ok":true}`), "slsaprovenance1", tt.key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if predicate != `{"ok":true}` {
+				t.Errorf("expected predicate file to hold the predicate, got %q", predicate)
+			}
+
+			args := runner.commands[0].Args
+			if !slices.Equal(args[:5], []string{"attest", "--yes", "--type", "slsaprovenance1", "--predicate"}) {
+				t.Errorf("unexpected args %v", args)
+			}
+			if !slices.Equal(args[6:], tt.expected) {
+				t.Errorf("expected trailing args %v, got %v", tt.expected, args[6:])
+			}
+		})
+	}
+}
+
+func TestAttestationTag(t *testing.T) {
+	if got := attestationTag("sha256:abc"); got != "sha256-abc.att" {
+		t.Errorf("unexpected attestation tag %q", got)
+	}
+}
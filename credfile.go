@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readCredentialFile reads a secret mounted as a file, such as a Kubernetes or
+// Docker secret, dropping the trailing newline most tools write.
+func readCredentialFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// loadCredentialFiles replaces credentials with the contents of their *_file
+// paths, which take precedence over inline and env values. Errors name the
+// config key and path but never the contents.
+func (c *Config) loadCredentialFiles() error {
+	for _, file := range []struct {
+		key   string
+		path  string
+		value *string
+	}{
+		{"client_secret_file", c.ClientSecretFile, &c.ClientSecret},
+		{"password_file", c.PasswordFile, &c.Password},
+		{"token_file", c.TokenFile, &c.Token},
+	} {
+		if file.path == "" {
+			continue
+		}
+		value, err := readCredentialFile(file.path)
+		if err != nil {
+			return fmt.Errorf("failed to read auth.%s: %w", file.key, err)
+		}
+		*file.value = value
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadCredentialFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "plain", content: "s3cret", expected: "s3cret"},
+		{name: "trailing newline", content: "s3cret\n", expected: "s3cret"},
+		{name: "crlf", content: "s3cret\r\n", expected: "s3cret"},
+		{name: "inner whitespace kept", content: " s3 cret\n", expected: " s3 cret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "secret")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readCredentialFile(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestConfig_LoadCredentialFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "password")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{Password: "inline", PasswordFile: path, Token: "inline-token"}
+	if err := cfg.loadCredentialFiles(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Password != "from-file" {
+		t.Errorf("expected the file to take precedence, got %q", cfg.Password)
+	}
+	if cfg.Token != "inline-token" {
+		t.Errorf("expected token without token_file to be kept, got %q", cfg.Token)
+	}
+
+	cfg = &Config{TokenFile: filepath.Join(dir, "missing")}
+	err := cfg.loadCredentialFiles()
+	if err == nil || !strings.Contains(err.Error(), "auth.token_file") {
+		t.Errorf("expected an error naming auth.token_file, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// usesAzSession reports whether an auth method works through an az session,
+// so subscription and identity lookups can use it.
+func usesAzSession(method string) bool {
+	switch method {
+	case "admin", "token", "credential_helper":
+		return false
+	}
+	return true
+}
+
+// dockerConfigPath returns the docker client config file, honoring DOCKER_CONFIG.
+func dockerConfigPath() (string, error) {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".docker", "config.json"), nil
+}
+
+// configuredCredentialHelper returns the credential helper the docker config
+// at path uses for server: its credHelpers entry, else credsStore.
+func configuredCredentialHelper(path, server string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("docker config %s does not exist", path)
+		}
+		return "", err
+	}
+	var config struct {
+		CredHelpers map[string]string `json:"credHelpers"`
+		CredsStore  string            `json:"credsStore"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", fmt.Errorf("failed to parse docker config %s: %w", path, err)
+	}
+	for host, helper := range config.CredHelpers {
+		if strings.EqualFold(host, server) && helper != "" {
+			return helper, nil
+		}
+	}
+	if config.CredsStore != "" {
+		return config.CredsStore, nil
+	}
+	return "", fmt.Errorf("docker config %s has no credHelpers entry for %s and no credsStore", path, server)
+}
+
+// isAuthFailure reports whether docker output indicates rejected or missing
+// registry credentials.
+func isAuthFailure(output string) bool {
+	lower := strings.ToLower(output)
+	return strings.Contains(lower, "unauthorized") ||
+		strings.Contains(lower, "authentication required") ||
+		strings.Contains(lower, "no basic auth credentials") ||
+		strings.Contains(lower, "denied")
+}
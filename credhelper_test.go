@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUsesAzSession(t *testing.T) {
+	for method, expected := range map[string]bool{
+		"azure_cli":         true,
+		"existing":          true,
+		"managed_identity":  true,
+		"admin":             false,
+		"token":             false,
+		"credential_helper": false,
+	} {
+		if got := usesAzSession(method); got != expected {
+			t.Errorf("usesAzSession(%q) = %v, expected %v", method, got, expected)
+		}
+	}
+}
+
+func TestConfiguredCredentialHelper(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+		wantErr  bool
+	}{
+		{name: "registry helper", config: `{"credHelpers": {"MyRegistry.azurecr.io": "acr-env"}, "credsStore": "desktop"}`, expected: "acr-env"},
+		{name: "creds store", config: `{"credHelpers": {"other.azurecr.io": "acr-env"}, "credsStore": "desktop"}`, expected: "desktop"},
+		{name: "no helper", config: `{"auths": {}}`, wantErr: true},
+		{name: "invalid", config: `{`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			helper, err := configuredCredentialHelper(path, "myregistry.azurecr.io")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if helper != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, helper)
+			}
+		})
+	}
+
+	if _, err := configuredCredentialHelper(filepath.Join(t.TempDir(), "missing.json"), "myregistry.azurecr.io"); err == nil {
+		t.Error("expected an error for a missing docker config")
+	}
+}
+
+func TestDockerConfigPath(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", "/etc/docker-ci")
+	path, err := dockerConfigPath()
+	if err != nil || path != "/etc/docker-ci/config.json" {
+		t.Errorf("expected /etc/docker-ci/config.json, got %q (%v)", path, err)
+	}
+}
+
+func TestIsAuthFailure(t *testing.T) {
+	for output, expected := range map[string]bool{
+		"unauthorized: authentication required":              true,
+		"denied: requested access to the resource is denied": true,
+		"no basic auth credentials":                          true,
+		"connection reset by peer":                           false,
+	} {
+		if got := isAuthFailure(output); got != expected {
+			t.Errorf("isAuthFailure(%q) = %v, expected %v", output, got, expected)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// defaultGitDescribeCommand is run when git_describe sets neither value nor command.
+const defaultGitDescribeCommand = "git describe --tags --long --always"
+
+// GitDescribeConfig supplies the {{.GitDescribe}} template value.
+type GitDescribeConfig struct {
+	// Value is used as-is when set.
+	Value string
+
+	// Command is split on whitespace and run once per Execute otherwise.
+	Command string
+}
+
+// resolveGitDescribe returns the sanitized describe string from the configured
+// value or by running the configured command.
+func resolveGitDescribe(ctx context.Context, runner CommandRunner, cfg GitDescribeConfig) (string, error) {
+	if cfg.Value != "" {
+		return sanitizeTag(cfg.Value), nil
+	}
+	fields := strings.Fields(cfg.Command)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	output, err := runner.Run(ctx, Command{Name: fields[0], Args: fields[1:]})
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w\n%s", cfg.Command, err, string(output))
+	}
+	return sanitizeTag(string(output)), nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResolveGitDescribe(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      GitDescribeConfig
+		output   string
+		err      error
+		expected string
+		wantErr  bool
+	}{
+		{name: "value", cfg: GitDescribeConfig{Value: "v1.2.3-14-gabc1234"}, expected: "v1.2.3-14-gabc1234"},
+		{name: "command", cfg: GitDescribeConfig{Command: defaultGitDescribeCommand}, output: "v1.2.3-14-gabc1234\n", expected: "v1.2.3-14-gabc1234"},
+		{name: "sanitized", cfg: GitDescribeConfig{Value: "release/1.2.3-2-gabc+dirty"}, expected: "release-1.2.3-2-gabc-dirty"},
+		{name: "command fails", cfg: GitDescribeConfig{Command: "git describe"}, err: errors.New("exit status 128"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				respond: func(Command) ([]byte, error) { return []byte(tt.output), tt.err },
+			}
+			got, err := resolveGitDescribe(context.Background(), runner, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+			if tt.cfg.Value != "" && len(runner.commands) != 0 {
+				t.Errorf("expected no command for a supplied value, ran %v", runner.commands)
+			}
+		})
+	}
+}
+
+func TestResolveGitDescribe_RunsCommand(t *testing.T) {
+	runner := &fakeRunner{}
+	if _, err := resolveGitDescribe(context.Background(), runner, GitDescribeConfig{Command: defaultGitDescribeCommand}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runner.assertCommands(t, []Command{{Name: "git", Args: []string{"describe", "--tags", "--long", "--always"}}})
+}
@@ -2,42 +2,397 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"os/exec"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
+// pushDigestPattern matches the digest line printed by docker push.
+var pushDigestPattern = regexp.MustCompile(`digest: (sha256:[0-9a-f]{64})`)
+
+// RateLimitError is returned when a registry rejects a pull because of rate limiting.
+type RateLimitError struct {
+	Image  string
+	Output string
+}
+
+// Error implements the error interface.
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("pull of %s was rate limited by the source registry; "+
+		"authenticate to the source registry with 'docker login' before running to raise the limit\n%s",
+		e.Image, e.Output)
+}
+
+// DaemonUnavailableError is returned when the Docker daemon cannot be reached.
+type DaemonUnavailableError struct {
+	Host   string
+	Output string
+}
+
+// Error implements the error interface.
+func (e *DaemonUnavailableError) Error() string {
+	host := e.Host
+	if host == "" {
+		host = "default socket"
+	}
+	return fmt.Sprintf("Docker daemon unavailable (DOCKER_HOST=%s); start Docker or point DOCKER_HOST at a running daemon, "+
+		"or set docker_preflight: false to skip this check\n%s", host, e.Output)
+}
+
+// SourceImageError is returned when the source image is missing locally or its
+// content is incomplete, such as a dangling reference left behind by a prune.
+type SourceImageError struct {
+	Image  string
+	Reason string
+	Output string
+}
+
+// Error implements the error interface.
+func (e *SourceImageError) Error() string {
+	return fmt.Sprintf("source image %s %s; rebuild or re-pull it (or set pull_source: true) before releasing\n%s",
+		e.Image, e.Reason, e.Output)
+}
+
 // DockerClient provides Docker CLI operations.
-type DockerClient struct{}
+type DockerClient struct {
+	runner CommandRunner
+}
 
 // NewDockerClient creates a new Docker client.
 func NewDockerClient() *DockerClient {
-	return &DockerClient{}
+	return &DockerClient{runner: &ExecRunner{}}
+}
+
+// SetRunner replaces the runner used for docker commands.
+func (d *DockerClient) SetRunner(r CommandRunner) {
+	d.runner = r
+}
+
+// SetTranscript records every command this client runs.
+func (d *DockerClient) SetTranscript(t *Transcript) {
+	d.runner = &ExecRunner{Transcript: t}
+}
+
+// Load loads images from a docker save stream and returns the references it loaded.
+func (d *DockerClient) Load(ctx context.Context, r io.Reader) ([]string, error) {
+	cmd := Command{Name: "docker", Args: []string{"load"}, Input: r}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		lower := strings.ToLower(string(output))
+		switch {
+		case strings.Contains(lower, "stdin is empty"), strings.Contains(lower, "empty archive"):
+			return nil, fmt.Errorf("source_stdin is set but stdin is empty; pipe a 'docker save' stream into the release")
+		case strings.Contains(lower, "unexpected eof"):
+			return nil, fmt.Errorf("image stream on stdin ended early; the 'docker save' producing it may have failed\n%s", string(output))
+		}
+		return nil, fmt.Errorf("docker load failed: %w\n%s", err, string(output))
+	}
+	return parseLoadedImages(string(output)), nil
+}
+
+// parseLoadedImages extracts the references printed by docker load.
+func parseLoadedImages(output string) []string {
+	images := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if ref, ok := strings.CutPrefix(line, "Loaded image ID: "); ok {
+			images = append(images, ref)
+		} else if ref, ok := strings.CutPrefix(line, "Loaded image: "); ok {
+			images = append(images, ref)
+		}
+	}
+	return images
+}
+
+// loadedImage reports whether docker load produced ref, accepting the implicit
+// latest tag and Docker Hub prefixes docker may add.
+func loadedImage(loaded []string, ref string) bool {
+	normalize := func(r string) string {
+		r = strings.TrimPrefix(r, "docker.io/")
+		r = strings.TrimPrefix(r, "library/")
+		if !strings.Contains(r[strings.LastIndex(r, "/")+1:], ":") && !strings.Contains(r, "@") {
+			r += ":latest"
+		}
+		return r
+	}
+	want := normalize(ref)
+	for _, image := range loaded {
+		if normalize(image) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// Ping checks that the Docker daemon answers.
+func (d *DockerClient) Ping(ctx context.Context) error {
+	cmd := Command{Name: "docker", Args: []string{"info", "--format", "{{.ServerVersion}}"}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		return &DaemonUnavailableError{Host: os.Getenv("DOCKER_HOST"), Output: strings.TrimSpace(string(output))}
+	}
+	return nil
 }
 
 // Tag tags a Docker image.
 func (d *DockerClient) Tag(ctx context.Context, source, target string) error {
-	cmd := exec.CommandContext(ctx, "docker", "tag", source, target)
-	output, err := cmd.CombinedOutput()
+	cmd := Command{Name: "docker", Args: []string{"tag", source, target}}
+	output, err := d.runner.Run(ctx, cmd)
 	if err != nil {
 		return fmt.Errorf("docker tag failed: %w\n%s", err, string(output))
 	}
 	return nil
 }
 
-// Push pushes a Docker image.
-func (d *DockerClient) Push(ctx context.Context, image string) error {
-	cmd := exec.CommandContext(ctx, "docker", "push", image)
-	output, err := cmd.CombinedOutput()
+// intermediateReference returns image with a random suffix appended to its
+// tag, giving each run a local reference that no concurrent run on the same
+// daemon can retag.
+func intermediateReference(image string) (string, error) {
+	random := make([]byte, 6)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate intermediate tag: %w", err)
+	}
+	suffix := "-tmp-" + hex.EncodeToString(random)
+
+	name, tag := image, "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name, tag = image[:i], image[i+1:]
+	}
+	if len(tag)+len(suffix) > 128 {
+		tag = tag[:128-len(suffix)]
+	}
+	return name + ":" + tag + suffix, nil
+}
+
+// Push pushes a Docker image and returns the pushed manifest digest.
+func (d *DockerClient) Push(ctx context.Context, image string) (string, error) {
+	cmd := Command{Name: "docker", Args: []string{"push", image}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("docker push failed: %w\n%s", err, string(output))
+	}
+	return parsePushDigest(string(output)), nil
+}
+
+// parsePushDigest extracts the manifest digest from docker push output.
+func parsePushDigest(output string) string {
+	m := pushDigestPattern.FindStringSubmatch(output)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// RemoveTag removes a local image reference without deleting the underlying image
+// while other references to it remain.
+func (d *DockerClient) RemoveTag(ctx context.Context, image string) error {
+	cmd := Command{Name: "docker", Args: []string{"rmi", "--no-prune", image}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("docker rmi failed: %w\n%s", err, string(output))
+	}
+	return nil
+}
+
+// Pull pulls a Docker image.
+func (d *DockerClient) Pull(ctx context.Context, image string) error {
+	cmd := Command{Name: "docker", Args: []string{"pull", image}}
+	output, err := d.runner.Run(ctx, cmd)
 	if err != nil {
-		return fmt.Errorf("docker push failed: %w\n%s", err, string(output))
+		if isRateLimited(string(output)) {
+			return &RateLimitError{Image: image, Output: string(output)}
+		}
+		return fmt.Errorf("docker pull failed: %w\n%s", err, string(output))
 	}
 	return nil
 }
 
+// isRateLimited reports whether docker output indicates a registry rate limit.
+func isRateLimited(output string) bool {
+	lower := strings.ToLower(output)
+	return strings.Contains(lower, "toomanyrequests") ||
+		strings.Contains(lower, "pull rate limit")
+}
+
+// ImageSize returns the size in bytes of a local Docker image.
+func (d *DockerClient) ImageSize(ctx context.Context, image string) (int64, error) {
+	cmd := Command{Name: "docker", Args: []string{"image", "inspect", "--format", "{{.Size}}", image}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		return 0, fmt.Errorf("docker image inspect failed: %w\n%s", err, string(output))
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected image size %q: %w", strings.TrimSpace(string(output)), err)
+	}
+	return size, nil
+}
+
+// CheckSource verifies that a local image has an ID and at least one layer, so
+// that a reference whose content is gone fails before it is tagged.
+func (d *DockerClient) CheckSource(ctx context.Context, image string) error {
+	cmd := Command{Name: "docker", Args: []string{"image", "inspect", "--format", "{{.Id}} {{len .RootFS.Layers}}", image}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		return &SourceImageError{Image: image, Reason: "is not present locally", Output: strings.TrimSpace(string(output))}
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 || !strings.HasPrefix(fields[0], "sha256:") {
+		return &SourceImageError{Image: image, Reason: "has no image config", Output: strings.TrimSpace(string(output))}
+	}
+	if layers, err := strconv.Atoi(fields[1]); err != nil || layers == 0 {
+		return &SourceImageError{Image: image, Reason: "has no layers", Output: strings.TrimSpace(string(output))}
+	}
+	return nil
+}
+
+// ImagePlatform returns the os/arch[/variant] of a local Docker image.
+func (d *DockerClient) ImagePlatform(ctx context.Context, image string) (string, error) {
+	cmd := Command{Name: "docker", Args: []string{
+		"image", "inspect", "--format", "{{.Os}}/{{.Architecture}}{{if .Variant}}/{{.Variant}}{{end}}", image,
+	}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("docker image inspect failed: %w\n%s", err, string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ImageLabels returns the labels of a local Docker image.
+func (d *DockerClient) ImageLabels(ctx context.Context, image string) (map[string]string, error) {
+	cmd := Command{Name: "docker", Args: []string{"image", "inspect", "--format", "{{json .Config.Labels}}", image}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("docker image inspect failed: %w\n%s", err, string(output))
+	}
+	return parseImageLabels(output)
+}
+
+// RemotePlatforms returns the platforms of a multi-platform image in its registry.
+func (d *DockerClient) RemotePlatforms(ctx context.Context, image string) ([]string, error) {
+	cmd := Command{Name: "docker", Args: []string{"manifest", "inspect", image}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("docker manifest inspect failed: %w\n%s", err, string(output))
+	}
+	return parseManifestListPlatforms(output)
+}
+
+// InspectManifest returns the raw manifest of an image in its registry.
+func (d *DockerClient) InspectManifest(ctx context.Context, image string) ([]byte, error) {
+	cmd := Command{Name: "docker", Args: []string{"manifest", "inspect", image}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("docker manifest inspect failed: %w\n%s", err, string(output))
+	}
+	return output, nil
+}
+
+// RemoteDigest returns the manifest (or index) digest of an image in its
+// registry, through the registry credentials docker is logged in with.
+func (d *DockerClient) RemoteDigest(ctx context.Context, image string) (string, error) {
+	cmd := Command{Name: "docker", Args: []string{"buildx", "imagetools", "inspect", "--format", "{{.Manifest.Digest}}", image}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("docker buildx imagetools inspect failed: %w\n%s", err, string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// SourceManifestDigest returns the registry manifest digest of a source
+// image: the repo digest docker recorded when the image was pulled or pushed,
+// or else the digest its registry reports. A local build that was never
+// pushed has neither.
+func (d *DockerClient) SourceManifestDigest(ctx context.Context, image string) (string, error) {
+	cmd := Command{Name: "docker", Args: []string{"image", "inspect", "--format", "{{json .RepoDigests}}", image}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err == nil {
+		if digest := parseRepoDigest(output, image); digest != "" {
+			return digest, nil
+		}
+	}
+	digest, err := d.RemoteDigest(ctx, image)
+	if err != nil {
+		return "", fmt.Errorf("%s has no repo digest and its registry did not resolve it: %w", image, err)
+	}
+	return digest, nil
+}
+
+// parseRepoDigest picks the digest from docker image inspect RepoDigests
+// output, preferring the entry for the image's own repository.
+func parseRepoDigest(output []byte, image string) string {
+	var repoDigests []string
+	if err := json.Unmarshal(output, &repoDigests); err != nil || len(repoDigests) == 0 {
+		return ""
+	}
+	name := image
+	if ref, err := parseImageReference(image); err == nil {
+		name = ref.Path
+		if ref.Domain != "" {
+			name = ref.Domain + "/" + ref.Path
+		}
+	}
+	for _, repoDigest := range repoDigests {
+		if repo, digest, ok := strings.Cut(repoDigest, "@"); ok && repo == name {
+			return digest
+		}
+	}
+	_, digest, _ := strings.Cut(repoDigests[0], "@")
+	return digest
+}
+
+// ImageDigest returns the ID of a local Docker image, the digest of its config
+// with the classic image store rather than of a registry manifest.
+func (d *DockerClient) ImageDigest(ctx context.Context, image string) (string, error) {
+	cmd := Command{Name: "docker", Args: []string{"image", "inspect", "--format", "{{.Id}}", image}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("docker image inspect failed: %w\n%s", err, string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// UsesContainerdStore reports whether the daemon keeps images in the containerd
+// image store, where an image ID is the manifest or index digest.
+func (d *DockerClient) UsesContainerdStore(ctx context.Context) (bool, error) {
+	cmd := Command{Name: "docker", Args: []string{"info", "--format", "{{json .DriverStatus}}"}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		return false, fmt.Errorf("docker info failed: %w\n%s", err, string(output))
+	}
+	return strings.Contains(string(output), "io.containerd.snapshotter"), nil
+}
+
+// ManifestExists checks if an image reference exists in its remote registry.
+func (d *DockerClient) ManifestExists(ctx context.Context, image string) (bool, error) {
+	cmd := Command{Name: "docker", Args: []string{"manifest", "inspect", image}}
+	output, err := d.runner.Run(ctx, cmd)
+	if err != nil {
+		if isManifestMissing(string(output)) {
+			return false, nil
+		}
+		return false, fmt.Errorf("docker manifest inspect failed: %w\n%s", err, string(output))
+	}
+	return true, nil
+}
+
+// isManifestMissing reports whether docker output indicates a missing manifest.
+func isManifestMissing(output string) bool {
+	lower := strings.ToLower(output)
+	return strings.Contains(lower, "no such manifest") ||
+		strings.Contains(lower, "manifest unknown")
+}
+
 // ImageExists checks if a Docker image exists locally.
 func (d *DockerClient) ImageExists(ctx context.Context, image string) (bool, error) {
-	cmd := exec.CommandContext(ctx, "docker", "image", "inspect", image)
-	err := cmd.Run()
+	cmd := Command{Name: "docker", Args: []string{"image", "inspect", image}}
+	_, err := d.runner.Run(ctx, cmd)
 	if err != nil {
 		return false, nil
 	}
@@ -1,6 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -27,8 +32,531 @@ func TestDockerClient_Methods(t *testing.T) {
 		_ = client.Push
 	})
 
+	t.Run("RemoveTag method exists", func(t *testing.T) {
+		// Verify the method signature by attempting to get a reference
+		_ = client.RemoveTag
+	})
+
+	t.Run("Pull method exists", func(t *testing.T) {
+		// Verify the method signature by attempting to get a reference
+		_ = client.Pull
+	})
+
+	t.Run("ManifestExists method exists", func(t *testing.T) {
+		// Verify the method signature by attempting to get a reference
+		_ = client.ManifestExists
+	})
+
 	t.Run("ImageExists method exists", func(t *testing.T) {
 		// Verify the method signature by attempting to get a reference
 		_ = client.ImageExists
 	})
+
+	t.Run("ImagePlatform method exists", func(t *testing.T) {
+		// Verify the method signature by attempting to get a reference
+		_ = client.ImagePlatform
+	})
+
+	t.Run("RemotePlatforms method exists", func(t *testing.T) {
+		// Verify the method signature by attempting to get a reference
+		_ = client.RemotePlatforms
+	})
+
+	t.Run("ImageSize method exists", func(t *testing.T) {
+		// Verify the method signature by attempting to get a reference
+		_ = client.ImageSize
+	})
+}
+
+func TestIsRateLimited(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected bool
+	}{
+		{
+			name:     "docker hub toomanyrequests",
+			output:   "Error response from daemon: toomanyrequests: You have reached your pull rate limit.",
+			expected: true,
+		},
+		{
+			name:     "pull rate limit wording",
+			output:   "You have reached your Pull Rate Limit",
+			expected: true,
+		},
+		{
+			name:     "manifest unknown",
+			output:   "Error response from daemon: manifest for nginx:nope not found: manifest unknown",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRateLimited(tt.output); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParsePushDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{
+			name:     "push output",
+			output:   "The push refers to repository [myregistry.azurecr.io/app]\n5f70bf18a086: Pushed\n1.0.0: digest: " + digest + " size: 528\n",
+			expected: digest,
+		},
+		{
+			name:     "no digest",
+			output:   "Everything up-to-date",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsePushDigest(tt.output); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestIntermediateReference(t *testing.T) {
+	suffix := regexp.MustCompile(`-tmp-[0-9a-f]{12}$`)
+
+	first, err := intermediateReference("myregistry.azurecr.io/app:1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(first, "myregistry.azurecr.io/app:1.0.0-tmp-") || !suffix.MatchString(first) {
+		t.Errorf("unexpected intermediate reference %q", first)
+	}
+	second, _ := intermediateReference("myregistry.azurecr.io/app:1.0.0")
+	if first == second {
+		t.Errorf("expected unique references, got %q twice", first)
+	}
+
+	if ref, _ := intermediateReference("localhost:5000/app"); !strings.HasPrefix(ref, "localhost:5000/app:latest-tmp-") {
+		t.Errorf("expected an untagged image to use latest, got %q", ref)
+	}
+
+	long, _ := intermediateReference("myregistry.azurecr.io/app:" + strings.Repeat("a", 128))
+	if tag := long[strings.LastIndex(long, ":")+1:]; len(tag) != 128 || !suffix.MatchString(tag) {
+		t.Errorf("expected the tag capped at 128 characters with the suffix kept, got %q", tag)
+	}
+}
+
+func TestIsManifestMissing(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected bool
+	}{
+		{name: "no such manifest", output: "no such manifest: myregistry.azurecr.io/app:1.0.0", expected: true},
+		{name: "manifest unknown", output: "manifest unknown: manifest tagged by \"1.0.0\" is not found", expected: true},
+		{name: "unauthorized", output: "unauthorized: authentication required", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isManifestMissing(tt.output); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	var err error = &RateLimitError{Image: "nginx:1.25", Output: "toomanyrequests"}
+	wrapped := fmt.Errorf("failed to pull source image: %w", err)
+
+	var rateErr *RateLimitError
+	if !errors.As(wrapped, &rateErr) {
+		t.Fatal("expected wrapped error to be a RateLimitError")
+	}
+
+	if !strings.Contains(err.Error(), "docker login") {
+		t.Errorf("expected guidance in error, got %q", err.Error())
+	}
+}
+
+func TestDockerClient_PushReturnsDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("b", 64)
+	runner := &fakeRunner{
+		respond: func(Command) ([]byte, error) {
+			return []byte("1.0.0: digest: " + digest + " size: 1234"), nil
+		},
+	}
+	client := NewDockerClient()
+	client.SetRunner(runner)
+
+	got, err := client.Push(context.Background(), "myregistry.azurecr.io/app:1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != digest {
+		t.Errorf("expected digest %q, got %q", digest, got)
+	}
+	runner.assertCommands(t, []Command{{Name: "docker", Args: []string{"push", "myregistry.azurecr.io/app:1.0.0"}}})
+}
+
+func TestDockerClient_PullRateLimited(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(Command) ([]byte, error) {
+			return []byte("toomanyrequests: You have reached your pull rate limit."), errors.New("exit status 1")
+		},
+	}
+	client := NewDockerClient()
+	client.SetRunner(runner)
+
+	err := client.Pull(context.Background(), "nginx:latest")
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("expected RateLimitError, got %v", err)
+	}
+}
+
+// presentSourceInspect is docker image inspect output for a complete local image.
+var presentSourceInspect = "sha256:" + strings.Repeat("a", 64) + " 3\n"
+
+func TestDockerClient_CheckSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		err     error
+		wantErr string
+	}{
+		{name: "present", output: presentSourceInspect},
+		{name: "missing", output: "Error: No such image: myapp:1.0.0", err: errors.New("exit status 1"), wantErr: "is not present locally"},
+		{name: "missing manifest", output: "<no value> 0", wantErr: "has no image config"},
+		{name: "no layers", output: "sha256:" + strings.Repeat("a", 64) + " 0", wantErr: "has no layers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				respond: func(Command) ([]byte, error) {
+					return []byte(tt.output), tt.err
+				},
+			}
+			client := NewDockerClient()
+			client.SetRunner(runner)
+
+			err := client.CheckSource(context.Background(), "myapp:1.0.0")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var sourceErr *SourceImageError
+			if !errors.As(err, &sourceErr) {
+				t.Fatalf("expected SourceImageError, got %v", err)
+			}
+			if sourceErr.Image != "myapp:1.0.0" || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("unexpected error %q", err.Error())
+			}
+			runner.assertCommands(t, []Command{{Name: "docker", Args: []string{"image", "inspect", "--format", "{{.Id}} {{len .RootFS.Layers}}", "myapp:1.0.0"}}})
+		})
+	}
+}
+
+func TestDockerClient_PingDaemonDown(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "unix:///var/run/missing.sock")
+	runner := &fakeRunner{
+		respond: func(Command) ([]byte, error) {
+			return []byte("Cannot connect to the Docker daemon at unix:///var/run/missing.sock. Is the docker daemon running?"), errors.New("exit status 1")
+		},
+	}
+	client := NewDockerClient()
+	client.SetRunner(runner)
+
+	err := client.Ping(context.Background())
+	var daemonErr *DaemonUnavailableError
+	if !errors.As(err, &daemonErr) {
+		t.Fatalf("expected DaemonUnavailableError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "DOCKER_HOST=unix:///var/run/missing.sock") {
+		t.Errorf("expected DOCKER_HOST in error, got %q", err.Error())
+	}
+	runner.assertCommands(t, []Command{{Name: "docker", Args: []string{"info", "--format", "{{.ServerVersion}}"}}})
+}
+
+func TestDockerClient_Load(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		err      error
+		expected []string
+		wantErr  string
+	}{
+		{
+			name:     "loaded",
+			output:   "Loaded image: myapp:latest\nLoaded image ID: sha256:abc\n",
+			expected: []string{"myapp:latest", "sha256:abc"},
+		},
+		{
+			name:    "empty stdin",
+			output:  "requested load from stdin, but stdin is empty",
+			err:     errors.New("exit status 1"),
+			wantErr: "stdin is empty",
+		},
+		{
+			name:    "truncated stream",
+			output:  "Error processing tar file(exit status 1): unexpected EOF",
+			err:     errors.New("exit status 1"),
+			wantErr: "ended early",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				respond: func(Command) ([]byte, error) { return []byte(tt.output), tt.err },
+			}
+			client := NewDockerClient()
+			client.SetRunner(runner)
+
+			stream := strings.NewReader("tar")
+			loaded, err := client.Load(context.Background(), stream)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fmt.Sprint(loaded) != fmt.Sprint(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, loaded)
+			}
+			if runner.commands[0].Input != stream {
+				t.Error("expected the stream to be passed as input")
+			}
+		})
+	}
+}
+
+func TestLoadedImage(t *testing.T) {
+	tests := []struct {
+		loaded   []string
+		ref      string
+		expected bool
+	}{
+		{loaded: []string{"myapp:1.0.0"}, ref: "myapp:1.0.0", expected: true},
+		{loaded: []string{"myapp:latest"}, ref: "myapp", expected: true},
+		{loaded: []string{"docker.io/library/nginx:1.25"}, ref: "nginx:1.25", expected: true},
+		{loaded: []string{"localhost:5000/myapp:latest"}, ref: "localhost:5000/myapp", expected: true},
+		{loaded: []string{"myapp:1.0.0"}, ref: "myapp:2.0.0", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if got := loadedImage(tt.loaded, tt.ref); got != tt.expected {
+				t.Errorf("loadedImage(%v, %q) = %v, want %v", tt.loaded, tt.ref, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDockerClient_ManifestExists(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		err      error
+		expected bool
+		wantErr  bool
+	}{
+		{name: "exists", output: "{}", expected: true},
+		{name: "missing", output: "no such manifest: myregistry.azurecr.io/app:1.0.0", err: errors.New("exit status 1")},
+		{name: "other failure", output: "unauthorized", err: errors.New("exit status 1"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewDockerClient()
+			client.SetRunner(&fakeRunner{
+				respond: func(Command) ([]byte, error) { return []byte(tt.output), tt.err },
+			})
+
+			exists, err := client.ManifestExists(context.Background(), "myregistry.azurecr.io/app:1.0.0")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if exists != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, exists)
+			}
+		})
+	}
+}
+
+func TestDockerClient_InspectManifest(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(Command) ([]byte, error) { return []byte(`{"mediaType":"m"}`), nil },
+	}
+	client := NewDockerClient()
+	client.SetRunner(runner)
+
+	output, err := client.InspectManifest(context.Background(), "myregistry.azurecr.io/app:1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(output) != `{"mediaType":"m"}` {
+		t.Errorf("unexpected output %q", output)
+	}
+	runner.assertCommands(t, []Command{
+		{Name: "docker", Args: []string{"manifest", "inspect", "myregistry.azurecr.io/app:1.0.0"}},
+	})
+
+	client.SetRunner(&fakeRunner{
+		respond: func(Command) ([]byte, error) { return []byte("unauthorized"), errors.New("exit status 1") },
+	})
+	if _, err := client.InspectManifest(context.Background(), "myregistry.azurecr.io/app:1.0.0"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestDockerClient_RemoteDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	runner := &fakeRunner{
+		respond: func(Command) ([]byte, error) { return []byte(digest + "\n"), nil },
+	}
+	client := NewDockerClient()
+	client.SetRunner(runner)
+
+	got, err := client.RemoteDigest(context.Background(), "myregistry.azurecr.io/app:1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != digest {
+		t.Errorf("expected %s, got %s", digest, got)
+	}
+	runner.assertCommands(t, []Command{
+		{Name: "docker", Args: []string{"buildx", "imagetools", "inspect", "--format", "{{.Manifest.Digest}}", "myregistry.azurecr.io/app:1.0.0"}},
+	})
+}
+
+func TestDockerClient_SourceManifestDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	other := "sha256:" + strings.Repeat("b", 64)
+	tests := []struct {
+		name        string
+		repoDigests string
+		remote      string
+		remoteErr   error
+		expected    string
+		wantErr     bool
+	}{
+		{
+			name:        "repo digest of the same repository",
+			repoDigests: `["mirror.example.com/app@` + other + `","myregistry.azurecr.io/app@` + digest + `"]`,
+			expected:    digest,
+		},
+		{
+			name:        "first repo digest",
+			repoDigests: `["mirror.example.com/app@` + digest + `"]`,
+			expected:    digest,
+		},
+		{
+			name:        "resolved in the registry",
+			repoDigests: `[]`,
+			remote:      digest + "\n",
+			expected:    digest,
+		},
+		{
+			name:        "never pushed",
+			repoDigests: `[]`,
+			remote:      "ERROR: not found",
+			remoteErr:   errors.New("exit status 1"),
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewDockerClient()
+			client.SetRunner(&fakeRunner{
+				respond: func(cmd Command) ([]byte, error) {
+					if cmd.Args[0] == "buildx" {
+						return []byte(tt.remote), tt.remoteErr
+					}
+					return []byte(tt.repoDigests + "\n"), nil
+				},
+			})
+
+			got, err := client.SourceManifestDigest(context.Background(), "myregistry.azurecr.io/app:1.0.0")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDockerClient_ImageLabels(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			return []byte(`{"org.opencontainers.image.base.name":"alpine:3.20"}` + "\n"), nil
+		},
+	}
+	client := NewDockerClient()
+	client.SetRunner(runner)
+
+	labels, err := client.ImageLabels(context.Background(), "myapp:latest")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if labels[baseImageNameLabel] != "alpine:3.20" {
+		t.Errorf("unexpected labels %v", labels)
+	}
+	runner.assertCommands(t, []Command{
+		{Name: "docker", Args: []string{"image", "inspect", "--format", "{{json .Config.Labels}}", "myapp:latest"}},
+	})
+}
+
+func TestDockerClient_UsesContainerdStore(t *testing.T) {
+	tests := []struct {
+		status   string
+		expected bool
+	}{
+		{status: `[["driver-type","io.containerd.snapshotter.v1"]]`, expected: true},
+		{status: `[["Backing Filesystem","extfs"],["Supports d_type","true"]]`, expected: false},
+	}
+	for _, tt := range tests {
+		runner := &fakeRunner{
+			respond: func(cmd Command) ([]byte, error) {
+				return []byte(tt.status + "\n"), nil
+			},
+		}
+		client := NewDockerClient()
+		client.SetRunner(runner)
+
+		got, err := client.UsesContainerdStore(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.status, tt.expected, got)
+		}
+		runner.assertCommands(t, []Command{
+			{Name: "docker", Args: []string{"info", "--format", "{{json .DriverStatus}}"}},
+		})
+	}
 }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// defaultHookTimeout bounds each push hook command unless hooks.timeout is set.
+const defaultHookTimeout = 5 * time.Minute
+
+// PushHooksConfig configures the commands run before and after the push.
+type PushHooksConfig struct {
+	// Pre and Post are tag-style templates, rendered whole and then split
+	// into arguments like a shell would, honouring quotes.
+	Pre  string
+	Post string
+
+	// Required turns a failing post-push command into an error.
+	Required bool
+	Timeout  time.Duration
+}
+
+// validateHookCommand checks that a hook command parses as a template.
+func validateHookCommand(command string) error {
+	if _, err := template.New("hook").Parse(command); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	return nil
+}
+
+// renderHookArgs renders command against data and splits the result into
+// arguments; quote a value that may contain spaces to keep it one argument.
+func renderHookArgs(command string, data any) ([]string, error) {
+	t, err := template.New("hook").Option("missingkey=error").Parse(command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	var buf strings.Builder
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render %q: %w", command, err)
+	}
+	return splitCommandLine(buf.String())
+}
+
+// splitCommandLine splits s on unquoted whitespace. Single quotes keep their
+// content literally; inside double quotes and outside quotes a backslash
+// escapes the next character.
+func splitCommandLine(s string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inArg = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, s)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in %q", s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// pushHookEnv returns the environment handed to the post-push command.
+func pushHookEnv(registry string, tags, pushedImages []string, digests map[string]string) []string {
+	refs := make([]string, 0, len(digests))
+	for ref, digest := range digests {
+		refs = append(refs, ref+"="+digest)
+	}
+	slices.Sort(refs)
+	return []string{
+		"ACR_REGISTRY=" + registry,
+		"ACR_TAGS=" + strings.Join(tags, ","),
+		"ACR_PUSHED_IMAGES=" + strings.Join(pushedImages, ","),
+		"ACR_DIGESTS=" + strings.Join(refs, ","),
+	}
+}
+
+// runPushHook runs a rendered hook command with env under timeout and prints
+// its output with secrets replaced by "***".
+func runPushHook(ctx context.Context, runner CommandRunner, name, command string, data any, env []string, timeout time.Duration, secrets []string) error {
+	args, err := renderHookArgs(command, data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output, err := runner.Run(ctx, Command{Name: args[0], Args: args[1:], Env: env})
+	if text := strings.TrimSpace(redactSecrets(string(output), secrets)); text != "" {
+		fmt.Printf("%s output:\n%s\n", name, text)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s timed out after %s", name, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
+	return nil
+}
+
+// redactSecrets replaces each non-empty secret in s with "***".
+func redactSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, "***")
+		}
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestRenderHookArgs(t *testing.T) {
+	data := newTemplateData(&plugin.ReleaseContext{Version: "1.0.0", Branch: "release notes"})
+
+	args, err := renderHookArgs(`./purge.sh --version {{ .Version }} "{{.Branch}}" --note 'built {{ printf "%s" .Version }}'`, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(args, []string{"./purge.sh", "--version", "1.0.0", "release notes", "--note", "built 1.0.0"}) {
+		t.Errorf("unexpected args %q", args)
+	}
+
+	if _, err := renderHookArgs(`./purge.sh "{{.Version}}`, data); err == nil {
+		t.Errorf("expected an error for an unterminated quote")
+	}
+
+	if _, err := renderHookArgs("./purge.sh {{.Vars.missing}}", data); err == nil {
+		t.Errorf("expected an error for a missing value")
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{input: "  ./warm.sh   a\tb ", expected: []string{"./warm.sh", "a", "b"}},
+		{input: `./warm.sh "a b" 'c "d"'`, expected: []string{"./warm.sh", "a b", `c "d"`}},
+		{input: `./warm.sh a\ b "x\"y" ''`, expected: []string{"./warm.sh", "a b", `x"y`, ""}},
+		{input: "", expected: nil},
+	}
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if !slices.Equal(got, tt.expected) {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+
+	for _, input := range []string{`./warm.sh 'a`, `./warm.sh a\`} {
+		if _, err := splitCommandLine(input); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func TestValidateHookCommand(t *testing.T) {
+	if err := validateHookCommand("./warm.sh {{ .Version }}"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateHookCommand("./warm.sh {{.Version"); err == nil {
+		t.Errorf("expected an error for an unterminated template")
+	}
+}
+
+func TestPushHookEnv(t *testing.T) {
+	env := pushHookEnv("myregistry.azurecr.io",
+		[]string{"1.0.0", "latest"},
+		[]string{"myregistry.azurecr.io/app:1.0.0", "myregistry.azurecr.io/app:latest"},
+		map[string]string{"myregistry.azurecr.io/app:latest": "sha256:b", "myregistry.azurecr.io/app:1.0.0": "sha256:a"},
+	)
+	expected := []string{
+		"ACR_REGISTRY=myregistry.azurecr.io",
+		"ACR_TAGS=1.0.0,latest",
+		"ACR_PUSHED_IMAGES=myregistry.azurecr.io/app:1.0.0,myregistry.azurecr.io/app:latest",
+		"ACR_DIGESTS=myregistry.azurecr.io/app:1.0.0=sha256:a,myregistry.azurecr.io/app:latest=sha256:b",
+	}
+	if !slices.Equal(env, expected) {
+		t.Errorf("expected %q, got %q", expected, env)
+	}
+}
+
+func TestRunPushHook(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			return []byte("purged with token s3cret"), errors.New("exit status 1")
+		},
+	}
+	data := newTemplateData(&plugin.ReleaseContext{Version: "1.0.0"})
+	env := []string{"ACR_TAGS=1.0.0"}
+
+	output := captureStdout(t, func() {
+		err := runPushHook(context.Background(), runner, "post_push_command", "./purge.sh {{.Version}}", data, env, time.Second, []string{"s3cret"})
+		if err == nil || !strings.Contains(err.Error(), "post_push_command failed") {
+			t.Errorf("expected a post_push_command failure, got %v", err)
+		}
+	})
+
+	if strings.Contains(output, "s3cret") || !strings.Contains(output, "purged with token ***") {
+		t.Errorf("expected redacted output, got %q", output)
+	}
+	if len(runner.commands) != 1 || runner.commands[0].Name != "./purge.sh" || !slices.Equal(runner.commands[0].Env, env) {
+		t.Errorf("unexpected command %+v", runner.commands)
+	}
+}
+
+func TestRunPushHook_Timeout(t *testing.T) {
+	runner := &blockingRunner{}
+	data := newTemplateData(&plugin.ReleaseContext{Version: "1.0.0"})
+
+	err := runPushHook(context.Background(), runner, "pre_push_command", "./slow.sh", data, nil, 10*time.Millisecond, nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	data, _ := io.ReadAll(r)
+	return string(data)
+}
+
+// blockingRunner blocks until its context is done.
+type blockingRunner struct{}
+
+// Run implements CommandRunner.
+func (blockingRunner) Run(ctx context.Context, _ Command) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
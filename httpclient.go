@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// TLSConfig constrains the TLS connections of HTTP calls.
+type TLSConfig struct {
+	// MinVersion is "1.2" or "1.3"; empty means 1.2.
+	MinVersion string
+}
+
+// tlsVersions maps tls.min_version values to crypto/tls versions.
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// minVersion returns the configured minimum TLS version, defaulting to 1.2.
+func (c TLSConfig) minVersion() uint16 {
+	if v, ok := tlsVersions[c.MinVersion]; ok {
+		return v
+	}
+	return tls.VersionTLS12
+}
+
+// HTTPTransportConfig tunes connection reuse for HTTP calls. Zero values
+// keep the net/http defaults.
+type HTTPTransportConfig struct {
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	DisableKeepAlives   bool
+}
+
+// net/http keeps only 2 idle connections per host, so parallel calls to one
+// registry redial; 10 covers max_parallel's usual range. 90s matches the
+// net/http idle timeout, long enough to span the pushes of one run.
+const (
+	defaultMaxIdleConnsPerHost = 10
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// userAgent returns the User-Agent sent on outgoing requests, with an optional
+// suffix identifying the pipeline.
+func userAgent(suffix string) string {
+	ua := "relicta-plugin-acr/" + Version
+	if suffix != "" {
+		ua += " " + suffix
+	}
+	return ua
+}
+
+// userAgentTransport sets the User-Agent header on every request.
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.base.RoundTrip(req)
+}
+
+// sharedTransport returns the transport of every HTTP client built from cfg,
+// creating it on first use so that connections are pooled across a run.
+func (cfg *Config) sharedTransport() *http.Transport {
+	if cfg.transport != nil {
+		return cfg.transport
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Proxy.enabled() {
+		transport.Proxy = cfg.Proxy.proxy
+	}
+	transport.TLSClientConfig = &tls.Config{MinVersion: cfg.TLS.minVersion()}
+	if cfg.HTTPTransport.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.HTTPTransport.MaxIdleConnsPerHost
+	}
+	if cfg.HTTPTransport.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.HTTPTransport.IdleConnTimeout
+	}
+	transport.DisableKeepAlives = cfg.HTTPTransport.DisableKeepAlives
+	cfg.transport = transport
+	return transport
+}
+
+// newHTTPClient returns the client used for every HTTP call the plugin makes.
+func newHTTPClient(cfg *Config, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &userAgentTransport{
+			base:      cfg.sharedTransport(),
+			userAgent: userAgent(cfg.UserAgentSuffix),
+		},
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClient_UserAgent(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	client := newHTTPClient(&Config{UserAgentSuffix: "pipeline/42"}, time.Second)
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	expected := "relicta-plugin-acr/" + Version + " pipeline/42"
+	if got != expected {
+		t.Errorf("expected User-Agent %q, got %q", expected, got)
+	}
+}
+
+func TestNewHTTPClient_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	client := newHTTPClient(&Config{Proxy: ProxyConfig{HTTP: proxy.URL}}, time.Second)
+	resp, err := client.Get("http://hooks.example.invalid/notify")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if proxied != "http://hooks.example.invalid/notify" {
+		t.Errorf("expected request to go through the proxy, got %q", proxied)
+	}
+}
+
+func TestNewHTTPClient_MinTLSVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		expected uint16
+	}{
+		{name: "default", expected: tls.VersionTLS12},
+		{name: "1.2", version: "1.2", expected: tls.VersionTLS12},
+		{name: "1.3", version: "1.3", expected: tls.VersionTLS13},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newHTTPClient(&Config{TLS: TLSConfig{MinVersion: tt.version}}, time.Second)
+			transport := client.Transport.(*userAgentTransport).base.(*http.Transport)
+			if got := transport.TLSClientConfig.MinVersion; got != tt.expected {
+				t.Errorf("expected min version %x, got %x", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClient_RejectsOldTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	client := newHTTPClient(&Config{TLS: TLSConfig{MinVersion: "1.3"}}, time.Second)
+	_, err := client.Get(server.URL)
+	if err == nil || !strings.Contains(err.Error(), "protocol version") {
+		t.Errorf("expected a protocol version error, got %v", err)
+	}
+}
+
+func TestNewHTTPClient_Transport(t *testing.T) {
+	cfg := &Config{HTTPTransport: HTTPTransportConfig{
+		MaxIdleConnsPerHost: 32,
+		IdleConnTimeout:     time.Minute,
+		DisableKeepAlives:   true,
+	}}
+
+	first := newHTTPClient(cfg, time.Second).Transport.(*userAgentTransport).base.(*http.Transport)
+	second := newHTTPClient(cfg, time.Minute).Transport.(*userAgentTransport).base.(*http.Transport)
+	if first != second {
+		t.Error("expected clients of one run to share a transport")
+	}
+	if first.MaxIdleConnsPerHost != 32 || first.IdleConnTimeout != time.Minute || !first.DisableKeepAlives {
+		t.Errorf("unexpected transport settings: %d %s %v", first.MaxIdleConnsPerHost, first.IdleConnTimeout, first.DisableKeepAlives)
+	}
+}
+
+func TestParseConfig_HTTPTransport(t *testing.T) {
+	p := &ACRPlugin{}
+
+	defaults := p.parseConfig(map[string]any{}).HTTPTransport
+	if defaults != (HTTPTransportConfig{MaxIdleConnsPerHost: defaultMaxIdleConnsPerHost, IdleConnTimeout: defaultIdleConnTimeout}) {
+		t.Errorf("unexpected defaults %+v", defaults)
+	}
+
+	cfg := p.parseConfig(map[string]any{
+		"http_transport": map[string]any{"max_idle_conns_per_host": 4, "idle_conn_timeout": "30s", "keep_alive": false},
+	}).HTTPTransport
+	if cfg != (HTTPTransportConfig{MaxIdleConnsPerHost: 4, IdleConnTimeout: 30 * time.Second, DisableKeepAlives: true}) {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// supportedHooks are the lifecycle hooks the plugin can push at, declared in
+// GetInfo. hooks.enabled selects among them.
+var supportedHooks = []plugin.Hook{plugin.HookPrePublish, plugin.HookPostPublish}
+
+// defaultEnabledHooks keeps the plugin at post-publish unless configured.
+var defaultEnabledHooks = []string{string(plugin.HookPostPublish)}
+
+// validateLifecycleHooks checks that every enabled hook, and every hook with a
+// repository override, is a hook the plugin supports. Names unknown to the
+// SDK are reported as such.
+func validateLifecycleHooks(enabled []string, repositories map[string]string) error {
+	names := slices.Clone(enabled)
+	for hook := range repositories {
+		names = append(names, hook)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if slices.Contains(supportedHooks, plugin.Hook(name)) {
+			continue
+		}
+		if !slices.Contains(plugin.AllHooks(), plugin.Hook(name)) {
+			return fmt.Errorf("unknown hook %q", name)
+		}
+		supported := make([]string, len(supportedHooks))
+		for i, hook := range supportedHooks {
+			supported[i] = string(hook)
+		}
+		return fmt.Errorf("hook %q is not supported; use %s", name, strings.Join(supported, " or "))
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestValidateLifecycleHooks(t *testing.T) {
+	tests := []struct {
+		name         string
+		enabled      []string
+		repositories map[string]string
+		wantErr      bool
+	}{
+		{name: "default", enabled: defaultEnabledHooks},
+		{name: "both publish hooks", enabled: []string{"pre-publish", "post-publish"}, repositories: map[string]string{"pre-publish": "staging"}},
+		{name: "unknown hook", enabled: []string{"post-publish", "promote"}, wantErr: true},
+		{name: "unsupported sdk hook", enabled: []string{"on-success"}, wantErr: true},
+		{name: "unsupported repository hook", enabled: defaultEnabledHooks, repositories: map[string]string{"pre-plan": "staging"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLifecycleHooks(tt.enabled, tt.repositories)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateLifecycleHooks_Message(t *testing.T) {
+	err := validateLifecycleHooks([]string{"on-success"}, nil)
+	expected := `hook "on-success" is not supported; use pre-publish or post-publish`
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// lockPollInterval is how often a held lock is re-checked.
+const lockPollInterval = 500 * time.Millisecond
+
+// FileLock is a host-local advisory lock backed by an exclusively created file.
+// It only serializes runs that share the lock directory.
+type FileLock struct {
+	path string
+}
+
+// AcquireFileLock blocks until the lock for key is acquired or ctx is done.
+func AcquireFileLock(ctx context.Context, dir, key string) (*FileLock, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	path := filepath.Join(dir, lockFileName(key))
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, _ = file.WriteString(strconv.Itoa(os.Getpid()))
+			file.Close()
+			return &FileLock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for lock %s (remove it if no other run holds it): %w", path, ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// AcquireFileLocks acquires the locks for all keys. Keys are taken in sorted
+// order so runs locking overlapping sets cannot deadlock; on failure the locks
+// already held are released.
+func AcquireFileLocks(ctx context.Context, dir string, keys []string) ([]*FileLock, error) {
+	keys = slices.Compact(slices.Sorted(slices.Values(keys)))
+	locks := make([]*FileLock, 0, len(keys))
+	for _, key := range keys {
+		lock, err := AcquireFileLock(ctx, dir, key)
+		if err != nil {
+			_ = ReleaseFileLocks(locks)
+			return nil, err
+		}
+		locks = append(locks, lock)
+	}
+	return locks, nil
+}
+
+// ReleaseFileLocks releases locks in reverse acquisition order, returning the
+// first error.
+func ReleaseFileLocks(locks []*FileLock) error {
+	var first error
+	for i := len(locks) - 1; i >= 0; i-- {
+		if err := locks[i].Release(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Release releases the lock.
+func (l *FileLock) Release() error {
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// lockFileName converts a lock key into a safe file name. The escaping is
+// reversible, so distinct keys never share a lock file.
+func lockFileName(key string) string {
+	return url.QueryEscape(key) + ".lock"
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFileLock_SerializesHolders(t *testing.T) {
+	dir := t.TempDir()
+	key := "myregistry.azurecr.io/team/app"
+
+	first, err := AcquireFileLock(context.Background(), dir, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := AcquireFileLock(ctx, dir, key); err == nil {
+		t.Fatal("expected second acquire to time out while the lock is held")
+	}
+
+	if err := first.Release(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, err := AcquireFileLock(context.Background(), dir, key)
+	if err != nil {
+		t.Fatalf("expected acquire after release to succeed: %v", err)
+	}
+	if err := second.Release(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFileLocks_AcquiresEveryKey(t *testing.T) {
+	dir := t.TempDir()
+	keys := []string{"myregistry.azurecr.io/team/worker", "myregistry.azurecr.io/team/app", "myregistry.azurecr.io/team/app"}
+
+	locks, err := AcquireFileLocks(context.Background(), dir, keys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(locks) != 2 {
+		t.Fatalf("expected duplicate keys to share a lock, got %d locks", len(locks))
+	}
+
+	// A run holding any of the paths is blocked
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := AcquireFileLocks(ctx, dir, []string{"myregistry.azurecr.io/team/worker"}); err == nil {
+		t.Fatal("expected an additional image path to be locked too")
+	}
+
+	if err := ReleaseFileLocks(locks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected every lock file to be removed, got %v", entries)
+	}
+}
+
+func TestLockFileName(t *testing.T) {
+	got := lockFileName("myregistry.azurecr.io/team/app")
+	if got != "myregistry.azurecr.io%2Fteam%2Fapp.lock" {
+		t.Errorf("unexpected lock file name %q", got)
+	}
+	if lockFileName("r/a/b_c") == lockFileName("r/a_b/c") {
+		t.Error("expected distinct keys to get distinct lock files")
+	}
+}
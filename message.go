@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// defaultSuccessMessage is the Execute message used when success_message is unset.
+const defaultSuccessMessage = "Successfully pushed {{.Count}} image(s) to ACR"
+
+// messageTemplateData holds the values available to the success message template.
+type messageTemplateData struct {
+	*templateData
+
+	Count        int
+	Registry     string
+	Tags         []string
+	PushedImages []string
+}
+
+// parseSuccessMessage parses a success message template.
+func parseSuccessMessage(tmpl string) (*template.Template, error) {
+	t, err := template.New("success_message").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid success message template: %w", err)
+	}
+	return t, nil
+}
+
+// renderSuccessMessage renders the success message against the push results.
+func renderSuccessMessage(tmpl string, data *messageTemplateData) (string, error) {
+	t, err := parseSuccessMessage(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render success message: %w", err)
+	}
+	return buf.String(), nil
+}
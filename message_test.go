@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestRenderSuccessMessage(t *testing.T) {
+	data := &messageTemplateData{
+		templateData: newTemplateData(&plugin.ReleaseContext{Version: "1.2.0"}),
+		Count:        2,
+		Registry:     "myregistry.azurecr.io",
+		Tags:         []string{"1.2.0", "latest"},
+		PushedImages: []string{"myregistry.azurecr.io/app:1.2.0", "myregistry.azurecr.io/app:latest"},
+	}
+
+	tests := []struct {
+		name     string
+		tmpl     string
+		expected string
+		wantErr  bool
+	}{
+		{name: "default", tmpl: defaultSuccessMessage, expected: "Successfully pushed 2 image(s) to ACR"},
+		{
+			name:     "custom",
+			tmpl:     `acr release={{.Version}} count={{.Count}} tags={{range $i, $t := .Tags}}{{if $i}},{{end}}{{$t}}{{end}}`,
+			expected: "acr release=1.2.0 count=2 tags=1.2.0,latest",
+		},
+		{name: "unknown field", tmpl: "{{.Nope}}", wantErr: true},
+		{name: "undefined function", tmpl: `{{join .Tags ","}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderSuccessMessage(tt.tmpl, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
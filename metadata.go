@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TagMetadata gathers everything known about one pushed tag.
+type TagMetadata struct {
+	Tag       string `json:"tag"`
+	Reference string `json:"reference"`
+	Digest    string `json:"digest"`
+	MediaType string `json:"media_type"`
+
+	// Size is the compressed size of the config and layers of an image
+	// manifest, or the summed size of the manifests a list references.
+	Size   int64 `json:"size"`
+	Layers int   `json:"layers"`
+
+	Platforms      []string `json:"platforms"`
+	PushDurationMS int64    `json:"push_duration_ms"`
+}
+
+// parseManifestMetadata fills the media type, size, layer count and, for
+// manifest lists, the platforms of meta from docker manifest inspect output.
+func parseManifestMetadata(output []byte, meta *TagMetadata) error {
+	var manifest struct {
+		MediaType string `json:"mediaType"`
+		Config    struct {
+			Size int64 `json:"size"`
+		} `json:"config"`
+		Layers []struct {
+			Size int64 `json:"size"`
+		} `json:"layers"`
+		Manifests []struct {
+			Size int64 `json:"size"`
+		} `json:"manifests"`
+	}
+	if err := json.Unmarshal(output, &manifest); err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	meta.MediaType = manifest.MediaType
+	if len(manifest.Manifests) > 0 {
+		for _, m := range manifest.Manifests {
+			meta.Size += m.Size
+		}
+		platforms, err := parseManifestListPlatforms(output)
+		if err != nil {
+			return err
+		}
+		meta.Platforms = platforms
+		return nil
+	}
+
+	meta.Size = manifest.Config.Size
+	for _, layer := range manifest.Layers {
+		meta.Size += layer.Size
+	}
+	meta.Layers = len(manifest.Layers)
+	return nil
+}
+
+// manifestTypes maps each pushed reference to the media type of its manifest,
+// leaving out tags whose manifest could not be inspected.
+func manifestTypes(metadata []TagMetadata) map[string]string {
+	types := map[string]string{}
+	for _, meta := range metadata {
+		if meta.MediaType != "" {
+			types[meta.Reference] = meta.MediaType
+		}
+	}
+	return types
+}
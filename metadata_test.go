@@ -0,0 +1,68 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestParseManifestMetadata(t *testing.T) {
+	t.Run("image manifest", func(t *testing.T) {
+		output := []byte(`{
+			"mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+			"config": {"size": 1000},
+			"layers": [{"size": 2000}, {"size": 3000}]
+		}`)
+		meta := TagMetadata{Platforms: []string{"linux/amd64"}}
+		if err := parseManifestMetadata(output, &meta); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if meta.MediaType != "application/vnd.docker.distribution.manifest.v2+json" || meta.Size != 6000 || meta.Layers != 2 {
+			t.Errorf("unexpected metadata %+v", meta)
+		}
+		if !slices.Equal(meta.Platforms, []string{"linux/amd64"}) {
+			t.Errorf("expected the known platform to be kept, got %v", meta.Platforms)
+		}
+	})
+
+	t.Run("image index", func(t *testing.T) {
+		output := []byte(`{
+			"mediaType": "application/vnd.oci.image.index.v1+json",
+			"manifests": [
+				{"size": 500, "platform": {"os": "linux", "architecture": "amd64"}},
+				{"size": 600, "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}}
+			]
+		}`)
+		var meta TagMetadata
+		if err := parseManifestMetadata(output, &meta); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if meta.MediaType != "application/vnd.oci.image.index.v1+json" || meta.Size != 1100 || meta.Layers != 0 {
+			t.Errorf("unexpected metadata %+v", meta)
+		}
+		if !slices.Equal(meta.Platforms, []string{"linux/amd64", "linux/arm64/v8"}) {
+			t.Errorf("unexpected platforms %v", meta.Platforms)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if err := parseManifestMetadata([]byte("not json"), &TagMetadata{}); err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
+
+func TestManifestTypes(t *testing.T) {
+	types := manifestTypes([]TagMetadata{
+		{Reference: "myregistry.azurecr.io/app:1.0.0", MediaType: "application/vnd.docker.distribution.manifest.v2+json"},
+		{Reference: "myregistry.azurecr.io/app:latest", MediaType: "application/vnd.oci.image.index.v1+json"},
+		{Reference: "myregistry.azurecr.io/app:broken"},
+	})
+	expected := map[string]string{
+		"myregistry.azurecr.io/app:1.0.0":  "application/vnd.docker.distribution.manifest.v2+json",
+		"myregistry.azurecr.io/app:latest": "application/vnd.oci.image.index.v1+json",
+	}
+	if !maps.Equal(types, expected) {
+		t.Errorf("expected %v, got %v", expected, types)
+	}
+}
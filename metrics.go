@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMetricsJob is the pushgateway job when metrics_pushgateway.job is unset.
+const defaultMetricsJob = "relicta-acr"
+
+// MetricsConfig configures pushing run metrics to a Prometheus pushgateway.
+type MetricsConfig struct {
+	URL     string
+	Job     string
+	Labels  map[string]string
+	Timeout time.Duration
+}
+
+// runMetrics are the values pushed for one run. Registry and Repository, the
+// primary image path, join the grouping key.
+type runMetrics struct {
+	Registry     string
+	Repository   string
+	Success      bool
+	Duration     time.Duration
+	PushedImages int
+	PushedBytes  int64
+}
+
+// validateMetricsURL checks that a pushgateway URL is an absolute http(s) URL.
+func validateMetricsURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("metrics_pushgateway.url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// pushedBytes sums the compressed size of each distinct pushed digest, so
+// tags sharing a manifest are counted once.
+func pushedBytes(metadata []TagMetadata) int64 {
+	seen := map[string]bool{}
+	var total int64
+	for _, meta := range metadata {
+		if meta.Digest == "" || seen[meta.Digest] {
+			continue
+		}
+		seen[meta.Digest] = true
+		total += meta.Size
+	}
+	return total
+}
+
+// formatMetrics renders m in the Prometheus text exposition format, labelled
+// with the run result. Each push replaces the group, so every value describes
+// the last run and is a gauge.
+func formatMetrics(m runMetrics) []byte {
+	result, failed := "success", 0
+	if !m.Success {
+		result, failed = "failure", 1
+	}
+	labels := fmt.Sprintf(`{result=%s}`, strconv.Quote(result))
+
+	var buf bytes.Buffer
+	write := func(name, help, value string) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s gauge\n%s%s %s\n", name, help, name, name, labels, value)
+	}
+	write("acr_push_duration_seconds", "Duration of the last run.",
+		strconv.FormatFloat(m.Duration.Seconds(), 'f', -1, 64))
+	write("acr_pushed_images", "Image references pushed by the last run.",
+		strconv.Itoa(m.PushedImages))
+	write("acr_push_bytes", "Compressed bytes of the distinct manifests pushed by the last run.",
+		strconv.FormatInt(m.PushedBytes, 10))
+	write("acr_push_failed", "Whether the last run failed.",
+		strconv.Itoa(failed))
+	return buf.Bytes()
+}
+
+// pushgatewayPathSegment encodes a grouping key value for the pushgateway
+// URL path, using the base64 form for values a path segment cannot hold.
+func pushgatewayPathSegment(name, value string) string {
+	if value == "" || strings.Contains(value, "/") {
+		return name + "@base64/" + base64.RawURLEncoding.EncodeToString([]byte(value))
+	}
+	return name + "/" + url.PathEscape(value)
+}
+
+// pushgatewayURL returns the grouping URL for the job, the registry and
+// repository of m, and the configured labels, in sorted order. The registry
+// and repository take precedence over labels of the same name.
+func pushgatewayURL(cfg MetricsConfig, m runMetrics) string {
+	grouping := maps.Clone(cfg.Labels)
+	if grouping == nil {
+		grouping = map[string]string{}
+	}
+	grouping["registry"] = m.Registry
+	if m.Repository != "" {
+		grouping["repository"] = m.Repository
+	}
+
+	path := strings.TrimRight(cfg.URL, "/") + "/metrics/" + pushgatewayPathSegment("job", cfg.Job)
+	names := make([]string, 0, len(grouping))
+	for name := range grouping {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path += "/" + pushgatewayPathSegment(name, grouping[name])
+	}
+	return path
+}
+
+// pushMetrics replaces the metrics of the job's group on the pushgateway,
+// as the Prometheus client's Push does.
+func pushMetrics(ctx context.Context, client *http.Client, cfg MetricsConfig, m runMetrics) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, pushgatewayURL(cfg, m), bytes.NewReader(formatMetrics(m)))
+	if err != nil {
+		return fmt.Errorf("failed to build metrics request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pushing metrics to %s failed: %w", cfg.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("pushing metrics to %s returned %s: %s", cfg.URL, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatMetrics(t *testing.T) {
+	got := string(formatMetrics(runMetrics{
+		Registry:     "myregistry.azurecr.io",
+		Success:      false,
+		Duration:     1500 * time.Millisecond,
+		PushedImages: 2,
+		PushedBytes:  1024,
+	}))
+
+	for _, line := range []string{
+		"# TYPE acr_push_duration_seconds gauge",
+		"# TYPE acr_pushed_images gauge",
+		"# TYPE acr_push_bytes gauge",
+		"# TYPE acr_push_failed gauge",
+		`acr_push_duration_seconds{result="failure"} 1.5`,
+		`acr_pushed_images{result="failure"} 2`,
+		`acr_push_bytes{result="failure"} 1024`,
+		`acr_push_failed{result="failure"} 1`,
+	} {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("expected line %q in:\n%s", line, got)
+		}
+	}
+}
+
+func TestPushgatewayURL(t *testing.T) {
+	cfg := MetricsConfig{
+		URL:    "http://pushgateway:9091/",
+		Job:    "relicta-acr",
+		Labels: map[string]string{"pipeline": "myorg/myrepo", "env": "prod", "registry": "ignored"},
+	}
+	got := pushgatewayURL(cfg, runMetrics{Registry: "myregistry.azurecr.io", Repository: "team/app"})
+	expected := "http://pushgateway:9091/metrics/job/relicta-acr/env/prod/pipeline@base64/bXlvcmcvbXlyZXBv" +
+		"/registry/myregistry.azurecr.io/repository@base64/dGVhbS9hcHA"
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	if _, ok := cfg.Labels["repository"]; ok || cfg.Labels["registry"] != "ignored" {
+		t.Errorf("expected the configured labels to be left alone, got %v", cfg.Labels)
+	}
+
+	got = pushgatewayURL(MetricsConfig{URL: "http://pushgateway:9091", Job: "relicta-acr"}, runMetrics{Registry: "myregistry.azurecr.io"})
+	if expected := "http://pushgateway:9091/metrics/job/relicta-acr/registry/myregistry.azurecr.io"; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestPushedBytes(t *testing.T) {
+	metadata := []TagMetadata{
+		{Tag: "1.0.0", Digest: "sha256:a", Size: 100},
+		{Tag: "latest", Digest: "sha256:a", Size: 100},
+		{Tag: "1.0.0", Digest: "sha256:b", Size: 50},
+	}
+	if got := pushedBytes(metadata); got != 150 {
+		t.Errorf("expected 150 bytes, got %d", got)
+	}
+}
+
+func TestPushMetrics(t *testing.T) {
+	var method, path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	cfg := MetricsConfig{URL: server.URL, Job: "relicta-acr", Timeout: time.Second}
+	if err := pushMetrics(context.Background(), server.Client(), cfg, runMetrics{Registry: "myregistry.azurecr.io", Success: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if method != http.MethodPut || path != "/metrics/job/relicta-acr/registry/myregistry.azurecr.io" {
+		t.Errorf("unexpected request %s %s", method, path)
+	}
+	if !strings.Contains(body, `acr_push_failed{result="success"} 0`) {
+		t.Errorf("unexpected body:\n%s", body)
+	}
+}
+
+func TestPushMetrics_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad metrics", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	cfg := MetricsConfig{URL: server.URL, Job: "relicta-acr", Timeout: time.Second}
+	err := pushMetrics(context.Background(), server.Client(), cfg, runMetrics{})
+	if err == nil || !strings.Contains(err.Error(), "bad metrics") {
+		t.Errorf("expected the gateway's error, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// dockerHubDomains are the registry hosts that identify Docker Hub.
+var dockerHubDomains = map[string]bool{
+	"docker.io":            true,
+	"index.docker.io":      true,
+	"registry-1.docker.io": true,
+}
+
+// sourcePath returns the repository path of source without its registry host,
+// for use as the ACR path. Docker Hub official images get their implicit
+// library/ namespace, which stripLibrary drops instead; as in rewriteSource,
+// unqualified references are Docker Hub images only when pulled.
+func sourcePath(source string, stripLibrary, pulled bool) (string, error) {
+	ref, err := parseImageReference(source)
+	if err != nil {
+		return "", err
+	}
+	path := ref.Path
+	if dockerHubDomains[ref.Domain] || (ref.Domain == "" && pulled) {
+		if !strings.Contains(path, "/") {
+			path = "library/" + path
+		}
+		if stripLibrary {
+			path = strings.TrimPrefix(path, "library/")
+		}
+	}
+	return path, nil
+}
+
+// rewriteSource applies source_rewrite prefixes and the Docker Hub mirror to a
+// source reference. Unqualified references are treated as Docker Hub images
+// only when they are pulled, since otherwise they name local images.
+func rewriteSource(source, mirror string, rewrites map[string]string, pulled bool) string {
+	if rewritten, ok := applySourceRewrite(source, rewrites); ok {
+		return rewritten
+	}
+	if mirror == "" {
+		return source
+	}
+
+	ref, err := parseImageReference(source)
+	if err != nil {
+		return source
+	}
+	if !dockerHubDomains[ref.Domain] && (ref.Domain != "" || !pulled) {
+		return source
+	}
+
+	// Official images live under library/ on Docker Hub
+	path := ref.Path
+	if !strings.Contains(path, "/") {
+		path = "library/" + path
+	}
+
+	rewritten := strings.TrimSuffix(mirror, "/") + "/" + path
+	if ref.Tag != "" {
+		rewritten += ":" + ref.Tag
+	}
+	if ref.Digest != "" {
+		rewritten += "@" + ref.Digest
+	}
+	return rewritten
+}
+
+// applySourceRewrite replaces the longest matching prefix of source. Prefixes
+// only match whole path components.
+func applySourceRewrite(source string, rewrites map[string]string) (string, bool) {
+	prefixes := make([]string, 0, len(rewrites))
+	for prefix := range rewrites {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	for _, prefix := range prefixes {
+		trimmed := strings.TrimSuffix(prefix, "/")
+		if !strings.HasPrefix(source, trimmed) {
+			continue
+		}
+		rest := source[len(trimmed):]
+		if rest != "" && !strings.ContainsAny(rest[:1], "/:@") {
+			continue
+		}
+		return strings.TrimSuffix(rewrites[prefix], "/") + rest, true
+	}
+	return source, false
+}
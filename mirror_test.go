@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewriteSource(t *testing.T) {
+	const mirror = "mirror.corp.example/dockerhub"
+
+	tests := []struct {
+		name     string
+		source   string
+		mirror   string
+		rewrites map[string]string
+		pulled   bool
+		expected string
+	}{
+		{
+			name:     "explicit docker hub",
+			source:   "docker.io/bitnami/nginx:1.25",
+			mirror:   mirror,
+			expected: "mirror.corp.example/dockerhub/bitnami/nginx:1.25",
+		},
+		{
+			name:     "official image pulled",
+			source:   "nginx:1.25",
+			mirror:   mirror,
+			pulled:   true,
+			expected: "mirror.corp.example/dockerhub/library/nginx:1.25",
+		},
+		{
+			name:     "unqualified local image",
+			source:   "myapp:latest",
+			mirror:   mirror,
+			expected: "myapp:latest",
+		},
+		{
+			name:     "other registry untouched",
+			source:   "ghcr.io/org/app:1.0",
+			mirror:   mirror,
+			pulled:   true,
+			expected: "ghcr.io/org/app:1.0",
+		},
+		{
+			name:     "rewrite map",
+			source:   "ghcr.io/org/app:1.0",
+			mirror:   mirror,
+			rewrites: map[string]string{"ghcr.io": "mirror.corp.example/ghcr", "ghcr.io/org": "mirror.corp.example/org"},
+			expected: "mirror.corp.example/org/app:1.0",
+		},
+		{
+			name:     "rewrite matches whole components",
+			source:   "ghcr.io/organisation/app:1.0",
+			rewrites: map[string]string{"ghcr.io/org": "mirror.corp.example/org"},
+			expected: "ghcr.io/organisation/app:1.0",
+		},
+		{
+			name:     "no mirror",
+			source:   "docker.io/library/nginx:1.25",
+			expected: "docker.io/library/nginx:1.25",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rewriteSource(tt.source, tt.mirror, tt.rewrites, tt.pulled)
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSourcePath(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		stripLibrary bool
+		pulled       bool
+		expected     string
+	}{
+		{name: "docker hub official image", source: "docker.io/library/nginx:1.25", expected: "library/nginx"},
+		{name: "short docker hub name", source: "docker.io/nginx:1.25", expected: "library/nginx"},
+		{name: "strip library", source: "docker.io/library/nginx:1.25", stripLibrary: true, expected: "nginx"},
+		{name: "docker hub organization", source: "docker.io/bitnami/redis:7", stripLibrary: true, expected: "bitnami/redis"},
+		{name: "other registry", source: "ghcr.io/org/team/app@sha256:" + strings.Repeat("a", 64), expected: "org/team/app"},
+		{name: "other registry library path", source: "quay.io/library/app:1", stripLibrary: true, expected: "library/app"},
+		{name: "unqualified local image", source: "myapp:latest", expected: "myapp"},
+		{name: "unqualified pulled image", source: "nginx:1.25", pulled: true, expected: "library/nginx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sourcePath(tt.source, tt.stripLibrary, tt.pulled)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := sourcePath("Not A Reference", false, false); err == nil {
+		t.Error("expected an error for an invalid reference")
+	}
+}
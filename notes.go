@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// releaseNotesFileName is the file name the notes are attached under.
+const releaseNotesFileName = "RELEASE_NOTES.md"
+
+// ReleaseNotesConfig describes release notes to attach as a referring OCI artifact.
+type ReleaseNotesConfig struct {
+	Enabled   bool
+	Text      string
+	File      string
+	MediaType string
+}
+
+// renderReleaseNotes renders the configured notes template against the release
+// context. Without text or file the generated release notes are used.
+func renderReleaseNotes(cfg ReleaseNotesConfig, data *templateData) (string, error) {
+	tmpl := cfg.Text
+	if cfg.File != "" {
+		content, err := os.ReadFile(cfg.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read release notes file: %w", err)
+		}
+		tmpl = string(content)
+	}
+	if tmpl == "" {
+		tmpl = "{{.ReleaseNotes}}"
+	}
+
+	t, err := template.New("release_notes").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid release notes template: %w", err)
+	}
+	var buf strings.Builder
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render release notes: %w", err)
+	}
+	return buf.String(), nil
+}
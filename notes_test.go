@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestRenderReleaseNotes(t *testing.T) {
+	data := newTemplateData(&plugin.ReleaseContext{Version: "1.2.0", ReleaseNotes: "- Added widgets"})
+
+	file := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(file, []byte("# {{.Version}}\n{{.ReleaseNotes}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		cfg      ReleaseNotesConfig
+		expected string
+		wantErr  bool
+	}{
+		{name: "default", cfg: ReleaseNotesConfig{}, expected: "- Added widgets"},
+		{name: "inline text", cfg: ReleaseNotesConfig{Text: "Release {{.Version}}"}, expected: "Release 1.2.0"},
+		{name: "file", cfg: ReleaseNotesConfig{File: file}, expected: "# 1.2.0\n- Added widgets"},
+		{name: "unknown field", cfg: ReleaseNotesConfig{Text: "{{.Nope}}"}, wantErr: true},
+		{name: "missing file", cfg: ReleaseNotesConfig{File: filepath.Join(t.TempDir(), "missing.md")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderReleaseNotes(tt.cfg, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// signatureHeader carries the HMAC-SHA256 signature of a signed notification body.
+const signatureHeader = "X-Relicta-Signature"
+
+// NotifyConfig configures the post-push webhook.
+type NotifyConfig struct {
+	URL      string
+	Method   string
+	Headers  map[string]string
+	Body     string
+	Secret   string
+	Timeout  time.Duration
+	Required bool
+}
+
+// notifyPayload is the default webhook body.
+type notifyPayload struct {
+	Version      string            `json:"version"`
+	Registry     string            `json:"registry"`
+	PushedImages []string          `json:"pushed_images"`
+	Digests      map[string]string `json:"digests"`
+}
+
+// notifyTemplateData holds the values available to the notification body template.
+type notifyTemplateData struct {
+	*templateData
+
+	Registry     string
+	PushedImages []string
+	Digests      map[string]string
+
+	// Payload is the default JSON payload, for templates that wrap it.
+	Payload string
+}
+
+// validateNotifyURL checks that a webhook URL is an absolute http(s) URL.
+func validateNotifyURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("notify.url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// parseNotifyBody parses a notification body template.
+func parseNotifyBody(body string) (*template.Template, error) {
+	t, err := template.New("notify").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notify.body template: %w", err)
+	}
+	return t, nil
+}
+
+// renderNotifyBody renders the configured body template, or the default JSON payload.
+func renderNotifyBody(cfg NotifyConfig, data *notifyTemplateData) ([]byte, error) {
+	payload, err := json.Marshal(notifyPayload{
+		Version:      data.Version,
+		Registry:     data.Registry,
+		PushedImages: data.PushedImages,
+		Digests:      data.Digests,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode notification payload: %w", err)
+	}
+	if cfg.Body == "" {
+		return payload, nil
+	}
+
+	data.Payload = string(payload)
+	t, err := parseNotifyBody(cfg.Body)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render notify.body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// signPayload returns the hex HMAC-SHA256 of body keyed by secret.
+func signPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendNotification delivers the webhook and fails on non-2xx responses.
+func sendNotification(ctx context.Context, client *http.Client, cfg NotifyConfig, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, cfg.Method, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	if cfg.Secret != "" {
+		req.Header.Set(signatureHeader, signPayload(cfg.Secret, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification to %s failed: %w", cfg.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notification to %s returned %s: %s", cfg.URL, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestValidateNotifyURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{name: "https url", url: "https://hooks.example.com/release", wantErr: false},
+		{name: "http url", url: "http://localhost:8080/hook", wantErr: false},
+		{name: "missing scheme", url: "hooks.example.com/release", wantErr: true},
+		{name: "unsupported scheme", url: "ftp://hooks.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateNotifyURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateNotifyURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderNotifyBody(t *testing.T) {
+	data := &notifyTemplateData{
+		templateData: newTemplateData(&plugin.ReleaseContext{Version: "1.2.3"}),
+		Registry:     "myregistry.azurecr.io",
+		PushedImages: []string{"myregistry.azurecr.io/myapp:1.2.3"},
+		Digests:      map[string]string{},
+	}
+
+	body, err := renderNotifyBody(NotifyConfig{}, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var payload notifyPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("default body is not JSON: %v", err)
+	}
+	if payload.Version != "1.2.3" || len(payload.PushedImages) != 1 {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	body, err = renderNotifyBody(NotifyConfig{Body: `{"text":"released {{.Version}}","data":{{.Payload}}}`}, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !json.Valid(body) {
+		t.Errorf("expected templated body to be JSON, got %s", body)
+	}
+}
+
+func TestSendNotification(t *testing.T) {
+	var gotSignature, gotHeader string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(signatureHeader)
+		gotHeader = r.Header.Get("X-Team")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := NotifyConfig{
+		URL:     server.URL,
+		Method:  http.MethodPost,
+		Headers: map[string]string{"X-Team": "platform"},
+		Secret:  "s3cret",
+		Timeout: time.Second,
+	}
+	body := []byte(`{"version":"1.0.0"}`)
+
+	if err := sendNotification(context.Background(), http.DefaultClient, cfg, body); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(gotBody) != string(body) {
+		t.Errorf("unexpected body %s", gotBody)
+	}
+	if gotHeader != "platform" {
+		t.Errorf("expected custom header, got %q", gotHeader)
+	}
+	if gotSignature != signPayload("s3cret", body) {
+		t.Errorf("unexpected signature %q", gotSignature)
+	}
+}
+
+func TestSendNotification_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := NotifyConfig{URL: server.URL, Method: http.MethodPost, Timeout: time.Second}
+	if err := sendNotification(context.Background(), http.DefaultClient, cfg, []byte("{}")); err == nil {
+		t.Error("expected error for non-2xx response")
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// orasDigestPattern matches the digest line printed by oras.
+var orasDigestPattern = regexp.MustCompile(`(?m)^Digest:\s*(sha256:[0-9a-f]{64})`)
+
+// OrasClient provides ORAS CLI operations for OCI artifacts.
+type OrasClient struct {
+	runner CommandRunner
+}
+
+// NewOrasClient creates a new ORAS client.
+func NewOrasClient() *OrasClient {
+	return &OrasClient{runner: &ExecRunner{}}
+}
+
+// SetRunner replaces the runner used for oras commands.
+func (o *OrasClient) SetRunner(r CommandRunner) {
+	o.runner = r
+}
+
+// SetTranscript records every command this client runs.
+func (o *OrasClient) SetTranscript(t *Transcript) {
+	o.runner = &ExecRunner{Transcript: t}
+}
+
+// Attach attaches a file to a subject image as a referring artifact and
+// returns the artifact digest.
+func (o *OrasClient) Attach(ctx context.Context, subject, file, mediaType string) (string, error) {
+	cmd := Command{Name: "oras", Args: []string{
+		"attach",
+		"--artifact-type", mediaType,
+		subject,
+		fmt.Sprintf("%s:%s", file, mediaType),
+	}}
+	output, err := o.runner.Run(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("oras attach failed: %w\n%s", err, string(output))
+	}
+	return parseOrasDigest(string(output)), nil
+}
+
+// AttachContent attaches in-memory content to a subject image under the given
+// file name and returns the artifact digest.
+func (o *OrasClient) AttachContent(ctx context.Context, subject, name string, content []byte, mediaType string) (string, error) {
+	dir, err := os.MkdirTemp("", "relicta-acr-attach-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create attachment directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, name), content, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write attachment: %w", err)
+	}
+
+	// oras only accepts relative paths, so run it next to the file
+	cmd := Command{Name: "oras", Dir: dir, Args: []string{
+		"attach",
+		"--artifact-type", mediaType,
+		subject,
+		fmt.Sprintf("%s:%s", name, mediaType),
+	}}
+	output, err := o.runner.Run(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("oras attach failed: %w\n%s", err, string(output))
+	}
+	return parseOrasDigest(string(output)), nil
+}
+
+// CopyToOCILayout copies an image from its registry into an OCI image layout
+// directory, given as dir:tag, and returns the copied manifest digest.
+func (o *OrasClient) CopyToOCILayout(ctx context.Context, source, layout string) (string, error) {
+	cmd := Command{Name: "oras", Args: []string{"copy", "--to-oci-layout", source, layout}}
+	output, err := o.runner.Run(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("oras copy failed: %w\n%s", err, string(output))
+	}
+	return parseOrasDigest(string(output)), nil
+}
+
+// FetchConfig returns the config blob of an image in its registry.
+func (o *OrasClient) FetchConfig(ctx context.Context, image string) ([]byte, error) {
+	cmd := Command{Name: "oras", Args: []string{"manifest", "fetch-config", image}}
+	output, err := o.runner.Run(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("oras manifest fetch-config failed: %w\n%s", err, string(output))
+	}
+	return output, nil
+}
+
+// parseOrasDigest extracts the artifact digest from oras output.
+func parseOrasDigest(output string) string {
+	m := orasDigestPattern.FindStringSubmatch(output)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewOrasClient(t *testing.T) {
+	client := NewOrasClient()
+	if client == nil {
+		t.Fatal("expected non-nil client")
+	}
+}
+
+func TestParseOrasDigest(t *testing.T) {
+	digest := "sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{
+			name:     "attach output",
+			output:   "Uploading 1a2b3c sbom.json\nUploaded  1a2b3c sbom.json\nAttached to [registry] myregistry.azurecr.io/app@sha256:abc\nDigest: " + digest + "\n",
+			expected: digest,
+		},
+		{
+			name:     "no digest",
+			output:   "Error: unauthorized",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseOrasDigest(tt.output); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestOrasClient_AttachCommand(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("c", 64)
+	runner := &fakeRunner{
+		respond: func(Command) ([]byte, error) {
+			return []byte("Attached to [registry] myregistry.azurecr.io/app@sha256:...\nDigest: " + digest + "\n"), nil
+		},
+	}
+	client := NewOrasClient()
+	client.SetRunner(runner)
+
+	got, err := client.Attach(context.Background(), "myregistry.azurecr.io/app@sha256:abc", "sbom.json", "application/spdx+json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != digest {
+		t.Errorf("expected digest %q, got %q", digest, got)
+	}
+	runner.assertCommands(t, []Command{{Name: "oras", Args: []string{
+		"attach",
+		"--artifact-type", "application/spdx+json",
+		"myregistry.azurecr.io/app@sha256:abc",
+		"sbom.json:application/spdx+json",
+	}}})
+}
+
+func TestOrasClient_CopyToOCILayout(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("e", 64)
+	runner := &fakeRunner{
+		respond: func(Command) ([]byte, error) {
+			return []byte("Copied [registry] myregistry.azurecr.io/app:1.0.0 => [oci-layout] /archive/app:1.0.0\nDigest: " + digest + "\n"), nil
+		},
+	}
+	client := NewOrasClient()
+	client.SetRunner(runner)
+
+	got, err := client.CopyToOCILayout(context.Background(), "myregistry.azurecr.io/app:1.0.0", "/archive/app:1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != digest {
+		t.Errorf("expected digest %q, got %q", digest, got)
+	}
+	runner.assertCommands(t, []Command{{Name: "oras", Args: []string{
+		"copy", "--to-oci-layout", "myregistry.azurecr.io/app:1.0.0", "/archive/app:1.0.0",
+	}}})
+}
+
+func TestOrasClient_FetchConfig(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(Command) ([]byte, error) {
+			return []byte(`{"config":{"Labels":{"retain":"true"}}}`), nil
+		},
+	}
+	client := NewOrasClient()
+	client.SetRunner(runner)
+
+	got, err := client.FetchConfig(context.Background(), "myregistry.azurecr.io/app:1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != `{"config":{"Labels":{"retain":"true"}}}` {
+		t.Errorf("unexpected config %s", got)
+	}
+	runner.assertCommands(t, []Command{{Name: "oras", Args: []string{
+		"manifest", "fetch-config", "myregistry.azurecr.io/app:1.0.0",
+	}}})
+}
+
+func TestOrasClient_AttachContent(t *testing.T) {
+	var written string
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			data, err := os.ReadFile(filepath.Join(cmd.Dir, "NOTES.md"))
+			if err != nil {
+				return nil, err
+			}
+			written = string(data)
+			return []byte("Digest: sha256:" + strings.Repeat("d", 64)), nil
+		},
+	}
+	client := NewOrasClient()
+	client.SetRunner(runner)
+
+	digest, err := client.AttachContent(context.Background(), "myregistry.azurecr.io/app@sha256:abc", "NOTES.md", []byte("notes"), "text/markdown")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if written != "notes" {
+		t.Errorf("expected content to be written before attach, got %q", written)
+	}
+	if digest != "sha256:"+strings.Repeat("d", 64) {
+		t.Errorf("unexpected digest %q", digest)
+	}
+	runner.assertCommands(t, []Command{{Name: "oras", Args: []string{
+		"attach",
+		"--artifact-type", "text/markdown",
+		"myregistry.azurecr.io/app@sha256:abc",
+		"NOTES.md:text/markdown",
+	}}})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// platformPattern matches an os/arch[/variant] platform string.
+var platformPattern = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9_]+(?:/[a-z0-9]+)?$`)
+
+// platformMatches reports whether a detected platform satisfies the expected
+// one. An expected platform without a variant accepts any variant.
+func platformMatches(expected, detected string) bool {
+	if expected == detected {
+		return true
+	}
+	return strings.Count(expected, "/") == 1 && strings.HasPrefix(detected, expected+"/")
+}
+
+// checkPlatform fails unless one of the detected platforms matches expected.
+func checkPlatform(image, expected string, detected []string) error {
+	for _, platform := range detected {
+		if platformMatches(expected, platform) {
+			return nil
+		}
+	}
+	return fmt.Errorf("source image %s has platform(s) %s, expected %s",
+		image, strings.Join(detected, ", "), expected)
+}
+
+// parseManifestListPlatforms extracts the platforms of a manifest list or OCI
+// index as printed by docker manifest inspect.
+func parseManifestListPlatforms(output []byte) ([]string, error) {
+	var list struct {
+		Manifests []struct {
+			Platform struct {
+				OS           string `json:"os"`
+				Architecture string `json:"architecture"`
+				Variant      string `json:"variant"`
+			} `json:"platform"`
+		} `json:"manifests"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if len(list.Manifests) == 0 {
+		return nil, fmt.Errorf("manifest is not a multi-platform list; its platform cannot be read without pulling it")
+	}
+
+	var platforms []string
+	for _, m := range list.Manifests {
+		// Attestation manifests carry an unknown platform
+		if m.Platform.OS == "" || m.Platform.OS == "unknown" {
+			continue
+		}
+		platform := m.Platform.OS + "/" + m.Platform.Architecture
+		if m.Platform.Variant != "" {
+			platform += "/" + m.Platform.Variant
+		}
+		platforms = append(platforms, platform)
+	}
+	return platforms, nil
+}
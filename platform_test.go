@@ -0,0 +1,55 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPlatformMatches(t *testing.T) {
+	tests := []struct {
+		expected string
+		detected string
+		matches  bool
+	}{
+		{expected: "linux/amd64", detected: "linux/amd64", matches: true},
+		{expected: "linux/arm64", detected: "linux/arm64/v8", matches: true},
+		{expected: "linux/arm64/v8", detected: "linux/arm64", matches: false},
+		{expected: "linux/amd64", detected: "linux/arm64", matches: false},
+		{expected: "linux/arm", detected: "linux/arm64", matches: false},
+	}
+
+	for _, tt := range tests {
+		if got := platformMatches(tt.expected, tt.detected); got != tt.matches {
+			t.Errorf("platformMatches(%q, %q) = %v, want %v", tt.expected, tt.detected, got, tt.matches)
+		}
+	}
+}
+
+func TestCheckPlatform(t *testing.T) {
+	if err := checkPlatform("app:1.0", "linux/amd64", []string{"linux/arm64", "linux/amd64"}); err != nil {
+		t.Errorf("expected multi-platform image containing amd64 to pass: %v", err)
+	}
+	if err := checkPlatform("app:1.0", "linux/amd64", []string{"linux/arm64"}); err == nil {
+		t.Error("expected mismatched platform to fail")
+	}
+}
+
+func TestParseManifestListPlatforms(t *testing.T) {
+	output := []byte(`{"manifests":[
+		{"platform":{"os":"linux","architecture":"amd64"}},
+		{"platform":{"os":"linux","architecture":"arm64","variant":"v8"}},
+		{"platform":{"os":"unknown","architecture":"unknown"}}
+	]}`)
+
+	platforms, err := parseManifestListPlatforms(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(platforms, []string{"linux/amd64", "linux/arm64/v8"}) {
+		t.Errorf("unexpected platforms %v", platforms)
+	}
+
+	if _, err := parseManifestListPlatforms([]byte(`{"schemaVersion":2,"layers":[]}`)); err == nil {
+		t.Error("expected error for a single-platform manifest")
+	}
+}
@@ -2,8 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
 	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
@@ -12,16 +22,46 @@ import (
 // Version is set at build time.
 var Version = "dev"
 
+// warnOutput is where non-fatal warnings are written.
+var warnOutput io.Writer = os.Stderr
+
 // ACRPlugin implements the Relicta plugin interface for Azure Container Registry.
-type ACRPlugin struct{}
+type ACRPlugin struct {
+	// resolveDigest overrides source digest resolution (used in tests).
+	resolveDigest func(ctx context.Context, image string) (string, error)
+
+	// runner overrides the runner for external commands (used in tests).
+	runner CommandRunner
+
+	// stdin overrides os.Stdin for source_stdin (used in tests).
+	stdin io.Reader
+}
 
 // Config holds the plugin configuration.
 type Config struct {
 	// ACR Configuration
 	Registry   string
+	Namespace  string
 	Repository string
 	Image      string
 
+	// PathMode selects which of repository and image form the path
+	PathMode string
+
+	// DerivePathFromSource takes repository and image from the source_image
+	// path; StripLibraryNamespace drops Docker Hub's implicit library/
+	DerivePathFromSource  bool
+	StripLibraryNamespace bool
+
+	// NormalizeSource expands source_image to its fully qualified form first
+	NormalizeSource bool
+
+	// AllowedRegistries restricts pushes to these registries when non-empty;
+	// EnvAllowedRegistries, from ACR_ALLOWED_REGISTRIES, does the same, and a
+	// registry must pass both
+	AllowedRegistries    []string
+	EnvAllowedRegistries []string
+
 	// Authentication
 	AuthMethod   string
 	ClientID     string
@@ -29,26 +69,349 @@ type Config struct {
 	TenantID     string
 	Username     string
 	Password     string
+	Token        string
+
+	// Credential files read at runtime, taking precedence over the values above
+	ClientSecretFile string
+	PasswordFile     string
+	TokenFile        string
+
+	// SuggestOnNotFound lists accessible registries to suggest a fix when the
+	// registry does not exist
+	SuggestOnNotFound bool
+
+	// Proxy for HTTP calls and external commands
+	Proxy ProxyConfig
+
+	// TLS constrains HTTP connections
+	TLS TLSConfig
+
+	// HTTPTransport tunes connection reuse; transport is built from it once per run
+	HTTPTransport HTTPTransportConfig
+	transport     *http.Transport
+
+	// UserAgentSuffix is appended to the User-Agent of HTTP and az requests
+	UserAgentSuffix string
+
+	// Notify posts a webhook after a successful push
+	Notify NotifyConfig
+
+	// Metrics pushes run metrics to a Prometheus pushgateway after every run
+	Metrics MetricsConfig
+
+	// Lock serializes concurrent runs pushing to the same repository
+	Lock LockConfig
+
+	// PreserveRegistryCase keeps the registry's casing in image references
+	PreserveRegistryCase bool
+
+	// Subscription selects the Azure subscription before az acr login
+	Subscription string
+
+	// ReportRegistryInfo adds the registry's SKU, location and encryption to the outputs
+	ReportRegistryInfo bool
+
+	// VerifyAnonymousPull is the expected anonymous pull setting, "enabled"
+	// or "disabled"; SetAnonymousPull corrects a mismatch instead of failing
+	// and AnonymousPullWarnOnly only warns about one
+	VerifyAnonymousPull   string
+	SetAnonymousPull      bool
+	AnonymousPullWarnOnly bool
+
+	// TranscriptFile receives a JSON line for every external command
+	TranscriptFile string
+
+	// AuditLog receives one JSON line per registry mutation
+	AuditLog string
+
+	// AzureConfigDir isolates the Azure CLI session ("isolated" for a per-run temp dir)
+	AzureConfigDir string
+
+	// AcknowledgeAdminAuth silences the admin credential warning
+	AcknowledgeAdminAuth bool
+	// FailOnEmptyCredentials turns configured-but-empty credentials into errors
+	FailOnEmptyCredentials bool
+	// VerifyCredentialHelper checks the docker config for a credential helper
+	VerifyCredentialHelper bool
+
+	// Additional image names published from the same source
+	AdditionalImages []ImageTarget
 
 	// Source image
 	SourceImage string
+	PullSource  bool
+	SourceStdin bool
+
+	// Source rewriting applied before the source is pulled, tagged or imported
+	SourceRegistryMirror string
+	SourceRewrite        map[string]string
+
+	// Promote copies SourceImage server-side from within ACR instead of pushing a local image
+	Promote bool
+
+	// AutoServerSide promotes a source_image already in the target registry instead of pushing it
+	AutoServerSide bool
+
+	// SourceRegistry authenticates a promote from another registry
+	SourceRegistry SourceCredentials
+
+	// SBOM attached to each pushed image
+	SBOM SBOMConfig
+
+	// ReleaseNotes attached to each pushed image
+	ReleaseNotes ReleaseNotesConfig
+
+	// RecordBaseImage reports the base image named by the source image's OCI
+	// labels; AttachBaseImage also attaches it to each pushed image
+	RecordBaseImage bool
+	AttachBaseImage bool
+
+	// Provenance attested for each pushed image
+	Provenance ProvenanceConfig
+
+	// ArchiveOCILayout is a directory receiving an OCI image layout of each pushed image
+	ArchiveOCILayout string
+
+	// SigningManifestFile receives the pushed references and digests for signing out of band
+	SigningManifestFile string
 
 	// Tags
-	Tags []string
+	Tags         []string
+	FloatingTags []string
+
+	// SuccessMessage is the template for the Execute message
+	SuccessMessage string
+
+	// GitDescribe supplies {{.GitDescribe}} directly or from a command
+	GitDescribe GitDescribeConfig
+
+	// LatestPolicy controls whether a configured "latest" tag is pushed:
+	// always, stable_only (not for prereleases) or never
+	LatestPolicy string
+
+	// ProvenanceInputs are the templates hashed into {{.ProvenanceHash}}
+	ProvenanceInputs []string
+
+	// Bump selects how {{.NextVersion}} is derived from the version
+	Bump string
+
+	// SequencePattern matches the existing tags {{.NextSequence}} counts from
+	SequencePattern string
+
+	// DisableDefaultTag stops {{.Version}} from being pushed when no tags are
+	// configured; RequireTags rejects the resulting empty tag list
+	DisableDefaultTag bool
+	RequireTags       bool
+
+	// BranchPolicy restricts which branches may push to matching repositories
+	BranchPolicy []BranchRule
+
+	// RequireImmutableTag fails when every pushed tag is in MutableTags, which
+	// always includes FloatingTags
+	RequireImmutableTag bool
+	MutableTags         []string
+
+	TemplateVars map[string]string
+	CIVars       map[string][]string
+	TagsLimit    int
+
+	// ExpectedPlatform is the os/arch[/variant] the source image must provide
+	ExpectedPlatform string
+
+	// Size guard
+	MaxImageSize       int64
+	AllowOversizeImage bool
+
+	// DockerPreflight checks that the Docker daemon is reachable before starting
+	DockerPreflight bool
+
+	// PushHooks run commands before and after the push
+	PushHooks PushHooksConfig
+
+	// EnabledHooks are the lifecycle hooks Execute pushes at; HookRepositories
+	// replace Repository at the named hooks
+	EnabledHooks     []string
+	HookRepositories map[string]string
+
+	// CleanupLocalTags removes the local registry tags after a successful push
+	CleanupLocalTags bool
+
+	// IsolateLocalTags tags and pushes through a unique intermediate tag,
+	// then moves the release tag to the pushed digest server-side
+	IsolateLocalTags bool
+
+	// Push verification
+	VerifyAfterPush bool
+	VerifyTimeout   time.Duration
+
+	// VerifyIntegrity compares each pushed tag with the source digest captured
+	// before pushing
+	VerifyIntegrity bool
+
+	// DeletePreviousBut keeps this many versions before the current release and
+	// untags the one that falls out of that window
+	DeletePreviousBut int
+
+	// ProtectLabels keeps a retention candidate whose image config carries any
+	// of these labels
+	ProtectLabels map[string]string
+
+	// UntaggedRetention enables the registry's untagged-manifest retention
+	// policy with this many days (Premium only); 0 leaves it unchanged
+	UntaggedRetention int
+
+	// Overwrite protection
+	NoOverwrite bool
+	Force       bool
+
+	// TagMetadata inspects each pushed tag for the tag_metadata output
+	TagMetadata bool
+
+	// ReportTagNovelty classifies each tag as new or overwritten before pushing
+	ReportTagNovelty bool
 
 	// Behavior
-	DryRun bool
+	Enabled        bool
+	DryRun         bool
+	DryRunMode     string
+	MaxUploadRate  int
+	ExecuteTimeout time.Duration
+
+	// EfficientTagging pushes one tag per image and copies the rest server-side
+	EfficientTagging bool
+
+	// MaxParallel bounds the pushes in flight across all images and tags
+	MaxParallel int
+
+	// MaxPushes caps the push attempts, successful or failed, of a run; 0 is unlimited
+	MaxPushes int
+}
+
+// LockConfig configures the host-local push lock.
+type LockConfig struct {
+	Enabled bool
+	Dir     string
+	Timeout time.Duration
+}
+
+// ImageReference describes one pushed image by tag and, when known, by digest.
+type ImageReference struct {
+	Tag       string `json:"tag"`
+	TagRef    string `json:"tag_ref"`
+	Digest    string `json:"digest"`
+	DigestRef string `json:"digest_ref"`
+}
+
+// newImageReference builds the reference forms for an image pushed under tag.
+// The digest forms stay empty when the digest is unknown.
+func newImageReference(registryURL, imagePath, tag, digest string) ImageReference {
+	ref := ImageReference{
+		Tag:    tag,
+		TagRef: fmt.Sprintf("%s/%s:%s", registryURL, imagePath, tag),
+		Digest: digest,
+	}
+	if digest != "" {
+		ref.DigestRef = fmt.Sprintf("%s/%s@%s", registryURL, imagePath, digest)
+	}
+	return ref
+}
+
+// SBOMConfig describes an SBOM to attach as a referring OCI artifact.
+type SBOMConfig struct {
+	File      string
+	MediaType string
+}
+
+// ImageTarget is an image name within the registry.
+type ImageTarget struct {
+	Namespace  string
+	Repository string
+	Image      string
+}
+
+// WithPathMode drops the component path_mode leaves out: the repository for
+// "image_only", the image for "repository_only". "compose" keeps both.
+func (t ImageTarget) WithPathMode(mode string) ImageTarget {
+	switch mode {
+	case "image_only":
+		t.Repository = ""
+	case "repository_only":
+		t.Image = ""
+	}
+	return t
+}
+
+// Path returns the image path within the registry, skipping empty components.
+func (t ImageTarget) Path() string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{t.Namespace, t.Repository, t.Image} {
+		if part = strings.Trim(part, "/"); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
 }
 
 // GetInfo returns plugin metadata.
 func (p *ACRPlugin) GetInfo() plugin.Info {
 	return plugin.Info{
-		Name:        "acr",
-		Version:     Version,
-		Description: "Push container images to Azure Container Registry (ACR)",
-		Hooks: []plugin.Hook{
-			plugin.HookPostPublish,
-		},
+		Name:         "acr",
+		Version:      Version,
+		Description:  "Push container images to Azure Container Registry (ACR)",
+		ConfigSchema: p.configSchemaJSON(),
+		Hooks:        slices.Clone(supportedHooks),
+	}
+}
+
+// SupportedAuthMethods returns the values accepted by auth.method.
+func (p *ACRPlugin) SupportedAuthMethods() []string {
+	return []string{"azure_cli", "service_principal", "admin", "managed_identity", "existing", "token", "credential_helper"}
+}
+
+// OutputSchema returns the output keys produced by Execute with their descriptions.
+func (p *ACRPlugin) OutputSchema() map[string]string {
+	return map[string]string{
+		"registry":              "Full registry URL",
+		"platforms":             "Platforms detected on the source image when expected_platform is set",
+		"source_image":          "Effective source reference after mirror and rewrite rules",
+		"repository":            "Repository name, after template rendering",
+		"image_path":            "Composed path of the primary image within the registry",
+		"derived_path":          "Path taken from source_image by derive_path_from_source (empty otherwise)",
+		"normalized_source":     "source_image as normalized by normalize_source (empty otherwise)",
+		"tags":                  "List of processed tags that were pushed",
+		"resolved_tags":         "List of processed tags before tags_limit was applied",
+		"floating_tags":         "Tags of this run that mutable_tags or floating_tags treat as floating",
+		"pushed_images":         "List of pushed image references, in configured tag order",
+		"pushed_by_image":       "Pushed image references grouped by image path",
+		"digests":               "Manifest digest of each pushed image reference",
+		"references":            "Tag and digest reference forms of each pushed image",
+		"source_digest":         "Source digest captured before pushing (verify_integrity)",
+		"verified_digests":      "Registry digest verified for each pushed tag (verify_integrity)",
+		"sequence":              "Number chosen for {{.NextSequence}} (0 when unused)",
+		"tag_metadata":          "Per pushed tag: reference, digest, media type, compressed size, layers, platforms and push duration (tag_metadata)",
+		"manifest_types":        "Manifest media type of each pushed image reference, schema2 or OCI (tag_metadata)",
+		"new_tags":              "Image references that did not exist before the run (report_tag_novelty)",
+		"overwritten_tags":      "Image references that already existed and were overwritten (report_tag_novelty)",
+		"untagged_tags":         "Image references untagged by delete_previous_but; their manifests stay",
+		"deleted_tags":          "Deprecated alias of untagged_tags",
+		"protected_tags":        "Image references delete_previous_but kept because of protect_labels",
+		"reclaimable_bytes":     "Estimated storage freed by the deleted tags once ACR reclaims it",
+		"promoted_digest":       "Manifest digest copied by promote (empty otherwise)",
+		"imported_images":       "References imported by promote (empty otherwise)",
+		"server_side":           "Whether tags were copied server-side by promote or auto_server_side",
+		"acr_primary_digest":    "Digest pushed for the first tag of the primary image (empty in dry runs)",
+		"acr_primary_reference": "Digest reference of the primary push, or its tag reference when the digest is unknown",
+		"registry_info":         "Registry SKU, location and encryption status when report_registry_info is set",
+		"anonymous_pull":        "Final anonymous pull setting, enabled or disabled, when verify_anonymous_pull is set",
+		"subscription":          "ID of the Azure subscription the az session used (empty for admin and token auth and dry runs)",
+		"sbom_digests":          "Digest of the attached SBOM artifact for each image path",
+		"release_notes_digests": "Digest of the attached release notes artifact for each image path",
+		"base_image":            "Name and digest of the base image from the source image's OCI labels (record_base_image)",
+		"base_image_artifacts":  "Digest of the attached base image record for each image path (attach_base_image)",
+		"oci_layouts":           "OCI layout path (dir:tag) and digest archived by archive_oci_layout for each image path",
+		"provenance_digests":    "Digest of the SLSA provenance attestation for each image path",
+		"steps":                 "Ordered phases of the run with status, start time and duration",
+		"upload_rate":           "Effective push bandwidth limit in bytes/sec (0 means unthrottled)",
 	}
 }
 
@@ -57,24 +420,136 @@ func (p *ACRPlugin) Validate(ctx context.Context, config map[string]any) (*plugi
 	vb := helpers.NewValidationBuilder()
 	cfg := p.parseConfig(config)
 
+	// Nothing is required when the plugin is disabled
+	if !cfg.Enabled {
+		return vb.Build(), nil
+	}
+
+	// Check the mode Execute will run in
+	cfg.applyAutoServerSide()
+
 	// Registry is required
 	if cfg.Registry == "" {
 		vb.AddError("registry", "ACR registry name is required")
+	} else if err := checkRegistryPolicy(cfg.Registry, cfg.EnvAllowedRegistries, cfg.AllowedRegistries); err != nil {
+		vb.AddError("registry", err.Error())
+	}
+
+	if err := validateBranchPolicy(cfg.BranchPolicy); err != nil {
+		vb.AddError("branch_policy", err.Error())
 	}
 
-	// Image name is required
-	if cfg.Image == "" {
+	// Image name is required unless the path is derived from the source or
+	// built from the repository alone
+	switch {
+	case cfg.DerivePathFromSource:
+		if cfg.Image != "" || cfg.Repository != "" {
+			vb.AddError("derive_path_from_source", "derive_path_from_source replaces repository and image; remove them")
+		}
+	case cfg.PathMode == "repository_only":
+		if cfg.Repository == "" {
+			vb.AddError("repository", "path_mode repository_only requires repository")
+		}
+	case cfg.Image == "":
 		vb.AddError("image", "image name is required")
 	}
 
+	switch cfg.PathMode {
+	case "compose", "image_only":
+	case "repository_only":
+		// Every additional image would collapse onto the same repository
+		if len(cfg.AdditionalImages) > 0 {
+			vb.AddError("path_mode", "path_mode repository_only cannot be combined with additional_images")
+		}
+	default:
+		vb.AddError("path_mode", "path_mode must be one of: compose, image_only, repository_only")
+	}
+
 	// Source image is required
 	if cfg.SourceImage == "" {
 		vb.AddError("source_image", "source image is required")
+	} else if _, err := parseImageReference(cfg.SourceImage); err != nil {
+		vb.AddError("source_image", err.Error())
+	} else if cfg.Promote {
+		if err := validatePromotionSource(cfg.SourceImage); err != nil {
+			vb.AddError("source_image", err.Error())
+		}
+	}
+	if err := validateSourceCredentials(cfg.SourceRegistry, cfg.SourceImage, cfg.Registry, cfg.Promote); err != nil {
+		vb.AddError("source_registry", err.Error())
+	}
+	if cfg.VerifyIntegrity && cfg.Promote && isCrossRegistrySource(cfg.SourceImage, cfg.Registry) {
+		vb.AddError("verify_integrity", "verify_integrity cannot resolve the digest of a promote source in another registry")
+	}
+
+	// A streamed source is loaded locally, never pulled or promoted
+	if cfg.SourceStdin && (cfg.PullSource || cfg.Promote) {
+		vb.AddError("source_stdin", "source_stdin cannot be combined with pull_source or promote")
+	}
+
+	// Promotion never touches the local Docker daemon
+	if cfg.Promote && cfg.PullSource {
+		vb.AddError("pull_source", "pull_source cannot be combined with promote")
+	}
+
+	// SBOM file must exist
+	if cfg.SBOM.File != "" {
+		if err := helpers.ValidateAssetPath(cfg.SBOM.File); err != nil {
+			vb.AddError("sbom.file", err.Error())
+		}
+	}
+
+	// Namespace must be a valid repository path prefix
+	// Templated paths are checked once rendered
+	if cfg.Namespace != "" && !strings.Contains(cfg.Namespace, "{{") && !namespacePattern.MatchString(strings.Trim(cfg.Namespace, "/")) {
+		vb.AddError("namespace", "namespace must be lowercase path components of letters, digits and '.', '_' or '-' separated by '/'")
+	}
+
+	// Release notes come from inline text or a file, not both
+	if cfg.ReleaseNotes.Text != "" && cfg.ReleaseNotes.File != "" {
+		vb.AddError("release_notes", "set either release_notes.text or release_notes.file, not both")
+	}
+	if cfg.AttachBaseImage && !cfg.RecordBaseImage {
+		vb.AddError("attach_base_image", "attach_base_image requires record_base_image")
+	}
+	if cfg.ReleaseNotes.File != "" {
+		if err := helpers.ValidateAssetPath(cfg.ReleaseNotes.File); err != nil {
+			vb.AddError("release_notes.file", err.Error())
+		}
+	}
+
+	// Provenance must name the builder it vouches for
+	if cfg.Provenance.Enabled && cfg.Provenance.BuilderID == "" {
+		vb.AddError("provenance.builder_id", "provenance requires builder_id, such as the URI of the CI workflow")
+	}
+
+	// The archive must be writable before anything is pushed
+	if cfg.ArchiveOCILayout != "" {
+		if err := checkWritableDir(cfg.ArchiveOCILayout); err != nil {
+			vb.AddError("archive_oci_layout", err.Error())
+		}
+	}
+	if cfg.SigningManifestFile != "" {
+		if err := checkWritableDir(filepath.Dir(cfg.SigningManifestFile)); err != nil {
+			vb.AddError("signing_manifest_file", err.Error())
+		}
+	}
+
+	// Every additional image needs a name
+	for i, target := range cfg.AdditionalImages {
+		if target.Image == "" {
+			vb.AddError(fmt.Sprintf("additional_images[%d].image", i), "additional image name is required")
+		}
+	}
+
+	// Credential files take precedence over inline and env values
+	if err := cfg.loadCredentialFiles(); err != nil {
+		vb.AddError("auth", err.Error())
 	}
 
 	// Validate auth method
-	validMethods := []string{"azure_cli", "service_principal", "admin", "managed_identity", ""}
-	isValidMethod := false
+	validMethods := p.SupportedAuthMethods()
+	isValidMethod := cfg.AuthMethod == ""
 	for _, m := range validMethods {
 		if cfg.AuthMethod == m {
 			isValidMethod = true
@@ -82,13 +557,20 @@ func (p *ACRPlugin) Validate(ctx context.Context, config map[string]any) (*plugi
 		}
 	}
 	if !isValidMethod {
-		vb.AddError("auth.method", "auth method must be 'azure_cli', 'service_principal', 'admin', or 'managed_identity'")
+		vb.AddError("auth.method", fmt.Sprintf("auth method must be one of: %s", strings.Join(validMethods, ", ")))
 	}
 
 	// Service principal requires credentials
 	if cfg.AuthMethod == "service_principal" {
-		if cfg.ClientID == "" || cfg.ClientSecret == "" || cfg.TenantID == "" {
-			vb.AddError("auth", "service principal requires client_id, client_secret, and tenant_id")
+		// Name each missing credential so the secret that did not propagate is obvious
+		for _, field := range []struct{ key, env, value string }{
+			{"client_id", "AZURE_CLIENT_ID", cfg.ClientID},
+			{"client_secret", "AZURE_CLIENT_SECRET", cfg.ClientSecret},
+			{"tenant_id", "AZURE_TENANT_ID", cfg.TenantID},
+		} {
+			if field.value == "" {
+				vb.AddError("auth."+field.key, fmt.Sprintf("service principal requires %s (or %s), which is empty", field.key, field.env))
+			}
 		}
 	}
 
@@ -97,24 +579,428 @@ func (p *ACRPlugin) Validate(ctx context.Context, config map[string]any) (*plugi
 		if cfg.Username == "" || cfg.Password == "" {
 			vb.AddError("auth", "admin auth requires username and password")
 		}
+		if !cfg.AcknowledgeAdminAuth {
+			warnf("auth method 'admin' uses the registry-wide admin account, which Azure security baselines discourage " +
+				"because it cannot be scoped or audited per identity; prefer 'service_principal' or 'managed_identity', " +
+				"or set auth.acknowledge_admin_auth to silence this warning")
+		}
+	}
+
+	// The credential helper supplies credentials; configured ones would be unused
+	if cfg.AuthMethod == "credential_helper" {
+		if authRaw, ok := config["auth"].(map[string]any); ok {
+			for _, key := range []string{"client_id", "client_secret", "tenant_id", "username", "password", "token"} {
+				if _, configured := authRaw[key]; configured {
+					warnf("auth.%s is ignored by auth method 'credential_helper'", key)
+				}
+			}
+		}
+	}
+
+	// Token auth needs the token itself
+	if cfg.AuthMethod == "token" && cfg.Token == "" {
+		vb.AddError("auth.token", "token auth requires auth.token or ACR_ACCESS_TOKEN")
+	}
+
+	// Managed identity depends on the host and cannot be checked statically
+	if cfg.AuthMethod == "managed_identity" {
+		warnf("auth method 'managed_identity' requires the host to have an assigned identity " +
+			"with push rights on the registry; this cannot be verified during validation")
+	}
+
+	// Credentials that are configured but resolve to nothing usually mean
+	// a secret did not propagate
+	if authRaw, ok := config["auth"].(map[string]any); ok {
+		resolved := map[string]string{
+			"client_id":     cfg.ClientID,
+			"client_secret": cfg.ClientSecret,
+			"tenant_id":     cfg.TenantID,
+			"username":      cfg.Username,
+			"password":      cfg.Password,
+			"token":         cfg.Token,
+		}
+		for _, key := range []string{"client_id", "client_secret", "tenant_id", "username", "password", "token"} {
+			if _, configured := authRaw[key]; !configured || resolved[key] != "" {
+				continue
+			}
+			msg := fmt.Sprintf("auth.%s is configured but resolved to an empty value", key)
+			if cfg.FailOnEmptyCredentials {
+				vb.AddError("auth."+key, msg)
+			} else {
+				warnf("%s", msg)
+			}
+		}
+	}
+
+	// Proxy URLs must be usable by both Go and the CLIs
+	for field, raw := range map[string]string{"proxy.http": cfg.Proxy.HTTP, "proxy.https": cfg.Proxy.HTTPS} {
+		if raw == "" {
+			continue
+		}
+		if err := validateProxyURL(raw); err != nil {
+			vb.AddError(field, err.Error())
+		}
+	}
+
+	// Push hook commands must be valid templates
+	for field, command := range map[string]string{"pre_push_command": cfg.PushHooks.Pre, "post_push_command": cfg.PushHooks.Post} {
+		if err := validateHookCommand(command); err != nil {
+			vb.AddError(field, err.Error())
+		}
+	}
+
+	// Hook timeout must be a valid duration
+	if raw := helpers.NewConfigParser(helpers.NewConfigParser(config).GetMap("hooks")).GetString("timeout", "", ""); raw != "" {
+		if d, err := time.ParseDuration(raw); err != nil || d <= 0 {
+			vb.AddError("hooks.timeout", "hooks.timeout must be a positive duration such as '5m'")
+		}
+	}
+
+	if err := validateLifecycleHooks(cfg.EnabledHooks, cfg.HookRepositories); err != nil {
+		vb.AddError("hooks", err.Error())
+	}
+
+	// Only TLS versions the security baseline allows can be required
+	if _, ok := tlsVersions[cfg.TLS.MinVersion]; cfg.TLS.MinVersion != "" && !ok {
+		vb.AddError("tls.min_version", "tls.min_version must be one of: 1.2, 1.3")
+	}
+
+	// Anonymous pull expectation
+	if _, ok := anonymousPullStates[cfg.VerifyAnonymousPull]; cfg.VerifyAnonymousPull != "" && !ok {
+		vb.AddError("verify_anonymous_pull", "verify_anonymous_pull must be 'enabled' or 'disabled'")
+	}
+	if cfg.SetAnonymousPull && cfg.VerifyAnonymousPull == "" {
+		vb.AddError("set_anonymous_pull", "set_anonymous_pull requires verify_anonymous_pull to name the intended state")
+	}
+
+	// Connection pool settings must be usable
+	if cfg.HTTPTransport.MaxIdleConnsPerHost < 1 {
+		vb.AddError("http_transport.max_idle_conns_per_host", "http_transport.max_idle_conns_per_host must be at least 1")
+	}
+	if raw := helpers.NewConfigParser(helpers.NewConfigParser(config).GetMap("http_transport")).GetString("idle_conn_timeout", "", ""); raw != "" {
+		if d, err := time.ParseDuration(raw); err != nil || d <= 0 {
+			vb.AddError("http_transport.idle_conn_timeout", "http_transport.idle_conn_timeout must be a positive duration such as '90s'")
+		}
+	}
+
+	// Notification webhook
+	if cfg.Notify.URL != "" {
+		if err := validateNotifyURL(cfg.Notify.URL); err != nil {
+			vb.AddError("notify.url", err.Error())
+		}
+		if cfg.Notify.Body != "" {
+			if _, err := parseNotifyBody(cfg.Notify.Body); err != nil {
+				vb.AddError("notify.body", err.Error())
+			}
+		}
+	}
+
+	// Metrics pushgateway
+	if cfg.Metrics.URL != "" {
+		if err := validateMetricsURL(cfg.Metrics.URL); err != nil {
+			vb.AddError("metrics_pushgateway.url", err.Error())
+		}
+	}
+
+	// Subscription must be a subscription ID or display name
+	if cfg.Subscription != "" && !isValidSubscription(cfg.Subscription) {
+		vb.AddError("subscription", "subscription must be a subscription ID (GUID) or name")
+	}
+
+	// Upload rate is bytes per second
+	if cfg.MaxUploadRate < 0 {
+		vb.AddError("max_upload_rate", "max_upload_rate must not be negative")
+	}
+
+	// Transcript file must be writable
+	if cfg.TranscriptFile != "" {
+		if t, err := OpenTranscript(cfg.TranscriptFile); err != nil {
+			vb.AddError("transcript_file", err.Error())
+		} else {
+			t.Close()
+		}
+	}
+
+	// Expected platform must be os/arch[/variant]
+	if cfg.ExpectedPlatform != "" && !platformPattern.MatchString(cfg.ExpectedPlatform) {
+		vb.AddError("expected_platform", "expected_platform must look like os/arch or os/arch/variant, such as 'linux/amd64'")
+	}
+
+	// Image size limit must be a positive size
+	if raw := helpers.NewConfigParser(config).GetString("max_image_size", "", ""); raw != "" {
+		if _, err := parseByteSize(raw); err != nil {
+			vb.AddError("max_image_size", err.Error())
+		}
+	}
+
+	// CI variable overrides must name known variables
+	for key := range cfg.CIVars {
+		if _, ok := defaultCIVars[key]; !ok {
+			vb.AddError("ci_vars."+key, "unknown CI variable; expected build_number, run_id or pipeline_id")
+		}
+	}
+
+	// Verification timeout must be a valid duration
+	if raw := helpers.NewConfigParser(config).GetString("verify_timeout", "", ""); raw != "" {
+		if d, err := time.ParseDuration(raw); err != nil || d <= 0 {
+			vb.AddError("verify_timeout", "verify_timeout must be a positive duration such as '30s'")
+		}
+	}
+
+	// Success message must be a valid template
+	if _, err := parseSuccessMessage(cfg.SuccessMessage); err != nil {
+		vb.AddError("success_message", err.Error())
+	}
+
+	// Version bump for {{.NextVersion}}
+	switch cfg.Bump {
+	case "major", "minor", "patch":
+	default:
+		vb.AddError("bump", "bump must be one of: major, minor, patch")
+	}
+
+	// Sequence tags are matched with a regular expression
+	if _, err := regexp.Compile(cfg.SequencePattern); err != nil {
+		vb.AddError("sequence_pattern", fmt.Sprintf("invalid sequence_pattern: %v", err))
+	}
+
+	// When to move the latest tag
+	switch cfg.LatestPolicy {
+	case "always", "stable_only", "never":
+	default:
+		vb.AddError("latest_policy", "latest_policy must be one of: always, stable_only, never")
+	}
+
+	// Without the default tag an empty list must be intentional
+	if len(cfg.Tags) == 0 && cfg.RequireTags {
+		vb.AddError("tags", "no tags configured and disable_default_tag is set; add tags or set require_tags: false")
+	}
+
+	// Push concurrency
+	if cfg.MaxParallel < 1 {
+		vb.AddError("max_parallel", "max_parallel must be at least 1")
+	}
+
+	// Push cap; zero means unlimited
+	if cfg.MaxPushes < 0 {
+		vb.AddError("max_pushes", "max_pushes must not be negative")
+	}
+
+	// Isolated tags reach the release tag through az acr import
+	if cfg.IsolateLocalTags && !usesAzSession(cfg.AuthMethod) {
+		vb.AddError("isolate_local_tags", fmt.Sprintf("isolate_local_tags moves tags with az acr import, which auth method '%s' cannot run", cfg.AuthMethod))
+	}
+
+	// Efficient tagging copies the remaining tags through az acr import
+	if cfg.EfficientTagging && !usesAzSession(cfg.AuthMethod) {
+		vb.AddError("efficient_tagging", fmt.Sprintf("efficient_tagging copies tags with az acr import, which auth method '%s' cannot run", cfg.AuthMethod))
+	}
+
+	// Retention window
+	if cfg.DeletePreviousBut < 0 {
+		vb.AddError("delete_previous_but", "delete_previous_but must not be negative")
+	}
+	if len(cfg.ProtectLabels) > 0 && cfg.DeletePreviousBut == 0 {
+		vb.AddError("protect_labels", "protect_labels only applies with delete_previous_but")
+	}
+	if cfg.UntaggedRetention < 0 || cfg.UntaggedRetention > 365 {
+		vb.AddError("untagged_retention", "untagged_retention must be between 0 and 365 days")
+	}
+	if cfg.UntaggedRetention > 0 && !usesAzSession(cfg.AuthMethod) {
+		vb.AddError("untagged_retention", fmt.Sprintf("untagged_retention is set with az acr config retention, which auth method '%s' cannot run", cfg.AuthMethod))
+	}
+
+	// Tag limit
+	if cfg.TagsLimit < 0 {
+		vb.AddError("tags_limit", "tags_limit must not be negative")
+	}
+
+	// Dry-run mode
+	if cfg.DryRunMode != "full" && cfg.DryRunMode != "push_skip" {
+		vb.AddError("dry_run_mode", "dry_run_mode must be 'full' or 'push_skip'")
+	}
+
+	// Execute timeout must be a valid duration
+	if raw := helpers.NewConfigParser(config).GetString("execute_timeout", "", ""); raw != "" {
+		if d, err := time.ParseDuration(raw); err != nil || d <= 0 {
+			vb.AddError("execute_timeout", "execute_timeout must be a positive duration such as '10m'")
+		}
 	}
 
 	return vb.Build(), nil
 }
 
 // Execute runs the plugin logic.
-func (p *ACRPlugin) Execute(ctx context.Context, req plugin.ExecuteRequest) (*plugin.ExecuteResponse, error) {
+func (p *ACRPlugin) Execute(ctx context.Context, req plugin.ExecuteRequest) (result *plugin.ExecuteResponse, runErr error) {
 	cfg := p.parseConfig(req.Config)
 	cfg.DryRun = cfg.DryRun || req.DryRun
 
-	// Process tag templates
-	tags := p.processTags(cfg.Tags, &req.Context)
+	// A push_skip dry run authenticates and tags locally but never pushes
+	simulateOnly := cfg.DryRun && cfg.DryRunMode != "push_skip"
+
+	if !cfg.Enabled {
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: "ACR plugin disabled by config",
+			Outputs: map[string]any{
+				"pushed_images": []string{},
+			},
+		}, nil
+	}
+
+	// Only push at the lifecycle hooks the config enables
+	if !slices.Contains(cfg.EnabledHooks, string(req.Hook)) {
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: fmt.Sprintf("ACR plugin not enabled for hook %s", req.Hook),
+			Outputs: map[string]any{
+				"pushed_images": []string{},
+			},
+		}, nil
+	}
+	if repository, ok := cfg.HookRepositories[string(req.Hook)]; ok {
+		cfg.Repository = repository
+	}
+
+	// Expand short Docker Hub forms so every later step sees one reference
+	normalizedSource := ""
+	if cfg.NormalizeSource {
+		normalized, err := normalizeSource(cfg.SourceImage, cfg.PullSource)
+		if err != nil {
+			return nil, fmt.Errorf("normalize_source: %w", err)
+		}
+		cfg.SourceImage, normalizedSource = normalized, normalized
+	}
+
+	// Mirror the source's repository path instead of repository and image
+	derivedPath := ""
+	if cfg.DerivePathFromSource {
+		derived, err := sourcePath(cfg.SourceImage, cfg.StripLibraryNamespace, cfg.PullSource)
+		if err != nil {
+			return nil, fmt.Errorf("derive_path_from_source: %w", err)
+		}
+		derivedPath = derived
+		cfg.Repository, cfg.Image = splitImagePath(derived)
+	}
+
+	if err := checkRegistryPolicy(cfg.Registry, cfg.EnvAllowedRegistries, cfg.AllowedRegistries); err != nil {
+		return nil, err
+	}
+
+	// Retag a source already in the target registry server-side
+	if cfg.applyAutoServerSide() {
+		fmt.Printf("Source %s is already in %s; tagging server-side\n", cfg.SourceImage, loginServer(cfg.Registry))
+	}
+
+	if err := cfg.loadCredentialFiles(); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Tags) == 0 && cfg.RequireTags {
+		return nil, fmt.Errorf("no tags configured and disable_default_tag is set; add tags or set require_tags: false")
+	}
+
+	// Bound the whole run, including auth and every push
+	if cfg.ExecuteTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.ExecuteTimeout)
+		defer cancel()
+	}
+
+	started := time.Now()
+	pushedImages := []string{}
+
+	// Report the run to the pushgateway whatever its outcome
+	if cfg.Metrics.URL != "" && !cfg.DryRun {
+		defer func() {
+			metrics := runMetrics{
+				Registry:     loginServer(cfg.Registry),
+				Repository:   ImageTarget{Namespace: cfg.Namespace, Repository: cfg.Repository, Image: cfg.Image}.WithPathMode(cfg.PathMode).Path(),
+				Success:      runErr == nil,
+				Duration:     time.Since(started),
+				PushedImages: len(pushedImages),
+			}
+			if result != nil {
+				metadata, _ := result.Outputs["tag_metadata"].([]TagMetadata)
+				metrics.PushedBytes = pushedBytes(metadata)
+			}
+			if err := pushMetrics(context.WithoutCancel(ctx), newHTTPClient(cfg, cfg.Metrics.Timeout), cfg.Metrics, metrics); err != nil {
+				warnf("%v", err)
+			}
+		}()
+	}
+	steps := &stepRecorder{}
+	wrapErr := func(err error) error {
+		if cfg.ExecuteTimeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("execute exceeded timeout of %s after pushing %d image(s) %v: %w",
+				cfg.ExecuteTimeout, len(pushedImages), pushedImages, err)
+		}
+		return err
+	}
+
+	// The docker CLI has no bandwidth control, so pushes are never throttled
+	if cfg.MaxUploadRate > 0 {
+		warnf("max_upload_rate is not supported by the docker CLI backend; pushes will not be throttled")
+	}
+
+	// Record external commands when requested
+	var transcript *Transcript
+	if cfg.TranscriptFile != "" {
+		t, err := OpenTranscript(cfg.TranscriptFile)
+		if err != nil {
+			return nil, err
+		}
+		defer t.Close()
+		t.Redact(cfg.ClientSecret, cfg.Password, cfg.Token, cfg.SourceRegistry.Password)
+		transcript = t
+	}
+
+	// Every external command shares the transcript and proxy settings
+	var runner CommandRunner = &ExecRunner{Transcript: transcript, Env: cfg.Proxy.env()}
+	if p.runner != nil {
+		runner = p.runner
+	}
+	if cfg.Proxy.enabled() {
+		fmt.Printf("Using proxy http=%s https=%s no_proxy=%s\n",
+			redactProxyURL(cfg.Proxy.HTTP), redactProxyURL(cfg.Proxy.HTTPS), cfg.Proxy.NoProxy)
+	}
 
 	// Create ACR client
 	client := NewACRClient(cfg.Registry)
+	client.SetPreserveCase(cfg.PreserveRegistryCase)
+	client.SetSourceCredentials(cfg.SourceRegistry)
+	client.SetRunner(runner)
+	client.SetSubscription(cfg.Subscription)
+	client.SetUserAgent(userAgent(cfg.UserAgentSuffix))
+
+	// Create Docker client
+	docker := NewDockerClient()
+	docker.SetRunner(runner)
+
+	// Fail fast on an unreachable daemon rather than deep into the push
+	if cfg.DockerPreflight && !simulateOnly && !cfg.Promote {
+		if err := docker.Ping(ctx); err != nil {
+			return nil, wrapErr(err)
+		}
+	}
+
+	// Keep az sessions out of the shared ~/.azure
+	if cfg.AzureConfigDir == "isolated" {
+		dir, err := os.MkdirTemp("", "relicta-acr-azure-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure config directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		client.SetAzureConfigDir(dir)
+	} else if cfg.AzureConfigDir != "" {
+		client.SetAzureConfigDir(cfg.AzureConfigDir)
+	}
 
 	// Authenticate with ACR
-	if !cfg.DryRun {
+	authDone := steps.begin("authenticating")
+	if simulateOnly {
+		authDone(stepSkipped)
+	} else {
 		authCfg := &AuthConfig{
 			Method:       cfg.AuthMethod,
 			ClientID:     cfg.ClientID,
@@ -122,60 +1008,566 @@ func (p *ACRPlugin) Execute(ctx context.Context, req plugin.ExecuteRequest) (*pl
 			TenantID:     cfg.TenantID,
 			Username:     cfg.Username,
 			Password:     cfg.Password,
+			Token:        cfg.Token,
+			VerifyHelper: cfg.VerifyCredentialHelper,
 		}
 		if err := client.Authenticate(ctx, authCfg); err != nil {
-			return nil, fmt.Errorf("failed to authenticate with ACR: %w", err)
+			if cfg.SuggestOnNotFound && isRegistryNotFound(err.Error()) {
+				if names, listErr := client.ListRegistries(ctx); listErr == nil {
+					if suggestions := suggestRegistries(cfg.Registry, names); len(suggestions) > 0 {
+						return nil, wrapErr(fmt.Errorf("registry %s not found (did you mean '%s'?): %w",
+							cfg.Registry, strings.Join(suggestions, "', '"), err))
+					}
+				}
+			}
+			return nil, wrapErr(fmt.Errorf("failed to authenticate with ACR: %w", err))
 		}
+		authDone(stepCompleted)
 	}
 
-	// Create Docker client
-	docker := NewDockerClient()
+	// Report the subscription the az session resolved the registry in
+	activeSubscription := ""
+	if !simulateOnly && usesAzSession(cfg.AuthMethod) {
+		id, err := client.ActiveSubscription(ctx)
+		if err != nil {
+			warnf("failed to determine active subscription: %v", err)
+		}
+		activeSubscription = id
+	}
+
+	// Record registry mutations as they happen for compliance
+	var audit *AuditLog
+	if cfg.AuditLog != "" && !cfg.DryRun {
+		actor := cfg.Username
+		if usesAzSession(cfg.AuthMethod) {
+			id, err := client.ActiveIdentity(ctx)
+			if err != nil {
+				warnf("failed to determine the audit actor: %v", err)
+			}
+			actor = id
+		}
+		if actor == "" {
+			actor = "unknown"
+		}
+		a, err := OpenAuditLog(cfg.AuditLog, actor)
+		if err != nil {
+			return nil, err
+		}
+		defer a.Close()
+		audit = a
+	}
+
+	// Gather registry metadata for compliance reporting
+	var registryInfo *RegistryInfo
+	if cfg.ReportRegistryInfo && !simulateOnly {
+		info, err := client.ShowRegistry(ctx)
+		if err != nil {
+			warnf("skipping registry info: %v", err)
+		}
+		registryInfo = info
+	}
+
+	// Route the source through the configured mirror or rewrites
+	cfg.SourceImage = rewriteSource(cfg.SourceImage, cfg.SourceRegistryMirror, cfg.SourceRewrite, cfg.PullSource)
+
+	// Promotion copies the source within ACR, so resolve it there
+	promoteSource, promoteRelative := "", ""
+	if cfg.Promote {
+		promoteSource, promoteRelative = promotionSource(cfg.SourceImage, client.GetRegistryURL())
+	}
+
+	// Load the source image streamed on stdin
+	if cfg.SourceStdin {
+		loadDone := steps.begin("loading " + cfg.SourceImage + " from stdin")
+		if simulateOnly {
+			fmt.Printf("[dry-run] Would load %s from stdin\n", cfg.SourceImage)
+			loadDone(stepSimulated)
+		} else {
+			stdin := p.stdin
+			if stdin == nil {
+				stdin = os.Stdin
+			}
+			loaded, err := docker.Load(ctx, stdin)
+			if err != nil {
+				return nil, wrapErr(err)
+			}
+			if !loadedImage(loaded, cfg.SourceImage) {
+				return nil, fmt.Errorf("image stream on stdin does not contain %s; it loaded %v", cfg.SourceImage, loaded)
+			}
+			loadDone(stepCompleted)
+		}
+	}
+
+	// Pull the source image
+	if cfg.PullSource {
+		pullDone := steps.begin("pulling " + cfg.SourceImage)
+		if simulateOnly {
+			fmt.Printf("[dry-run] Would pull %s\n", cfg.SourceImage)
+			pullDone(stepSimulated)
+		} else if err := docker.Pull(ctx, cfg.SourceImage); err != nil {
+			return nil, wrapErr(fmt.Errorf("failed to pull source image: %w", err))
+		} else {
+			pullDone(stepCompleted)
+		}
+	}
+
+	// Catch a source reference whose content is gone before tagging it
+	if !simulateOnly && !cfg.Promote {
+		if err := docker.CheckSource(ctx, cfg.SourceImage); err != nil {
+			return nil, wrapErr(err)
+		}
+	}
+
+	// Record the base image the source was built FROM
+	baseImage := BaseImage{}
+	if cfg.RecordBaseImage && !simulateOnly {
+		if cfg.Promote {
+			warnf("record_base_image reads local image labels; no base image is recorded for a promote")
+		} else {
+			labels, err := docker.ImageLabels(ctx, cfg.SourceImage)
+			if err != nil {
+				return nil, wrapErr(fmt.Errorf("failed to read source image labels: %w", err))
+			}
+			if baseImage = baseImageFromLabels(labels); baseImage.Name == "" {
+				warnf("source image %s has no %s label; base image not recorded", cfg.SourceImage, baseImageNameLabel)
+			}
+		}
+	}
+
+	// Capture the source digest before pushing, so a source swapped mid-run
+	// fails verify_integrity
+	integritySource := ""
+	integrityByManifest := cfg.Promote
+	if cfg.VerifyIntegrity && !cfg.DryRun {
+		var err error
+		if cfg.Promote {
+			integritySource, err = client.ManifestDigest(ctx, promoteRelative)
+		} else {
+			integritySource, err = docker.ImageDigest(ctx, cfg.SourceImage)
+			if err == nil {
+				// The containerd image store's image ID is the manifest or index
+				// digest, and an index has no config to compare
+				integrityByManifest, err = docker.UsesContainerdStore(ctx)
+			}
+			if err == nil && integrityByManifest && !usesAzSession(cfg.AuthMethod) {
+				err = fmt.Errorf("the containerd image store identifies images by manifest digest, "+
+					"which auth method '%s' cannot look up in the registry", cfg.AuthMethod)
+			}
+		}
+		if err != nil {
+			return nil, wrapErr(fmt.Errorf("verify_integrity: failed to resolve source digest: %w", err))
+		}
+	}
+
+	// Refuse to push an image built for the wrong platform
+	platforms := []string{}
+	if cfg.ExpectedPlatform != "" && !simulateOnly {
+		if cfg.Promote {
+			detected, err := docker.RemotePlatforms(ctx, promoteSource)
+			if err != nil {
+				return nil, wrapErr(fmt.Errorf("failed to determine source platform: %w", err))
+			}
+			platforms = detected
+		} else {
+			platform, err := docker.ImagePlatform(ctx, cfg.SourceImage)
+			if err != nil {
+				return nil, wrapErr(fmt.Errorf("failed to determine source platform: %w", err))
+			}
+			platforms = []string{platform}
+		}
+		if err := checkPlatform(cfg.SourceImage, cfg.ExpectedPlatform, platforms); err != nil {
+			return nil, wrapErr(err)
+		}
+	}
+
+	// Refuse to push an image larger than allowed
+	if cfg.MaxImageSize > 0 && !simulateOnly && !cfg.Promote {
+		size, err := docker.ImageSize(ctx, cfg.SourceImage)
+		if err != nil {
+			return nil, wrapErr(fmt.Errorf("failed to check source image size: %w", err))
+		}
+		if size > cfg.MaxImageSize {
+			msg := fmt.Sprintf("source image %s is %s, exceeding max_image_size of %s",
+				cfg.SourceImage, formatByteSize(size), formatByteSize(cfg.MaxImageSize))
+			if !cfg.AllowOversizeImage {
+				return nil, fmt.Errorf("%s; set allow_oversize_image: true to push it anyway", msg)
+			}
+			warnf("%s", msg)
+		}
+	}
+
+	// Resolve the source digest only when a tag needs it
+	data := newTemplateData(&req.Context)
+	for k, v := range cfg.TemplateVars {
+		data.Vars[k] = v
+	}
+	ci := resolveCIVars(cfg.CIVars)
+	data.BuildNumber, data.RunID, data.PipelineID = ci["build_number"], ci["run_id"], ci["pipeline_id"]
+	data.NextVersion = nextVersion(req.Context.Version, cfg.Bump)
+	if cfg.GitDescribe.Value != "" || cfg.GitDescribe.Command != "" {
+		describe, err := resolveGitDescribe(ctx, runner, cfg.GitDescribe)
+		if err != nil {
+			warnf("could not resolve git describe, dropping tags that reference it: %v", err)
+		}
+		data.GitDescribe = describe
+	}
+	if referencesSourceDigest(slices.Concat(cfg.Tags, cfg.ProvenanceInputs)) {
+		resolve := p.resolveDigest
+		source := cfg.SourceImage
+		if resolve == nil && cfg.Promote {
+			resolve = client.ManifestDigest
+			source = promoteRelative
+		} else if resolve == nil {
+			resolve = docker.SourceManifestDigest
+		}
+		digest, err := resolve(ctx, source)
+		if err != nil {
+			warnf("could not resolve source digest, dropping tags that reference it: %v", err)
+		} else {
+			data.SourceDigest = digestHex(digest)
+			data.ShortSourceDigest = shortDigest(digest)
+		}
+	}
+
+	if len(cfg.ProvenanceInputs) > 0 {
+		hash, err := p.provenanceHash(cfg.ProvenanceInputs, data)
+		if err != nil {
+			warnf("could not compute provenance hash, dropping tags that reference it: %v", err)
+		}
+		data.ProvenanceHash = hash
+	}
+
+	// Render templated namespace and repositories, e.g. dev/{{.Branch}}
+	for _, path := range []*string{&cfg.Namespace, &cfg.Repository} {
+		rendered, err := renderPath(*path, data)
+		if err != nil {
+			return nil, err
+		}
+		*path = rendered
+	}
+	additionalImages := make([]ImageTarget, len(cfg.AdditionalImages))
+	for i, target := range cfg.AdditionalImages {
+		rendered, err := renderPath(target.Repository, data)
+		if err != nil {
+			return nil, err
+		}
+		target.Repository = rendered
+		additionalImages[i] = target
+	}
+
+	// Derive the next build counter from the tags of the primary repository;
+	// concurrent runs can pick the same number
+	sequence := 0
+	if referencesField(cfg.Tags, ".NextSequence") {
+		primaryPath := ImageTarget{Namespace: cfg.Namespace, Repository: cfg.Repository, Image: cfg.Image}.WithPathMode(cfg.PathMode).Path()
+		next, err := resolveNextSequence(ctx, client.ListTags, primaryPath, cfg.SequencePattern)
+		if err != nil {
+			warnf("could not resolve the next sequence, dropping tags that reference it: %v", err)
+		} else {
+			sequence = next
+			data.NextSequence = strconv.Itoa(next)
+		}
+	}
+
+	// Process tag templates
+	resolvedTags, duplicates := dedupeTags(p.processTags(cfg.Tags, data))
+	if len(duplicates) > 0 {
+		warnf("several tag templates resolved to %s; each is pushed once", strings.Join(duplicates, ", "))
+	}
+	resolvedTags, droppedLatest := applyLatestPolicy(resolvedTags, cfg.LatestPolicy, data.IsPrerelease)
+	if droppedLatest {
+		fmt.Printf("Skipping tag latest for %s (latest_policy: %s)\n", req.Context.Version, cfg.LatestPolicy)
+	}
+	tags := resolvedTags
+	if cfg.TagsLimit > 0 && len(tags) > cfg.TagsLimit {
+		tags = tags[:cfg.TagsLimit]
+	}
+	floatingTags := []string{}
+	for _, tag := range tags {
+		if isFloatingTag(tag, cfg.MutableTags) {
+			floatingTags = append(floatingTags, tag)
+		}
+	}
+	if cfg.RequireImmutableTag {
+		if err := checkImmutableTag(tags, cfg.MutableTags); err != nil {
+			return nil, err
+		}
+	}
 
 	// Push images
-	pushedImages := []string{}
 	registryURL := client.GetRegistryURL()
+	targets := []ImageTarget{ImageTarget{Namespace: cfg.Namespace, Repository: cfg.Repository, Image: cfg.Image}.WithPathMode(cfg.PathMode)}
+	for _, target := range additionalImages {
+		target.Namespace = cfg.Namespace
+		targets = append(targets, target.WithPathMode(cfg.PathMode))
+	}
+	for _, target := range targets {
+		if !namespacePattern.MatchString(target.Path()) {
+			return nil, fmt.Errorf("image path %q is not a valid ACR repository name", target.Path())
+		}
+		if err := checkBranchPolicy(cfg.BranchPolicy, target.Path(), req.Context.Branch, req.Context.TagName); err != nil {
+			return nil, err
+		}
+	}
 
-	for _, tag := range tags {
-		if tag == "" {
-			continue
+	// Serialize concurrent runs against the same repositories
+	if cfg.Lock.Enabled && !simulateOnly {
+		keys := make([]string, 0, len(targets))
+		for _, target := range targets {
+			keys = append(keys, registryURL+"/"+target.Path())
+		}
+		lockCtx, cancel := context.WithTimeout(ctx, cfg.Lock.Timeout)
+		locks, err := AcquireFileLocks(lockCtx, cfg.Lock.Dir, keys)
+		cancel()
+		if err != nil {
+			return nil, wrapErr(fmt.Errorf("failed to acquire push lock: %w", err))
+		}
+		defer func() {
+			if err := ReleaseFileLocks(locks); err != nil {
+				warnf("%v", err)
+			}
+		}()
+	}
+	// Run the pre-push command; a failure aborts before anything is pushed
+	hookSecrets := []string{cfg.ClientSecret, cfg.Password, cfg.Token, cfg.SourceRegistry.Password}
+	if cfg.PushHooks.Pre != "" {
+		env := []string{"ACR_REGISTRY=" + registryURL, "ACR_TAGS=" + strings.Join(tags, ",")}
+		if cfg.DryRun {
+			fmt.Printf("[dry-run] Would run pre_push_command: %s\n", cfg.PushHooks.Pre)
+		} else if err := runPushHook(ctx, runner, "pre_push_command", cfg.PushHooks.Pre, data, env, cfg.PushHooks.Timeout, hookSecrets); err != nil {
+			return nil, wrapErr(err)
+		}
+	}
+
+	// Fan the targets × tags matrix out over a bounded worker pool
+	run := newPushRun(cfg, targets, tags)
+	run.runner, run.client, run.docker = runner, client, docker
+	run.steps, run.audit, run.registryURL = steps, audit, registryURL
+	run.simulateOnly, run.promoteSource = simulateOnly, promoteSource
+	run.integritySource, run.integrityByManifest = integritySource, integrityByManifest
+	units := run.units
+
+	// With efficient_tagging the first tag of each image is pushed and the
+	// rest are copied server-side from its digest once that push is done
+	phases := [][]pushUnit{units}
+	if cfg.EfficientTagging && !cfg.Promote {
+		first, rest := splitFirstTags(units)
+		phases = [][]pushUnit{first, rest}
+		for _, unit := range first {
+			run.pushedFirst[unit.Index] = true
+		}
+	}
+	var pushErr error
+	for _, phase := range phases {
+		if pushErr = runPushUnits(ctx, phase, cfg.MaxParallel, run.push); pushErr != nil {
+			break
+		}
+	}
+	pushedImages = run.pushedImages
+	if err := pushErr; err != nil {
+		refs := make([]string, len(units))
+		for i, unit := range units {
+			refs[i] = fmt.Sprintf("%s/%s:%s", registryURL, unit.Target.Path(), unit.Tag)
+		}
+		// Report how far a cancelled or capped run got instead of the raw exec failure
+		if ctx.Err() != nil {
+			err = newCancelledError(ctx.Err(), refs, pushedImages)
+		} else if errors.Is(err, errPushCapReached) {
+			sortByMatrix(pushedImages, matrixOrder(registryURL, units), func(ref string) string { return ref })
+			err = newPushCapError(cfg.MaxPushes, refs, pushedImages)
+		}
+		return nil, wrapErr(err)
+	}
+
+	// Parallel pushes record results in completion order; report them in
+	// matrix order, which follows the configured tag order
+	order := matrixOrder(registryURL, units)
+	identity := func(ref string) string { return ref }
+	for _, refs := range [][]string{pushedImages, run.importedImages, run.newTags, run.overwrittenTags} {
+		sortByMatrix(refs, order, identity)
+	}
+	for _, refs := range run.pushedByImage {
+		sortByMatrix(refs, order, identity)
+	}
+	sortByMatrix(run.references, order, func(ref ImageReference) string { return ref.TagRef })
+
+	// The first tag of the primary image is what downstream plugins deploy
+	primaryDigest, primaryReference := "", ""
+	if len(units) > 0 {
+		primaryReference = fmt.Sprintf("%s/%s:%s", registryURL, units[0].Target.Path(), units[0].Tag)
+		if digest := run.digests[primaryReference]; digest != "" {
+			primaryDigest = digest
+			primaryReference = fmt.Sprintf("%s/%s@%s", registryURL, units[0].Target.Path(), digest)
+		}
+	}
+
+	// Gather one metadata record per pushed tag, in push matrix order
+	tagMetadata := []TagMetadata{}
+	if cfg.TagMetadata && !cfg.DryRun {
+		// An image manifest does not name its platform; a local source does
+		sourcePlatforms := platforms
+		if len(sourcePlatforms) == 0 && !cfg.Promote {
+			if platform, err := docker.ImagePlatform(ctx, cfg.SourceImage); err == nil && platform != "" {
+				sourcePlatforms = []string{platform}
+			}
+		}
+		for _, unit := range units {
+			ref := fmt.Sprintf("%s/%s:%s", registryURL, unit.Target.Path(), unit.Tag)
+			meta := TagMetadata{
+				Tag:            unit.Tag,
+				Reference:      ref,
+				Digest:         run.digests[ref],
+				Platforms:      sourcePlatforms,
+				PushDurationMS: run.pushDurations[ref].Milliseconds(),
+			}
+			manifest, err := docker.InspectManifest(ctx, ref)
+			if err == nil {
+				err = parseManifestMetadata(manifest, &meta)
+			}
+			if err != nil {
+				warnf("incomplete metadata for %s: %v", ref, err)
+			}
+			tagMetadata = append(tagMetadata, meta)
 		}
+	}
 
-		// Build image path
-		imagePath := cfg.Image
-		if cfg.Repository != "" {
-			imagePath = fmt.Sprintf("%s/%s", cfg.Repository, cfg.Image)
+	// Run the post-push command with the pushed references in its environment
+	if cfg.PushHooks.Post != "" {
+		env := pushHookEnv(registryURL, tags, pushedImages, run.digests)
+		if cfg.DryRun {
+			fmt.Printf("[dry-run] Would run post_push_command: %s\n", cfg.PushHooks.Post)
+		} else if err := runPushHook(ctx, runner, "post_push_command", cfg.PushHooks.Post, data, env, cfg.PushHooks.Timeout, hookSecrets); err != nil {
+			if cfg.PushHooks.Required {
+				return nil, wrapErr(err)
+			}
+			warnf("%v", err)
 		}
+	}
 
-		targetImage := fmt.Sprintf("%s/%s:%s", registryURL, imagePath, tag)
+	// Attach artifacts to each pushed image
+	attached, err := run.attach(ctx, data, &req.Context, baseImage, started)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
 
+	// Hand the digests to an out-of-band signing step
+	if cfg.SigningManifestFile != "" {
 		if cfg.DryRun {
-			fmt.Printf("[dry-run] Would tag %s as %s\n", cfg.SourceImage, targetImage)
-			fmt.Printf("[dry-run] Would push %s\n", targetImage)
+			fmt.Printf("[dry-run] Would write signing manifest %s\n", cfg.SigningManifestFile)
 		} else {
-			// Tag the image
-			if err := docker.Tag(ctx, cfg.SourceImage, targetImage); err != nil {
-				return nil, fmt.Errorf("failed to tag image: %w", err)
+			manifest := newSigningManifest(registryURL, req.Context.Version, run.references)
+			if err := writeSigningManifest(cfg.SigningManifestFile, manifest); err != nil {
+				return nil, wrapErr(err)
 			}
+		}
+	}
 
-			// Push the image
-			if err := docker.Push(ctx, targetImage); err != nil {
-				return nil, fmt.Errorf("failed to push image: %w", err)
+	// Check the registry's public visibility matches the intent
+	anonymousPull := ""
+	if cfg.VerifyAnonymousPull != "" {
+		if cfg.DryRun {
+			fmt.Printf("[dry-run] Would verify anonymous pull is %s\n", cfg.VerifyAnonymousPull)
+		} else {
+			state, err := reconcileAnonymousPull(ctx, client, cfg.VerifyAnonymousPull, cfg.SetAnonymousPull)
+			if err != nil && !cfg.AnonymousPullWarnOnly {
+				return nil, wrapErr(err)
+			} else if err != nil {
+				warnf("%v", err)
 			}
+			anonymousPull = state
+		}
+	}
 
-			fmt.Printf("Pushed: %s\n", targetImage)
+	// Retire the version that fell out of the retention window
+	retired := run.retire(ctx, req.Context.PreviousVersion)
+
+	// Let the registry purge untagged manifests
+	run.applyUntaggedRetention(ctx, registryInfo)
+
+	// Notify downstream systems
+	if cfg.Notify.URL != "" && !cfg.DryRun {
+		notifyDone := steps.begin("notifying")
+		body, err := renderNotifyBody(cfg.Notify, &notifyTemplateData{
+			templateData: data,
+			Registry:     registryURL,
+			PushedImages: pushedImages,
+			Digests:      run.digests,
+		})
+		if err == nil {
+			err = sendNotification(ctx, newHTTPClient(cfg, cfg.Notify.Timeout), cfg.Notify, body)
+		}
+		if err != nil {
+			if cfg.Notify.Required {
+				return nil, wrapErr(err)
+			}
+			warnf("%v", err)
+			notifyDone(stepFailed)
+		} else {
+			notifyDone(stepCompleted)
 		}
+	} else if cfg.Notify.URL != "" {
+		fmt.Printf("[dry-run] Would notify %s\n", cfg.Notify.URL)
+		steps.begin("notifying")(stepSimulated)
+	}
+	steps.begin("done")(stepCompleted)
 
-		pushedImages = append(pushedImages, targetImage)
+	message, err := renderSuccessMessage(cfg.SuccessMessage, &messageTemplateData{
+		templateData: data,
+		Count:        len(pushedImages),
+		Registry:     registryURL,
+		Tags:         tags,
+		PushedImages: pushedImages,
+	})
+	if err != nil {
+		warnf("%v; using the default message", err)
+		message = fmt.Sprintf("Successfully pushed %d image(s) to ACR", len(pushedImages))
 	}
 
 	return &plugin.ExecuteResponse{
 		Success: true,
-		Message: fmt.Sprintf("Successfully pushed %d image(s) to ACR", len(pushedImages)),
+		Message: message,
 		Outputs: map[string]any{
-			"registry":      registryURL,
-			"repository":    cfg.Repository,
-			"tags":          tags,
-			"pushed_images": pushedImages,
+			"registry":              registryURL,
+			"source_image":          cfg.SourceImage,
+			"platforms":             platforms,
+			"repository":            cfg.Repository,
+			"image_path":            targets[0].Path(),
+			"derived_path":          derivedPath,
+			"normalized_source":     normalizedSource,
+			"tags":                  tags,
+			"resolved_tags":         resolvedTags,
+			"floating_tags":         floatingTags,
+			"pushed_images":         pushedImages,
+			"pushed_by_image":       run.pushedByImage,
+			"digests":               run.digests,
+			"references":            run.references,
+			"promoted_digest":       run.promotedDigest,
+			"imported_images":       run.importedImages,
+			"server_side":           cfg.Promote,
+			"acr_primary_digest":    primaryDigest,
+			"acr_primary_reference": primaryReference,
+			"tag_metadata":          tagMetadata,
+			"manifest_types":        manifestTypes(tagMetadata),
+			"sequence":              sequence,
+			"source_digest":         integritySource,
+			"verified_digests":      run.integrityDigests,
+			"new_tags":              run.newTags,
+			"overwritten_tags":      run.overwrittenTags,
+			"untagged_tags":         retired.Untagged,
+			"deleted_tags":          retired.Untagged,
+			"protected_tags":        retired.Protected,
+			"reclaimable_bytes":     retired.ReclaimableBytes,
+			"subscription":          activeSubscription,
+			"registry_info":         registryInfo,
+			"anonymous_pull":        anonymousPull,
+			"sbom_digests":          attached.SBOM,
+			"release_notes_digests": attached.ReleaseNotes,
+			"base_image":            baseImage,
+			"base_image_artifacts":  attached.BaseImage,
+			"oci_layouts":           attached.Layouts,
+			"provenance_digests":    attached.Provenance,
+			"upload_rate":           0,
+			"steps":                 steps.Steps(),
 		},
 	}, nil
 }
@@ -184,10 +1576,16 @@ func (p *ACRPlugin) Execute(ctx context.Context, req plugin.ExecuteRequest) (*pl
 func (p *ACRPlugin) parseConfig(raw map[string]any) *Config {
 	parser := helpers.NewConfigParser(raw)
 
+	// Tags may be a list or a comma-separated string
 	tags := parser.GetStringSlice("tags", nil)
-	if len(tags) == 0 {
+	if list, ok := raw["tags"].(string); ok {
+		tags = splitList(list)
+	}
+	disableDefaultTag := parser.GetBool("disable_default_tag", false)
+	if len(tags) == 0 && !disableDefaultTag {
 		tags = []string{"{{.Version}}"}
 	}
+	floatingTags := parser.GetStringSlice("floating_tags", []string{"latest"})
 
 	// Parse nested auth config
 	authMethod := "azure_cli"
@@ -196,6 +1594,13 @@ func (p *ACRPlugin) parseConfig(raw map[string]any) *Config {
 	tenantID := ""
 	username := ""
 	password := ""
+	token := ""
+	clientSecretFile := ""
+	passwordFile := ""
+	tokenFile := ""
+	acknowledgeAdminAuth := false
+	failOnEmptyCredentials := false
+	verifyCredentialHelper := false
 	if authRaw, ok := raw["auth"].(map[string]any); ok {
 		authParser := helpers.NewConfigParser(authRaw)
 		authMethod = authParser.GetString("method", "", "azure_cli")
@@ -204,14 +1609,247 @@ func (p *ACRPlugin) parseConfig(raw map[string]any) *Config {
 		tenantID = authParser.GetString("tenant_id", "AZURE_TENANT_ID", "")
 		username = authParser.GetString("username", "ACR_USERNAME", "")
 		password = authParser.GetString("password", "ACR_PASSWORD", "")
+		token = authParser.GetString("token", "ACR_ACCESS_TOKEN", "")
+		clientSecretFile = authParser.GetString("client_secret_file", "", "")
+		passwordFile = authParser.GetString("password_file", "", "")
+		tokenFile = authParser.GetString("token_file", "", "")
+		acknowledgeAdminAuth = authParser.GetBool("acknowledge_admin_auth", false)
+		failOnEmptyCredentials = authParser.GetBool("fail_on_empty", false)
+		verifyCredentialHelper = authParser.GetBool("verify_helper", false)
+	}
+
+	// Parse source registry credentials; a service principal logs in with its
+	// client ID and secret as username and password
+	var sourceRegistry SourceCredentials
+	if sourceRegistryRaw := parser.GetMap("source_registry"); sourceRegistryRaw != nil {
+		sourceRegistryParser := helpers.NewConfigParser(sourceRegistryRaw)
+		sourceRegistry.Username = sourceRegistryParser.GetString("username", "ACR_SOURCE_USERNAME", "")
+		sourceRegistry.Password = sourceRegistryParser.GetString("password", "ACR_SOURCE_PASSWORD", "")
+		if sourceRegistry.Username == "" {
+			sourceRegistry.Username = sourceRegistryParser.GetString("client_id", "", "")
+			sourceRegistry.Password = sourceRegistryParser.GetString("client_secret", "", "")
+		}
+	}
+
+	// Parse user template variables; a map value reads from the named env var
+	templateVars := map[string]string{}
+	for name, value := range parser.GetMap("template_vars") {
+		switch v := value.(type) {
+		case string:
+			templateVars[name] = v
+		case map[string]any:
+			if env, ok := v["env"].(string); ok {
+				templateVars[name] = os.Getenv(env)
+			}
+		default:
+			templateVars[name] = fmt.Sprint(v)
+		}
+	}
+
+	// Parse notify config
+	notify := NotifyConfig{Method: http.MethodPost, Timeout: 10 * time.Second}
+	if notifyRaw := parser.GetMap("notify"); notifyRaw != nil {
+		notifyParser := helpers.NewConfigParser(notifyRaw)
+		notify.URL = notifyParser.GetString("url", "", "")
+		notify.Method = strings.ToUpper(notifyParser.GetString("method", "", http.MethodPost))
+		notify.Body = notifyParser.GetString("body", "", "")
+		notify.Secret = notifyParser.GetString("secret", "", "")
+		notify.Required = notifyParser.GetBool("required", false)
+		if d := parseDuration(notifyParser.GetString("timeout", "", "")); d > 0 {
+			notify.Timeout = d
+		}
+		for name, value := range notifyParser.GetMap("headers") {
+			if notify.Headers == nil {
+				notify.Headers = map[string]string{}
+			}
+			notify.Headers[name] = fmt.Sprint(value)
+		}
+	}
+
+	// Parse CI variable overrides; a value is one env var name or a list of them
+	ciVars := map[string][]string{}
+	ciRaw := parser.GetMap("ci_vars")
+	ciParser := helpers.NewConfigParser(ciRaw)
+	for key, value := range ciRaw {
+		if name, ok := value.(string); ok {
+			ciVars[key] = []string{name}
+		} else {
+			ciVars[key] = ciParser.GetStringSlice(key, nil)
+		}
+	}
+
+	// Parse proxy config
+	proxy := ProxyConfig{}
+	if proxyRaw := parser.GetMap("proxy"); proxyRaw != nil {
+		proxyParser := helpers.NewConfigParser(proxyRaw)
+		proxy.HTTP = proxyParser.GetString("http", "", "")
+		proxy.HTTPS = proxyParser.GetString("https", "", "")
+		proxy.NoProxy = proxyParser.GetString("no_proxy", "", "")
+	}
+
+	// Parse TLS config
+	tlsConfig := TLSConfig{}
+	if tlsRaw := parser.GetMap("tls"); tlsRaw != nil {
+		tlsParser := helpers.NewConfigParser(tlsRaw)
+		tlsConfig.MinVersion = tlsParser.GetString("min_version", "", "")
+	}
+
+	// Parse retention label protection
+	protectLabels := map[string]string{}
+	for key, value := range parser.GetMap("protect_labels") {
+		protectLabels[key] = fmt.Sprint(value)
+	}
+
+	// Parse HTTP transport config
+	httpTransport := HTTPTransportConfig{
+		MaxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     defaultIdleConnTimeout,
+	}
+	if httpTransportRaw := parser.GetMap("http_transport"); httpTransportRaw != nil {
+		httpTransportParser := helpers.NewConfigParser(httpTransportRaw)
+		httpTransport.MaxIdleConnsPerHost = httpTransportParser.GetInt("max_idle_conns_per_host", defaultMaxIdleConnsPerHost)
+		if d := parseDuration(httpTransportParser.GetString("idle_conn_timeout", "", "")); d > 0 {
+			httpTransport.IdleConnTimeout = d
+		}
+		httpTransport.DisableKeepAlives = !httpTransportParser.GetBool("keep_alive", true)
+	}
+
+	// Parse push hooks
+	enabledHooks := defaultEnabledHooks
+	hookRepositories := map[string]string{}
+	pushHooks := PushHooksConfig{
+		Pre:     parser.GetString("pre_push_command", "", ""),
+		Post:    parser.GetString("post_push_command", "", ""),
+		Timeout: defaultHookTimeout,
+	}
+	if hooksRaw := parser.GetMap("hooks"); hooksRaw != nil {
+		hooksParser := helpers.NewConfigParser(hooksRaw)
+		pushHooks.Required = hooksParser.GetBool("required", false)
+		enabledHooks = hooksParser.GetStringSlice("enabled", defaultEnabledHooks)
+		for hook, repository := range hooksParser.GetMap("repositories") {
+			hookRepositories[hook] = fmt.Sprint(repository)
+		}
+		if d := parseDuration(hooksParser.GetString("timeout", "", "")); d > 0 {
+			pushHooks.Timeout = d
+		}
+	}
+
+	// Parse metrics pushgateway config
+	metrics := MetricsConfig{Job: defaultMetricsJob, Labels: map[string]string{}, Timeout: 10 * time.Second}
+	if metricsPushgatewayRaw := parser.GetMap("metrics_pushgateway"); metricsPushgatewayRaw != nil {
+		metricsPushgatewayParser := helpers.NewConfigParser(metricsPushgatewayRaw)
+		metrics.URL = metricsPushgatewayParser.GetString("url", "", "")
+		metrics.Job = metricsPushgatewayParser.GetString("job", "", defaultMetricsJob)
+		if d := parseDuration(metricsPushgatewayParser.GetString("timeout", "", "")); d > 0 {
+			metrics.Timeout = d
+		}
+		for name, value := range metricsPushgatewayParser.GetMap("labels") {
+			metrics.Labels[name] = fmt.Sprint(value)
+		}
+	}
+
+	// Parse lock config
+	lock := LockConfig{
+		Dir:     filepath.Join(os.TempDir(), "relicta-acr-locks"),
+		Timeout: 10 * time.Minute,
+	}
+	if lockRaw := parser.GetMap("lock"); lockRaw != nil {
+		lockParser := helpers.NewConfigParser(lockRaw)
+		lock.Enabled = lockParser.GetBool("enabled", true)
+		lock.Dir = lockParser.GetString("dir", "", lock.Dir)
+		if d := parseDuration(lockParser.GetString("timeout", "", "")); d > 0 {
+			lock.Timeout = d
+		}
+	}
+
+	// Parse git describe config
+	gitDescribe := GitDescribeConfig{}
+	if gitDescribeRaw := parser.GetMap("git_describe"); gitDescribeRaw != nil {
+		gitDescribeParser := helpers.NewConfigParser(gitDescribeRaw)
+		gitDescribe.Value = gitDescribeParser.GetString("value", "GIT_DESCRIBE", "")
+		gitDescribe.Command = gitDescribeParser.GetString("command", "", "")
+		if gitDescribe.Value == "" && gitDescribe.Command == "" {
+			gitDescribe.Command = defaultGitDescribeCommand
+		}
+	}
+
+	// Parse provenance config
+	provenance := ProvenanceConfig{}
+	if provenanceRaw := parser.GetMap("provenance"); provenanceRaw != nil {
+		provenanceParser := helpers.NewConfigParser(provenanceRaw)
+		provenance.Enabled = provenanceParser.GetBool("enabled", true)
+		provenance.BuilderID = provenanceParser.GetString("builder_id", "", "")
+		provenance.BuildType = provenanceParser.GetString("build_type", "", defaultProvenanceBuildType)
+		provenance.Key = provenanceParser.GetString("key", "COSIGN_KEY", "")
+	}
+
+	// Parse release notes config
+	releaseNotes := ReleaseNotesConfig{}
+	if releaseNotesRaw := parser.GetMap("release_notes"); releaseNotesRaw != nil {
+		releaseNotesParser := helpers.NewConfigParser(releaseNotesRaw)
+		releaseNotes.Enabled = releaseNotesParser.GetBool("enabled", true)
+		releaseNotes.Text = releaseNotesParser.GetString("text", "", "")
+		releaseNotes.File = releaseNotesParser.GetString("file", "", "")
+		releaseNotes.MediaType = releaseNotesParser.GetString("media_type", "", "text/markdown")
+	}
+
+	// Parse SBOM config
+	sbom := SBOMConfig{}
+	if sbomRaw := parser.GetMap("sbom"); sbomRaw != nil {
+		sbomParser := helpers.NewConfigParser(sbomRaw)
+		sbom.File = sbomParser.GetString("file", "", "")
+		sbom.MediaType = sbomParser.GetString("media_type", "", "application/vnd.cyclonedx+json")
+	}
+
+	// Parse the image size guard; Validate reports unparsable values
+	var maxImageSize int64
+	if rawSize := parser.GetString("max_image_size", "", ""); rawSize != "" {
+		maxImageSize, _ = parseByteSize(rawSize)
+	}
+
+	// Parse push verification timeout
+	verifyTimeout := 30 * time.Second
+	if d := parseDuration(parser.GetString("verify_timeout", "", "")); d > 0 {
+		verifyTimeout = d
+	}
+
+	// Parse source rewrite prefixes
+	sourceRewrite := map[string]string{}
+	for prefix, replacement := range parser.GetMap("source_rewrite") {
+		sourceRewrite[prefix] = fmt.Sprint(replacement)
+	}
+
+	// Parse additional image names
+	var additionalImages []ImageTarget
+	if list, ok := raw["additional_images"].([]any); ok {
+		for _, item := range list {
+			entry, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			entryParser := helpers.NewConfigParser(entry)
+			additionalImages = append(additionalImages, ImageTarget{
+				Repository: entryParser.GetString("repository", "", ""),
+				Image:      entryParser.GetString("image", "", ""),
+			})
+		}
 	}
 
 	return &Config{
 		// ACR Configuration
 		Registry:   parser.GetString("registry", "", ""),
+		Namespace:  parser.GetString("namespace", "", ""),
 		Repository: parser.GetString("repository", "", ""),
 		Image:      parser.GetString("image", "", ""),
 
+		PathMode:              parser.GetString("path_mode", "", "compose"),
+		DerivePathFromSource:  parser.GetBool("derive_path_from_source", false),
+		StripLibraryNamespace: parser.GetBool("strip_library_namespace", false),
+		NormalizeSource:       parser.GetBool("normalize_source", false),
+
+		AllowedRegistries:    parser.GetStringSlice("allowed_registries", nil),
+		EnvAllowedRegistries: splitList(os.Getenv("ACR_ALLOWED_REGISTRIES")),
+
 		// Authentication
 		AuthMethod:   authMethod,
 		ClientID:     clientID,
@@ -219,52 +1857,184 @@ func (p *ACRPlugin) parseConfig(raw map[string]any) *Config {
 		TenantID:     tenantID,
 		Username:     username,
 		Password:     password,
+		Token:        token,
+
+		ClientSecretFile: clientSecretFile,
+		PasswordFile:     passwordFile,
+		TokenFile:        tokenFile,
+
+		Subscription: parser.GetString("subscription", "AZURE_SUBSCRIPTION_ID", ""),
+
+		PreserveRegistryCase: parser.GetBool("preserve_registry_case", false),
+		Notify:               notify,
+		Metrics:              metrics,
+		ReportRegistryInfo:   parser.GetBool("report_registry_info", false),
+		UserAgentSuffix:      parser.GetString("user_agent_suffix", "", ""),
+		SuggestOnNotFound:    parser.GetBool("suggest_on_not_found", false),
+		Proxy:                proxy,
+		TLS:                  tlsConfig,
+		HTTPTransport:        httpTransport,
+		Lock:                 lock,
+		TranscriptFile:       parser.GetString("transcript_file", "", ""),
+		AuditLog:             parser.GetString("audit_log", "", ""),
+		AzureConfigDir:       parser.GetString("azure_config_dir", "", ""),
+
+		VerifyAnonymousPull:   strings.ToLower(parser.GetString("verify_anonymous_pull", "", "")),
+		SetAnonymousPull:      parser.GetBool("set_anonymous_pull", false),
+		AnonymousPullWarnOnly: parser.GetBool("anonymous_pull_warn_only", false),
+
+		AcknowledgeAdminAuth:   acknowledgeAdminAuth,
+		FailOnEmptyCredentials: failOnEmptyCredentials,
+		VerifyCredentialHelper: verifyCredentialHelper,
+
+		// Additional image names
+		AdditionalImages: additionalImages,
 
 		// Source image
-		SourceImage: parser.GetString("source_image", "", ""),
+		SourceImage:    parser.GetString("source_image", "", ""),
+		PullSource:     parser.GetBool("pull_source", false),
+		SourceStdin:    parser.GetBool("source_stdin", false),
+		Promote:        parser.GetBool("promote", false),
+		AutoServerSide: parser.GetBool("auto_server_side", false),
+		SourceRegistry: sourceRegistry,
+
+		SourceRegistryMirror: parser.GetString("source_registry_mirror", "", ""),
+		SourceRewrite:        sourceRewrite,
+
+		// SBOM
+		SBOM: sbom,
+
+		// Release notes
+		ReleaseNotes: releaseNotes,
+
+		RecordBaseImage: parser.GetBool("record_base_image", false),
+		AttachBaseImage: parser.GetBool("attach_base_image", false),
+
+		// Provenance attestation
+		Provenance: provenance,
+
+		ArchiveOCILayout: parser.GetString("archive_oci_layout", "", ""),
+
+		SigningManifestFile: parser.GetString("signing_manifest_file", "", ""),
 
 		// Tags
-		Tags: tags,
+		Tags:         tags,
+		FloatingTags: floatingTags,
+
+		Bump:              parser.GetString("bump", "", "patch"),
+		SequencePattern:   parser.GetString("sequence_pattern", "", defaultSequencePattern),
+		LatestPolicy:      parser.GetString("latest_policy", "", "always"),
+		ProvenanceInputs:  parser.GetStringSlice("provenance_inputs", nil),
+		GitDescribe:       gitDescribe,
+		SuccessMessage:    parser.GetString("success_message", "", defaultSuccessMessage),
+		DisableDefaultTag: disableDefaultTag,
+		RequireTags:       parser.GetBool("require_tags", true),
+
+		BranchPolicy:        parseBranchPolicy(parser.GetMap("branch_policy")),
+		RequireImmutableTag: parser.GetBool("require_immutable_tag", false),
+		MutableTags:         mutableTags(parser.GetStringSlice("mutable_tags", defaultMutableTags), floatingTags),
+
+		TemplateVars: templateVars,
+		CIVars:       ciVars,
+		TagsLimit:    parser.GetInt("tags_limit", 0),
+
+		ExpectedPlatform: parser.GetString("expected_platform", "", ""),
+
+		// Size guard
+		MaxImageSize:       maxImageSize,
+		AllowOversizeImage: parser.GetBool("allow_oversize_image", false),
+
+		CleanupLocalTags: parser.GetBool("cleanup_local_tags", false),
+		IsolateLocalTags: parser.GetBool("isolate_local_tags", false),
+		PushHooks:        pushHooks,
+		EnabledHooks:     enabledHooks,
+		HookRepositories: hookRepositories,
+		DockerPreflight:  parser.GetBool("docker_preflight", true),
+
+		// Push verification
+		VerifyAfterPush: parser.GetBool("verify_after_push", false),
+		VerifyTimeout:   verifyTimeout,
+		VerifyIntegrity: parser.GetBool("verify_integrity", false),
+
+		DeletePreviousBut: parser.GetInt("delete_previous_but", 0),
+		ProtectLabels:     protectLabels,
+		UntaggedRetention: parser.GetInt("untagged_retention", 0),
+
+		// Overwrite protection
+		NoOverwrite: parser.GetBool("no_overwrite", false),
+		Force:       parser.GetBool("force", false),
+
+		ReportTagNovelty: parser.GetBool("report_tag_novelty", false),
+		TagMetadata:      parser.GetBool("tag_metadata", false),
 
 		// Behavior
-		DryRun: parser.GetBool("dry_run", false),
+		Enabled:        parser.GetBool("enabled", true),
+		DryRun:         parser.GetBool("dry_run", false),
+		DryRunMode:     parser.GetString("dry_run_mode", "", "full"),
+		MaxUploadRate:  parser.GetInt("max_upload_rate", 0),
+		ExecuteTimeout: parseDuration(parser.GetString("execute_timeout", "", "")),
+		MaxParallel:    parser.GetInt("max_parallel", 1),
+		MaxPushes:      parser.GetInt("max_pushes", 0),
+
+		EfficientTagging: parser.GetBool("efficient_tagging", false),
 	}
 }
 
-// processTags processes tag templates with release context.
-func (p *ACRPlugin) processTags(tags []string, ctx *plugin.ReleaseContext) []string {
-	processed := make([]string, 0, len(tags))
-
-	for _, tag := range tags {
-		result := p.processTemplate(tag, ctx)
-		if result != "" {
-			processed = append(processed, result)
+// splitList splits a comma-separated list, trimming whitespace and skipping
+// empty entries.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
+	return items
+}
 
-	return processed
+// parseDuration parses a duration string, returning zero when empty or invalid.
+func parseDuration(raw string) time.Duration {
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0
+	}
+	return d
 }
 
-// processTemplate replaces template variables with actual values.
-func (p *ACRPlugin) processTemplate(tmpl string, ctx *plugin.ReleaseContext) string {
-	result := tmpl
+// namespacePattern matches one or more repository path components.
+var namespacePattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+
+// subscriptionIDPattern matches an Azure subscription ID.
+var subscriptionIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
 
-	// Handle conditional templates (simplified)
-	if strings.Contains(result, "{{if") {
-		return ""
+// isValidSubscription reports whether s is a subscription ID or a plausible
+// subscription name (at most 64 printable characters without surrounding spaces).
+func isValidSubscription(s string) bool {
+	if subscriptionIDPattern.MatchString(s) {
+		return true
 	}
+	if len(s) > 64 || strings.TrimSpace(s) != s {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
 
-	// Replace common template variables
-	result = strings.ReplaceAll(result, "{{.Version}}", ctx.Version)
-	result = strings.ReplaceAll(result, "{{.PreviousVersion}}", ctx.PreviousVersion)
-	result = strings.ReplaceAll(result, "{{.TagName}}", ctx.TagName)
-	result = strings.ReplaceAll(result, "{{.ReleaseType}}", ctx.ReleaseType)
+// warnf writes a non-fatal warning.
+func warnf(format string, args ...any) {
+	fmt.Fprintf(warnOutput, "Warning: "+format+"\n", args...)
+}
 
-	// Handle branch name
-	if ctx.Branch != "" {
-		safeBranch := strings.ReplaceAll(ctx.Branch, "/", "-")
-		result = strings.ReplaceAll(result, "{{.Branch}}", safeBranch)
+// isFloatingTag reports whether a tag is expected to move between releases.
+func isFloatingTag(tag string, floating []string) bool {
+	for _, f := range floating {
+		if tag == f {
+			return true
+		}
 	}
-
-	return result
+	return false
 }
@@ -1,8 +1,19 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
 )
@@ -33,6 +44,65 @@ func TestACRPlugin_GetInfo(t *testing.T) {
 	if !hasPostPublish {
 		t.Error("expected HookPostPublish in hooks")
 	}
+	if !slices.Contains(info.Hooks, plugin.HookPrePublish) {
+		t.Error("expected HookPrePublish in hooks")
+	}
+}
+
+func TestACRPlugin_SupportedAuthMethods(t *testing.T) {
+	p := &ACRPlugin{}
+
+	for _, method := range p.SupportedAuthMethods() {
+		resp, err := p.Validate(context.Background(), map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+			"auth":         map[string]any{"method": method},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, e := range resp.Errors {
+			if e.Field == "auth.method" {
+				t.Errorf("advertised auth method %q rejected by Validate", method)
+			}
+		}
+	}
+}
+
+func TestACRPlugin_OutputSchema(t *testing.T) {
+	p := &ACRPlugin{}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	schema := p.OutputSchema()
+	for key := range resp.Outputs {
+		if _, ok := schema[key]; !ok {
+			t.Errorf("output %q missing from OutputSchema", key)
+		}
+	}
+	for key := range schema {
+		if _, ok := resp.Outputs[key]; !ok {
+			t.Errorf("OutputSchema key %q not produced by Execute", key)
+		}
+	}
 }
 
 func TestACRPlugin_Validate(t *testing.T) {
@@ -66,6 +136,92 @@ func TestACRPlugin_Validate(t *testing.T) {
 			wantErrors:  1,
 			description: "should fail when source_image is missing",
 		},
+		{
+			name:        "malformed source_image",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "my app:latest"},
+			wantErrors:  1,
+			description: "should fail when source_image is not a valid reference",
+		},
+		{
+			name: "invalid subscription",
+			config: map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"subscription": " padded name",
+			},
+			wantErrors:  1,
+			description: "should fail when subscription is neither an ID nor a name",
+		},
+		{
+			name: "invalid namespace",
+			config: map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"namespace":    "Platform//Team",
+			},
+			wantErrors:  1,
+			description: "should fail when namespace is not a valid path",
+		},
+		{
+			name: "invalid max_image_size",
+			config: map[string]any{
+				"registry":       "myregistry",
+				"image":          "myapp",
+				"source_image":   "myapp:latest",
+				"max_image_size": "huge",
+			},
+			wantErrors:  1,
+			description: "should fail when max_image_size is not a size",
+		},
+		{
+			name: "promote from another registry",
+			config: map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "ghcr.io/org/myapp:1.0.0",
+				"promote":      true,
+			},
+			wantErrors:  1,
+			description: "should fail when the promote source is not in ACR",
+		},
+		{
+			name: "promote with pull_source",
+			config: map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "staging/myapp:1.0.0",
+				"promote":      true,
+				"pull_source":  true,
+			},
+			wantErrors:  1,
+			description: "should fail when promote is combined with pull_source",
+		},
+		{
+			name: "source_registry without password",
+			config: map[string]any{
+				"registry":        "myregistry",
+				"image":           "myapp",
+				"source_image":    "shared.azurecr.io/base/myapp:1.0.0",
+				"promote":         true,
+				"source_registry": map[string]any{"username": "puller"},
+			},
+			wantErrors:  1,
+			description: "should fail when cross-registry credentials are incomplete",
+		},
+		{
+			name: "verify_integrity with cross-registry promote",
+			config: map[string]any{
+				"registry":         "myregistry",
+				"image":            "myapp",
+				"source_image":     "shared.azurecr.io/base/myapp:1.0.0",
+				"promote":          true,
+				"verify_integrity": true,
+			},
+			wantErrors:  1,
+			description: "should fail when the promote source digest cannot be resolved",
+		},
 		{
 			name:        "invalid auth method",
 			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "auth": map[string]any{"method": "invalid"}},
@@ -80,8 +236,8 @@ func TestACRPlugin_Validate(t *testing.T) {
 				"source_image": "myapp:latest",
 				"auth":         map[string]any{"method": "service_principal"},
 			},
-			wantErrors:  1,
-			description: "should fail when service principal credentials are missing",
+			wantErrors:  3,
+			description: "should fail once per missing service principal credential",
 		},
 		{
 			name: "service_principal with credentials",
@@ -99,79 +255,2859 @@ func TestACRPlugin_Validate(t *testing.T) {
 			wantErrors:  0,
 			description: "should pass with service principal credentials",
 		},
-		{
-			name: "admin missing credentials",
-			config: map[string]any{
-				"registry":     "myregistry",
-				"image":        "myapp",
-				"source_image": "myapp:latest",
-				"auth":         map[string]any{"method": "admin"},
-			},
-			wantErrors:  1,
-			description: "should fail when admin credentials are missing",
+		{
+			name: "admin missing credentials",
+			config: map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"auth":         map[string]any{"method": "admin"},
+			},
+			wantErrors:  1,
+			description: "should fail when admin credentials are missing",
+		},
+		{
+			name: "admin with credentials",
+			config: map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"auth": map[string]any{
+					"method":   "admin",
+					"username": "admin",
+					"password": "password123",
+				},
+			},
+			wantErrors:  0,
+			description: "should pass with admin credentials",
+		},
+		{
+			name: "managed_identity auth",
+			config: map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"auth":         map[string]any{"method": "managed_identity"},
+			},
+			wantErrors:  0,
+			description: "should pass with managed identity auth",
+		},
+		{
+			name: "managed_identity auth with client id",
+			config: map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"auth": map[string]any{
+					"method":    "managed_identity",
+					"client_id": "11111111-2222-3333-4444-555555555555",
+				},
+			},
+			wantErrors:  0,
+			description: "should pass with a user-assigned identity client id",
+		},
+		{
+			name: "negative max_upload_rate",
+			config: map[string]any{
+				"registry":        "myregistry",
+				"image":           "myapp",
+				"source_image":    "myapp:latest",
+				"max_upload_rate": -1,
+			},
+			wantErrors:  1,
+			description: "should fail with a negative upload rate",
+		},
+		{
+			name: "invalid execute_timeout",
+			config: map[string]any{
+				"registry":        "myregistry",
+				"image":           "myapp",
+				"source_image":    "myapp:latest",
+				"execute_timeout": "soon",
+			},
+			wantErrors:  1,
+			description: "should fail with an unparseable execute timeout",
+		},
+		{
+			name: "additional image missing name",
+			config: map[string]any{
+				"registry":          "myregistry",
+				"image":             "myapp",
+				"source_image":      "myapp:latest",
+				"additional_images": []any{map[string]any{"repository": "legacy"}},
+			},
+			wantErrors:  1,
+			description: "should fail when an additional image has no name",
+		},
+		{
+			name: "invalid dry_run_mode",
+			config: map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"dry_run_mode": "partial",
+			},
+			wantErrors:  1,
+			description: "should fail with an unknown dry-run mode",
+		},
+		{
+			name: "sbom file missing",
+			config: map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"sbom":         map[string]any{"file": "does-not-exist.cdx.json"},
+			},
+			wantErrors:  1,
+			description: "should fail when the SBOM file does not exist",
+		},
+		{
+			name:        "unsupported tls version",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "tls": map[string]any{"min_version": "1.0"}},
+			wantErrors:  1,
+			description: "should fail when tls.min_version is below 1.2",
+		},
+		{
+			name:        "invalid verify_anonymous_pull",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "verify_anonymous_pull": "public"},
+			wantErrors:  1,
+			description: "should fail when verify_anonymous_pull is not enabled or disabled",
+		},
+		{
+			name:        "set_anonymous_pull without verify_anonymous_pull",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "set_anonymous_pull": true},
+			wantErrors:  1,
+			description: "should fail when set_anonymous_pull has no intended state",
+		},
+		{
+			name:        "protect_labels without delete_previous_but",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "protect_labels": map[string]any{"retain": "true"}},
+			wantErrors:  1,
+			description: "should fail when protect_labels has no retention to apply to",
+		},
+		{
+			name:        "invalid metrics_pushgateway url",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "metrics_pushgateway": map[string]any{"url": "pushgateway:9091"}},
+			wantErrors:  1,
+			description: "should fail when the pushgateway URL is not absolute",
+		},
+		{
+			name:        "negative max_pushes",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "max_pushes": -1},
+			wantErrors:  1,
+			description: "should fail when max_pushes is negative",
+		},
+		{
+			name:        "efficient_tagging with admin auth",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "efficient_tagging": true, "auth": map[string]any{"method": "admin", "username": "myregistry", "password": "secret", "acknowledge_admin_auth": true}},
+			wantErrors:  1,
+			description: "should fail when efficient_tagging cannot run az acr import",
+		},
+		{
+			name:        "isolate_local_tags with token auth",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "isolate_local_tags": true, "auth": map[string]any{"method": "token", "token": "access-token"}},
+			wantErrors:  1,
+			description: "should fail when isolate_local_tags cannot run az acr import",
+		},
+		{
+			name:        "untagged_retention above 365 days",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "untagged_retention": 400},
+			wantErrors:  1,
+			description: "should fail when untagged_retention exceeds what ACR accepts",
+		},
+		{
+			name:        "untagged_retention with token auth",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "untagged_retention": 7, "auth": map[string]any{"method": "token", "token": "access-token"}},
+			wantErrors:  1,
+			description: "should fail when untagged_retention cannot run az acr config retention",
+		},
+		{
+			name:        "invalid hooks.timeout",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "hooks": map[string]any{"timeout": "5 minutes"}},
+			wantErrors:  1,
+			description: "should fail when hooks.timeout is not a duration",
+		},
+		{
+			name:        "invalid http_transport",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "http_transport": map[string]any{"max_idle_conns_per_host": 0, "idle_conn_timeout": "soon"}},
+			wantErrors:  2,
+			description: "should fail when the connection pool settings are unusable",
+		},
+		{
+			name:        "invalid sequence_pattern",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "sequence_pattern": "("},
+			wantErrors:  1,
+			description: "should fail when sequence_pattern is not a regular expression",
+		},
+		{
+			name:        "branch_policy without branches",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "branch_policy": map[string]any{"prod/*": []any{}}},
+			wantErrors:  1,
+			description: "should fail when a repository pattern allows no branches",
+		},
+		{
+			name:        "derive_path_from_source without image",
+			config:      map[string]any{"registry": "myregistry", "source_image": "docker.io/library/nginx:1.25", "derive_path_from_source": true},
+			wantErrors:  0,
+			description: "should not require image when the path is derived",
+		},
+		{
+			name:        "derive_path_from_source with image",
+			config:      map[string]any{"registry": "myregistry", "image": "nginx", "source_image": "docker.io/library/nginx:1.25", "derive_path_from_source": true},
+			wantErrors:  1,
+			description: "should fail when image is set alongside derive_path_from_source",
+		},
+		{
+			name:        "path_mode repository_only without image",
+			config:      map[string]any{"registry": "myregistry", "repository": "team/app", "source_image": "myapp:latest", "path_mode": "repository_only"},
+			wantErrors:  0,
+			description: "should not require image when the path is the repository",
+		},
+		{
+			name:        "path_mode repository_only without repository",
+			config:      map[string]any{"registry": "myregistry", "image": "app", "source_image": "myapp:latest", "path_mode": "repository_only"},
+			wantErrors:  1,
+			description: "should require repository when the path is the repository",
+		},
+		{
+			name: "path_mode repository_only with additional images",
+			config: map[string]any{
+				"registry":          "myregistry",
+				"repository":        "team/app",
+				"source_image":      "myapp:latest",
+				"path_mode":         "repository_only",
+				"additional_images": []any{map[string]any{"image": "worker"}},
+			},
+			wantErrors:  1,
+			description: "should fail when additional images would share the repository path",
+		},
+		{
+			name:        "invalid path_mode",
+			config:      map[string]any{"registry": "myregistry", "image": "app", "source_image": "myapp:latest", "path_mode": "flat"},
+			wantErrors:  1,
+			description: "should fail when path_mode is unknown",
+		},
+		{
+			name:        "unsupported lifecycle hook",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "hooks": map[string]any{"enabled": []any{"on-success"}}},
+			wantErrors:  1,
+			description: "should fail when hooks.enabled names a hook the plugin cannot push at",
+		},
+		{
+			name:        "disable_default_tag without tags",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "disable_default_tag": true},
+			wantErrors:  1,
+			description: "should fail when no tags remain and require_tags is on",
+		},
+		{
+			name:        "disable_default_tag with require_tags off",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "disable_default_tag": true, "require_tags": false},
+			wantErrors:  0,
+			description: "should allow an intentionally empty tag list",
+		},
+		{
+			name:        "token auth without token",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "auth": map[string]any{"method": "token"}},
+			wantErrors:  1,
+			description: "should fail when token auth has no token",
+		},
+		{
+			name:        "token auth",
+			config:      map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "auth": map[string]any{"method": "token", "token": "access-token"}},
+			wantErrors:  0,
+			description: "should pass with a token",
+		},
+		{
+			name:        "registry in allowed_registries",
+			config:      map[string]any{"registry": "myregistry.azurecr.io", "image": "myapp", "source_image": "myapp:latest", "allowed_registries": []any{"myregistry"}},
+			wantErrors:  0,
+			description: "should pass when the login server is allowed",
+		},
+		{
+			name:        "registry not in allowed_registries",
+			config:      map[string]any{"registry": "otherregistry", "image": "myapp", "source_image": "myapp:latest", "allowed_registries": []any{"myregistry"}},
+			wantErrors:  1,
+			description: "should fail when the registry is not allowed",
+		},
+		{
+			name:        "disabled skips required fields",
+			config:      map[string]any{"enabled": false},
+			wantErrors:  0,
+			description: "should pass without required fields when disabled",
+		},
+		{
+			name:        "empty config",
+			config:      map[string]any{},
+			wantErrors:  3,
+			description: "should fail with multiple errors for empty config",
+		},
+	}
+
+	p := &ACRPlugin{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := p.Validate(context.Background(), tt.config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(resp.Errors) != tt.wantErrors {
+				t.Errorf("%s: expected %d errors, got %d: %v",
+					tt.description, tt.wantErrors, len(resp.Errors), resp.Errors)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Validate_AdminAuthWarning(t *testing.T) {
+	tests := []struct {
+		name        string
+		acknowledge bool
+		wantWarning bool
+	}{
+		{name: "warns by default", acknowledge: false, wantWarning: true},
+		{name: "acknowledged", acknowledge: true, wantWarning: false},
+	}
+
+	p := &ACRPlugin{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			warnOutput = &buf
+			defer func() { warnOutput = os.Stderr }()
+
+			resp, err := p.Validate(context.Background(), map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"auth": map[string]any{
+					"method":                 "admin",
+					"username":               "admin",
+					"password":               "password123",
+					"acknowledge_admin_auth": tt.acknowledge,
+				},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !resp.Valid {
+				t.Errorf("expected admin auth to remain valid, got %v", resp.Errors)
+			}
+
+			if got := strings.Contains(buf.String(), "admin"); got != tt.wantWarning {
+				t.Errorf("expected warning %v, got %q", tt.wantWarning, buf.String())
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Validate_AllowedRegistriesEnv(t *testing.T) {
+	t.Setenv("ACR_ALLOWED_REGISTRIES", "prodregistry, devregistry")
+
+	tests := []struct {
+		name     string
+		registry string
+		allowed  []any
+		valid    bool
+	}{
+		{name: "env only", registry: "devregistry", valid: true},
+		{name: "outside env", registry: "otherregistry", valid: false},
+		{name: "in both", registry: "prodregistry", allowed: []any{"prodregistry", "otherregistry"}, valid: true},
+		{name: "config cannot widen env", registry: "otherregistry", allowed: []any{"otherregistry"}, valid: false},
+		{name: "config narrows env", registry: "devregistry", allowed: []any{"prodregistry"}, valid: false},
+	}
+
+	p := &ACRPlugin{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{"registry": tt.registry, "image": "myapp", "source_image": "myapp:latest"}
+			if tt.allowed != nil {
+				config["allowed_registries"] = tt.allowed
+			}
+			resp, err := p.Validate(context.Background(), config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Valid != tt.valid {
+				t.Errorf("expected valid %v, got errors %v", tt.valid, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Validate_ServicePrincipalFields(t *testing.T) {
+	t.Setenv("AZURE_CLIENT_SECRET", "")
+	t.Setenv("AZURE_TENANT_ID", "")
+
+	p := &ACRPlugin{}
+	resp, err := p.Validate(context.Background(), map[string]any{
+		"registry":     "myregistry",
+		"image":        "myapp",
+		"source_image": "myapp:latest",
+		"auth": map[string]any{
+			"method":    "service_principal",
+			"client_id": "my-client",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fields := []string{}
+	for _, e := range resp.Errors {
+		fields = append(fields, e.Field)
+	}
+	if !slices.Equal(fields, []string{"auth.client_secret", "auth.tenant_id"}) {
+		t.Errorf("expected errors for client_secret and tenant_id, got %v", resp.Errors)
+	}
+}
+
+func TestACRPlugin_Validate_CredentialFiles(t *testing.T) {
+	t.Setenv("AZURE_CLIENT_SECRET", "")
+
+	secretFile := filepath.Join(t.TempDir(), "client-secret")
+	if err := os.WriteFile(secretFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &ACRPlugin{}
+	for _, tt := range []struct {
+		name       string
+		file       string
+		wantErrors int
+	}{
+		{name: "readable file", file: secretFile, wantErrors: 0},
+		{name: "unreadable file", file: secretFile + ".missing", wantErrors: 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := p.Validate(context.Background(), map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"auth": map[string]any{
+					"method":             "service_principal",
+					"client_id":          "my-client",
+					"tenant_id":          "my-tenant",
+					"client_secret_file": tt.file,
+				},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(resp.Errors) != tt.wantErrors {
+				t.Errorf("expected %d errors, got %v", tt.wantErrors, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Validate_EmptyCredentials(t *testing.T) {
+	t.Setenv("AZURE_CLIENT_SECRET", "")
+
+	tests := []struct {
+		name        string
+		failOnEmpty bool
+		wantErrors  int
+	}{
+		{name: "warns by default", failOnEmpty: false, wantErrors: 1},
+		{name: "fails when configured", failOnEmpty: true, wantErrors: 2},
+	}
+
+	p := &ACRPlugin{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			warnOutput = &buf
+			defer func() { warnOutput = os.Stderr }()
+
+			resp, err := p.Validate(context.Background(), map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"auth": map[string]any{
+					"method":        "service_principal",
+					"client_id":     "my-client",
+					"client_secret": "",
+					"tenant_id":     "my-tenant",
+					"fail_on_empty": tt.failOnEmpty,
+				},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(resp.Errors) != tt.wantErrors {
+				t.Errorf("expected %d errors, got %d: %v", tt.wantErrors, len(resp.Errors), resp.Errors)
+			}
+
+			if !tt.failOnEmpty && !strings.Contains(buf.String(), "auth.client_secret") {
+				t.Errorf("expected empty client_secret warning, got %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Validate_ManagedIdentityNote(t *testing.T) {
+	var buf bytes.Buffer
+	warnOutput = &buf
+	defer func() { warnOutput = os.Stderr }()
+
+	p := &ACRPlugin{}
+	_, err := p.Validate(context.Background(), map[string]any{
+		"registry":     "myregistry",
+		"image":        "myapp",
+		"source_image": "myapp:latest",
+		"auth":         map[string]any{"method": "managed_identity"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "assigned identity") {
+		t.Errorf("expected managed identity note, got %q", buf.String())
+	}
+}
+
+func TestIsValidSubscription(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{value: "00000000-1111-2222-3333-444444444444", expected: true},
+		{value: "Production Platform", expected: true},
+		{value: " Production", expected: false},
+		{value: "bad\x00name", expected: false},
+		{value: strings.Repeat("a", 65), expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := isValidSubscription(tt.value); got != tt.expected {
+			t.Errorf("isValidSubscription(%q) = %v, want %v", tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestACRPlugin_Execute_DryRun(t *testing.T) {
+	p := &ACRPlugin{}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+			"tags":         []any{"1.0.0", "latest"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !resp.Success {
+		t.Error("expected success in dry run")
+	}
+
+	pushedImages, ok := resp.Outputs["pushed_images"].([]string)
+	if !ok {
+		t.Fatal("expected pushed_images in outputs")
+	}
+
+	if len(pushedImages) != 2 {
+		t.Errorf("expected 2 pushed images, got %d", len(pushedImages))
+	}
+}
+
+func TestACRPlugin_Execute_AdditionalImages(t *testing.T) {
+	p := &ACRPlugin{}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "app",
+			"source_image": "app:latest",
+			"tags":         []any{"1.0.0", "latest"},
+			"additional_images": []any{
+				map[string]any{"image": "app-legacy"},
+				map[string]any{"repository": "myteam", "image": "app-prod"},
+			},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pushedImages, _ := resp.Outputs["pushed_images"].([]string)
+	if len(pushedImages) != 6 {
+		t.Fatalf("expected 6 pushed images, got %d: %v", len(pushedImages), pushedImages)
+	}
+
+	byImage, ok := resp.Outputs["pushed_by_image"].(map[string][]string)
+	if !ok {
+		t.Fatal("expected pushed_by_image in outputs")
+	}
+
+	expected := map[string][]string{
+		"app":             {"myregistry.azurecr.io/app:1.0.0", "myregistry.azurecr.io/app:latest"},
+		"app-legacy":      {"myregistry.azurecr.io/app-legacy:1.0.0", "myregistry.azurecr.io/app-legacy:latest"},
+		"myteam/app-prod": {"myregistry.azurecr.io/myteam/app-prod:1.0.0", "myregistry.azurecr.io/myteam/app-prod:latest"},
+	}
+	for path, refs := range expected {
+		got := byImage[path]
+		if len(got) != len(refs) {
+			t.Errorf("%s: expected %v, got %v", path, refs, got)
+			continue
+		}
+		for i := range refs {
+			if got[i] != refs[i] {
+				t.Errorf("%s[%d]: expected %q, got %q", path, i, refs[i], got[i])
+			}
+		}
+	}
+}
+
+func TestImageTarget_Path(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   ImageTarget
+		expected string
+	}{
+		{name: "image only", target: ImageTarget{Image: "app"}, expected: "app"},
+		{name: "repository", target: ImageTarget{Repository: "team", Image: "app"}, expected: "team/app"},
+		{
+			name:     "namespace and repository",
+			target:   ImageTarget{Namespace: "platform/team-a", Repository: "svc", Image: "app"},
+			expected: "platform/team-a/svc/app",
+		},
+		{
+			name:     "empty components collapse",
+			target:   ImageTarget{Namespace: "platform/", Repository: "", Image: "app"},
+			expected: "platform/app",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.target.Path(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_PathMode(t *testing.T) {
+	tests := []struct {
+		mode       string
+		namespace  string
+		repository string
+		image      string
+		expected   string
+	}{
+		{mode: "compose", repository: "team", image: "app", expected: "myregistry.azurecr.io/team/app:1.0.0"},
+		{mode: "compose", image: "app", expected: "myregistry.azurecr.io/app:1.0.0"},
+		{mode: "compose", namespace: "platform", repository: "team", image: "app", expected: "myregistry.azurecr.io/platform/team/app:1.0.0"},
+		{mode: "image_only", repository: "team", image: "app", expected: "myregistry.azurecr.io/app:1.0.0"},
+		{mode: "image_only", namespace: "platform", repository: "team", image: "app", expected: "myregistry.azurecr.io/platform/app:1.0.0"},
+		{mode: "repository_only", repository: "team/app", image: "ignored", expected: "myregistry.azurecr.io/team/app:1.0.0"},
+		{mode: "repository_only", namespace: "platform", repository: "team", expected: "myregistry.azurecr.io/platform/team:1.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode+" "+tt.expected, func(t *testing.T) {
+			p := &ACRPlugin{}
+			req := plugin.ExecuteRequest{
+				Hook:   plugin.HookPostPublish,
+				DryRun: true,
+				Config: map[string]any{
+					"registry":     "myregistry",
+					"namespace":    tt.namespace,
+					"repository":   tt.repository,
+					"image":        tt.image,
+					"source_image": "myapp:latest",
+					"path_mode":    tt.mode,
+				},
+				Context: plugin.ReleaseContext{Version: "1.0.0"},
+			}
+
+			resp, err := p.Execute(context.Background(), req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got, _ := resp.Outputs["pushed_images"].([]string); !slices.Equal(got, []string{tt.expected}) {
+				t.Errorf("expected %s, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_Namespace(t *testing.T) {
+	p := &ACRPlugin{}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":          "myregistry",
+			"namespace":         "platform",
+			"repository":        "team-a",
+			"image":             "app",
+			"source_image":      "app:latest",
+			"tags":              []any{"1.0.0"},
+			"additional_images": []any{map[string]any{"image": "app-legacy"}},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if path := resp.Outputs["image_path"]; path != "platform/team-a/app" {
+		t.Errorf("expected image_path platform/team-a/app, got %v", path)
+	}
+
+	pushedImages, _ := resp.Outputs["pushed_images"].([]string)
+	expected := []string{"myregistry.azurecr.io/platform/team-a/app:1.0.0", "myregistry.azurecr.io/platform/app-legacy:1.0.0"}
+	if len(pushedImages) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, pushedImages)
+	}
+	for i := range expected {
+		if pushedImages[i] != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], pushedImages[i])
+		}
+	}
+}
+
+func TestACRPlugin_Execute_TagsLimit(t *testing.T) {
+	p := &ACRPlugin{}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+			"tags":         []any{"{{.Version}}", "latest", "stable"},
+			"tags_limit":   1,
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resolved, _ := resp.Outputs["resolved_tags"].([]string)
+	if len(resolved) != 3 {
+		t.Errorf("expected 3 resolved tags, got %v", resolved)
+	}
+
+	tags, _ := resp.Outputs["tags"].([]string)
+	if len(tags) != 1 || tags[0] != "1.0.0" {
+		t.Errorf("expected limited tags [1.0.0], got %v", tags)
+	}
+
+	pushedImages, _ := resp.Outputs["pushed_images"].([]string)
+	if len(pushedImages) != 1 {
+		t.Errorf("expected 1 pushed image, got %v", pushedImages)
+	}
+}
+
+func TestACRPlugin_Execute_DuplicateTags(t *testing.T) {
+	var buf bytes.Buffer
+	warnOutput = &buf
+	defer func() { warnOutput = os.Stderr }()
+
+	p := &ACRPlugin{}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+			"tags":         []any{"{{.TagName}}", "v{{.Version}}"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+			TagName: "v1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pushedImages, _ := resp.Outputs["pushed_images"].([]string)
+	if len(pushedImages) != 1 || pushedImages[0] != "myregistry.azurecr.io/myapp:v1.0.0" {
+		t.Errorf("expected a single push of v1.0.0, got %v", pushedImages)
+	}
+
+	if !strings.Contains(buf.String(), "v1.0.0") {
+		t.Errorf("expected duplicate tag warning, got %q", buf.String())
+	}
+}
+
+func TestACRPlugin_Execute_SuccessMessage(t *testing.T) {
+	p := &ACRPlugin{}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":        "myregistry",
+			"image":           "myapp",
+			"source_image":    "myapp:latest",
+			"tags":            []any{"{{.Version}}", "latest"},
+			"success_message": "pushed={{.Count}} registry={{.Registry}} tags={{range .Tags}}[{{.}}]{{end}}",
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "pushed=2 registry=myregistry.azurecr.io tags=[1.0.0][latest]"
+	if resp.Message != expected {
+		t.Errorf("expected message %q, got %q", expected, resp.Message)
+	}
+}
+
+func TestACRPlugin_Execute_TemplatedRepository(t *testing.T) {
+	p := &ACRPlugin{}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"repository":   "dev/{{.Branch}}",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+			Branch:  "feature/Login",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := resp.Outputs["repository"]; got != "dev/feature-login" {
+		t.Errorf("expected rendered repository dev/feature-login, got %v", got)
+	}
+	pushedImages, _ := resp.Outputs["pushed_images"].([]string)
+	if len(pushedImages) != 1 || pushedImages[0] != "myregistry.azurecr.io/dev/feature-login/myapp:1.0.0" {
+		t.Errorf("unexpected pushed images %v", pushedImages)
+	}
+}
+
+func TestACRPlugin_Execute_RegistryCase(t *testing.T) {
+	tests := []struct {
+		name     string
+		preserve bool
+		expected string
+	}{
+		{name: "lowercased", expected: "myregistry.azurecr.io/myapp:1.0.0"},
+		{name: "preserved", preserve: true, expected: "MyRegistry.azurecr.io/myapp:1.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ACRPlugin{}
+			req := plugin.ExecuteRequest{
+				Hook:   plugin.HookPostPublish,
+				DryRun: true,
+				Config: map[string]any{
+					"registry":               "MyRegistry",
+					"image":                  "myapp",
+					"source_image":           "myapp:latest",
+					"preserve_registry_case": tt.preserve,
+				},
+				Context: plugin.ReleaseContext{Version: "1.0.0"},
+			}
+
+			resp, err := p.Execute(context.Background(), req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			pushedImages, _ := resp.Outputs["pushed_images"].([]string)
+			if len(pushedImages) != 1 || pushedImages[0] != tt.expected {
+				t.Errorf("expected %s, got %v", tt.expected, pushedImages)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_RequireImmutableTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []any
+		wantErr bool
+	}{
+		{name: "version tag", tags: []any{"{{.Version}}", "latest"}},
+		{name: "latest only", tags: []any{"latest"}, wantErr: true},
+		{name: "floating only", tags: []any{"latest", "edge"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ACRPlugin{}
+			req := plugin.ExecuteRequest{
+				Hook:   plugin.HookPostPublish,
+				DryRun: true,
+				Config: map[string]any{
+					"registry":              "myregistry",
+					"image":                 "myapp",
+					"source_image":          "myapp:latest",
+					"tags":                  tt.tags,
+					"require_immutable_tag": true,
+				},
+				Context: plugin.ReleaseContext{Version: "1.0.0"},
+			}
+
+			_, err := p.Execute(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_FloatingTagsOutput(t *testing.T) {
+	p := &ACRPlugin{}
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":              "myregistry",
+			"image":                 "myapp",
+			"source_image":          "myapp:latest",
+			"tags":                  []any{"{{.Version}}", "latest", "nightly", "rc"},
+			"require_immutable_tag": true,
+			"mutable_tags":          []any{"nightly"},
+			"floating_tags":         []any{"latest", "rc"},
+		},
+		Context: plugin.ReleaseContext{Version: "1.0.0"},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := resp.Outputs["floating_tags"].([]string); !slices.Equal(got, []string{"latest", "nightly", "rc"}) {
+		t.Errorf("unexpected floating tags %v", got)
+	}
+}
+
+func TestACRPlugin_Execute_BranchPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		branch  string
+		wantErr bool
+	}{
+		{name: "main", branch: "main"},
+		{name: "feature branch", branch: "feature/x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ACRPlugin{}
+			req := plugin.ExecuteRequest{
+				Hook:   plugin.HookPostPublish,
+				DryRun: true,
+				Config: map[string]any{
+					"registry":      "myregistry",
+					"namespace":     "prod",
+					"image":         "myapp",
+					"source_image":  "myapp:latest",
+					"branch_policy": map[string]any{"prod/*": []any{"main"}},
+				},
+				Context: plugin.ReleaseContext{Version: "1.0.0", Branch: tt.branch},
+			}
+
+			_, err := p.Execute(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_LatestPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		version  string
+		expected []string
+	}{
+		{name: "stable_only stable", policy: "stable_only", version: "1.0.0", expected: []string{"1.0.0", "latest"}},
+		{name: "stable_only prerelease", policy: "stable_only", version: "2.0.0-rc.1", expected: []string{"2.0.0-rc.1"}},
+		{name: "always prerelease", policy: "always", version: "2.0.0-rc.1", expected: []string{"2.0.0-rc.1", "latest"}},
+		{name: "never stable", policy: "never", version: "1.0.0", expected: []string{"1.0.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ACRPlugin{}
+			req := plugin.ExecuteRequest{
+				Hook:   plugin.HookPostPublish,
+				DryRun: true,
+				Config: map[string]any{
+					"registry":      "myregistry",
+					"image":         "myapp",
+					"source_image":  "myapp:latest",
+					"tags":          []any{"{{.Version}}", "latest"},
+					"latest_policy": tt.policy,
+				},
+				Context: plugin.ReleaseContext{Version: tt.version},
+			}
+
+			resp, err := p.Execute(context.Background(), req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tags, _ := resp.Outputs["tags"].([]string)
+			if !slices.Equal(tags, tt.expected) {
+				t.Errorf("expected tags %v, got %v", tt.expected, tags)
+			}
+		})
+	}
+}
+
+func TestNewImageReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	ref := newImageReference("myregistry.azurecr.io", "team/app", "1.0.0", digest)
+	if ref.TagRef != "myregistry.azurecr.io/team/app:1.0.0" {
+		t.Errorf("unexpected tag_ref %q", ref.TagRef)
+	}
+	if ref.DigestRef != "myregistry.azurecr.io/team/app@"+digest {
+		t.Errorf("unexpected digest_ref %q", ref.DigestRef)
+	}
+
+	ref = newImageReference("myregistry.azurecr.io", "team/app", "1.0.0", "")
+	if ref.Digest != "" || ref.DigestRef != "" {
+		t.Errorf("expected empty digest fields without a digest, got %+v", ref)
+	}
+}
+
+func TestACRPlugin_Execute_References(t *testing.T) {
+	p := &ACRPlugin{}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+			"tags":         []any{"{{.Version}}", "latest"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	references, _ := resp.Outputs["references"].([]ImageReference)
+	if len(references) != 2 {
+		t.Fatalf("expected 2 references, got %v", references)
+	}
+	if references[0].Tag != "1.0.0" || references[0].TagRef != "myregistry.azurecr.io/myapp:1.0.0" {
+		t.Errorf("unexpected first reference %+v", references[0])
+	}
+}
+
+func TestACRPlugin_Execute_PrimaryDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("c", 64)
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			if cmd.Name == "docker" && cmd.Args[0] == "push" {
+				return []byte("digest: " + digest + " size: 528"), nil
+			}
+			if cmd.Name == "docker" && cmd.Args[0] == "image" {
+				return []byte(presentSourceInspect), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":          "myregistry",
+			"image":             "myapp",
+			"source_image":      "myapp:latest",
+			"tags":              []any{"{{.Version}}", "latest"},
+			"additional_images": []any{map[string]any{"image": "worker"}},
+			"auth":              map[string]any{"method": "token", "token": "access-token"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := resp.Outputs["acr_primary_digest"]; got != digest {
+		t.Errorf("expected primary digest %q, got %v", digest, got)
+	}
+	if got := resp.Outputs["acr_primary_reference"]; got != "myregistry.azurecr.io/myapp@"+digest {
+		t.Errorf("unexpected primary reference %v", got)
+	}
+}
+
+func TestACRPlugin_Execute_AuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	digest := "sha256:" + strings.Repeat("d", 64)
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			if cmd.Name == "docker" && cmd.Args[0] == "push" {
+				return []byte("digest: " + digest + " size: 528"), nil
+			}
+			if cmd.Name == "docker" && cmd.Args[0] == "image" {
+				return []byte(presentSourceInspect), nil
+			}
+			if cmd.Name == "az" && slices.Contains(cmd.Args, "user.name") {
+				return []byte("ci@example.com\n"), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+			"tags":         []any{"{{.Version}}", "latest"},
+			"audit_log":    path,
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	if _, err := p.Execute(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one audit entry per pushed tag, got %q", data)
+	}
+	for _, line := range lines {
+		var entry auditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if entry.Action != auditPush || entry.Actor != "ci@example.com" || entry.Registry != "myregistry.azurecr.io" || entry.Digest != digest {
+			t.Errorf("unexpected audit entry %+v", entry)
+		}
+	}
+}
+
+func TestACRPlugin_Execute_EfficientTagging(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("e", 64)
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			if cmd.Name == "docker" && cmd.Args[0] == "push" {
+				return []byte("digest: " + digest + " size: 528"), nil
+			}
+			if cmd.Name == "docker" && cmd.Args[0] == "image" {
+				return []byte(presentSourceInspect), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":          "myregistry",
+			"image":             "myapp",
+			"source_image":      "myapp:latest",
+			"tags":              []any{"{{.Version}}", "latest", "stable"},
+			"efficient_tagging": true,
+			"auth":              map[string]any{"method": "managed_identity"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pushes, imports := 0, []string{}
+	for _, cmd := range runner.commands {
+		if cmd.Name == "docker" && cmd.Args[0] == "push" {
+			pushes++
+		}
+		if cmd.Name == "az" && slices.Contains(cmd.Args, "import") {
+			imports = append(imports, strings.Join(cmd.Args, " "))
+		}
+	}
+	if pushes != 1 {
+		t.Errorf("expected one docker push, got %d", pushes)
+	}
+	expected := []string{
+		"acr import --name myregistry --source myregistry.azurecr.io/myapp@" + digest + " --image myapp:latest --force",
+		"acr import --name myregistry --source myregistry.azurecr.io/myapp@" + digest + " --image myapp:stable --force",
+	}
+	if !slices.Equal(imports, expected) {
+		t.Errorf("expected server-side copies %v, got %v", expected, imports)
+	}
+
+	digests, _ := resp.Outputs["digests"].(map[string]string)
+	if digests["myregistry.azurecr.io/myapp:stable"] != digest {
+		t.Errorf("expected copied tags to report the pushed digest, got %v", digests)
+	}
+}
+
+func TestACRPlugin_Execute_SourceManifestUnknown(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			if cmd.Name == "docker" && cmd.Args[0] == "image" {
+				return []byte("Error response from daemon: manifest unknown"), errors.New("exit status 1")
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+			"auth":         map[string]any{"method": "token", "token": "access-token"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	_, err := p.Execute(context.Background(), req)
+	var sourceErr *SourceImageError
+	if !errors.As(err, &sourceErr) {
+		t.Fatalf("expected SourceImageError, got %v", err)
+	}
+	for _, cmd := range runner.commands {
+		if cmd.Name == "docker" && (cmd.Args[0] == "tag" || cmd.Args[0] == "push") {
+			t.Errorf("expected no tag or push after a failed source check, got %v", cmd.Args)
+		}
+	}
+}
+
+func TestACRPlugin_Execute_ArchiveOCILayout(t *testing.T) {
+	archive := t.TempDir()
+	digest := "sha256:" + strings.Repeat("f", 64)
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			switch {
+			case cmd.Name == "docker" && cmd.Args[0] == "push":
+				return []byte("digest: " + digest + " size: 528"), nil
+			case cmd.Name == "docker" && cmd.Args[0] == "image":
+				return []byte(presentSourceInspect), nil
+			case cmd.Name == "oras":
+				return []byte("Digest: " + digest + "\n"), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":           "myregistry",
+			"repository":         "team",
+			"image":              "myapp",
+			"source_image":       "myapp:latest",
+			"archive_oci_layout": archive,
+			"auth":               map[string]any{"method": "token", "token": "access-token"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	layouts, _ := resp.Outputs["oci_layouts"].(map[string]ArchivedLayout)
+	expected := ArchivedLayout{Path: filepath.Join(archive, "team", "myapp") + ":1.0.0", Digest: digest}
+	if layouts["team/myapp"] != expected {
+		t.Errorf("expected layout %+v, got %v", expected, layouts)
+	}
+	last := runner.commands[len(runner.commands)-1]
+	if !slices.Equal(last.Args, []string{"copy", "--to-oci-layout", "myregistry.azurecr.io/team/myapp@" + digest, expected.Path}) {
+		t.Errorf("unexpected oras command %v", last.Args)
+	}
+}
+
+func TestACRPlugin_Execute_ArchiveOCILayoutDigestFromRegistry(t *testing.T) {
+	archive := t.TempDir()
+	digest := "sha256:" + strings.Repeat("f", 64)
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			switch {
+			case cmd.Name == "docker" && cmd.Args[0] == "image":
+				return []byte(presentSourceInspect), nil
+			case cmd.Name == "docker" && cmd.Args[0] == "buildx":
+				return []byte(digest + "\n"), nil
+			case cmd.Name == "oras":
+				return []byte("Digest: " + digest + "\n"), nil
+			}
+			// The push output names no digest
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":           "myregistry",
+			"image":              "myapp",
+			"source_image":       "myapp:latest",
+			"archive_oci_layout": archive,
+			"auth":               map[string]any{"method": "token", "token": "access-token"},
+		},
+		Context: plugin.ReleaseContext{Version: "1.0.0"},
+	}
+
+	if _, err := p.Execute(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Token auth cannot run az, so docker resolves the digest
+	last := runner.commands[len(runner.commands)-1]
+	if !slices.Contains(last.Args, "myregistry.azurecr.io/myapp@"+digest) {
+		t.Errorf("expected the digest resolved by docker to be archived, got %v", last.Args)
+	}
+}
+
+func TestACRPlugin_Execute_SBOMDigestFromRegistry(t *testing.T) {
+	sbom := filepath.Join(t.TempDir(), "sbom.cdx.json")
+	if err := os.WriteFile(sbom, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	digest := "sha256:" + strings.Repeat("e", 64)
+	tests := []struct {
+		name     string
+		resolved string
+		wantErr  bool
+	}{
+		{name: "resolved", resolved: digest + "\n"},
+		{name: "unresolved", resolved: "\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				respond: func(cmd Command) ([]byte, error) {
+					switch {
+					case cmd.Name == "docker" && cmd.Args[0] == "image":
+						return []byte(presentSourceInspect), nil
+					case cmd.Name == "az" && slices.Contains(cmd.Args, "digest"):
+						return []byte(tt.resolved), nil
+					case cmd.Name == "oras":
+						return []byte("Digest: sha256:" + strings.Repeat("d", 64) + "\n"), nil
+					}
+					// The push output names no digest
+					return nil, nil
+				},
+			}
+			p := &ACRPlugin{runner: runner}
+
+			req := plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"registry":     "myregistry",
+					"image":        "myapp",
+					"source_image": "myapp:latest",
+					"sbom":         map[string]any{"file": sbom},
+				},
+				Context: plugin.ReleaseContext{Version: "1.0.0"},
+			}
+
+			_, err := p.Execute(context.Background(), req)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "myregistry.azurecr.io/myapp:1.0.0") {
+					t.Fatalf("expected an error naming the image, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.ContainsFunc(runner.commands, func(cmd Command) bool {
+				return cmd.Name == "oras" && slices.Contains(cmd.Args, "myregistry.azurecr.io/myapp@"+digest)
+			}) {
+				t.Error("expected the SBOM to be attached to the digest resolved in the registry")
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_SigningManifestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signing.json")
+	digest := "sha256:" + strings.Repeat("f", 64)
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			switch {
+			case cmd.Name == "docker" && cmd.Args[0] == "push":
+				return []byte("digest: " + digest + " size: 528"), nil
+			case cmd.Name == "docker" && cmd.Args[0] == "image":
+				return []byte(presentSourceInspect), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":              "myregistry",
+			"image":                 "myapp",
+			"source_image":          "myapp:latest",
+			"tags":                  []any{"{{.Version}}", "latest"},
+			"signing_manifest_file": path,
+			"auth":                  map[string]any{"method": "token", "token": "access-token"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	if _, err := p.Execute(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read signing manifest: %v", err)
+	}
+	var manifest SigningManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("invalid signing manifest: %v", err)
+	}
+	if manifest.Registry != "myregistry.azurecr.io" || manifest.Version != "1.0.0" {
+		t.Errorf("unexpected manifest header %+v", manifest)
+	}
+	if len(manifest.Images) != 2 || manifest.Images[0].Reference != "myregistry.azurecr.io/myapp:1.0.0" || manifest.Images[1].Reference != "myregistry.azurecr.io/myapp:latest" {
+		t.Fatalf("unexpected manifest images %+v", manifest.Images)
+	}
+	if manifest.Images[0].DigestRef != "myregistry.azurecr.io/myapp@"+digest {
+		t.Errorf("unexpected digest reference %q", manifest.Images[0].DigestRef)
+	}
+}
+
+func TestACRPlugin_Execute_IsolateLocalTags(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("f", 64)
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			switch {
+			case cmd.Name == "docker" && cmd.Args[0] == "push":
+				return []byte("digest: " + digest + " size: 528"), nil
+			case cmd.Name == "docker" && cmd.Args[0] == "image":
+				return []byte(presentSourceInspect), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":           "myregistry",
+			"image":              "myapp",
+			"source_image":       "myapp:latest",
+			"tags":               []any{"1.0.0"},
+			"isolate_local_tags": true,
+			"auth":               map[string]any{"method": "managed_identity"},
+			"audit_log":          auditPath,
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := resp.Outputs["pushed_images"].([]string); !slices.Equal(got, []string{"myregistry.azurecr.io/myapp:1.0.0"}) {
+		t.Errorf("unexpected pushed images %v", got)
+	}
+
+	var intermediate string
+	for _, cmd := range runner.commands {
+		if cmd.Name == "docker" && cmd.Args[0] == "tag" {
+			intermediate = cmd.Args[2]
+		}
+	}
+	if !strings.HasPrefix(intermediate, "myregistry.azurecr.io/myapp:1.0.0-tmp-") {
+		t.Fatalf("expected an intermediate tag, got %q", intermediate)
+	}
+	remote := "myapp" + intermediate[strings.LastIndex(intermediate, ":"):]
+
+	var tail []Command
+	for _, cmd := range runner.commands {
+		if cmd.Name == "docker" && (cmd.Args[0] == "push" || cmd.Args[0] == "rmi") ||
+			cmd.Name == "az" && slices.Contains(cmd.Args, "repository") || cmd.Name == "az" && slices.Contains(cmd.Args, "import") {
+			tail = append(tail, Command{Name: cmd.Name, Args: cmd.Args})
+		}
+	}
+	expected := []Command{
+		{Name: "docker", Args: []string{"push", intermediate}},
+		{Name: "az", Args: []string{"acr", "import", "--name", "myregistry", "--source", "myregistry.azurecr.io/myapp@" + digest, "--image", "myapp:1.0.0", "--force"}},
+		{Name: "az", Args: []string{"acr", "repository", "untag", "--name", "myregistry", "--image", remote}},
+		{Name: "docker", Args: []string{"rmi", "--no-prune", intermediate}},
+	}
+	if len(tail) != len(expected) {
+		t.Fatalf("expected commands %v, got %v", expected, tail)
+	}
+	for i := range expected {
+		if tail[i].Name != expected[i].Name || !slices.Equal(tail[i].Args, expected[i].Args) {
+			t.Errorf("command %d: expected %v, got %v", i, expected[i], tail[i])
+		}
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var trail []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry auditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if entry.Digest != digest {
+			t.Errorf("unexpected digest in %+v", entry)
+		}
+		trail = append(trail, entry.Action+" "+entry.Image)
+	}
+	expectedTrail := []string{
+		"push " + intermediate,
+		"import myregistry.azurecr.io/myapp:1.0.0",
+		"delete " + intermediate,
+	}
+	if !slices.Equal(trail, expectedTrail) {
+		t.Errorf("expected audit trail %q, got %q", expectedTrail, trail)
+	}
+}
+
+func TestACRPlugin_Execute_IsolateLocalTags_PushFailure(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			switch {
+			case cmd.Name == "docker" && cmd.Args[0] == "push":
+				return []byte("denied"), errors.New("exit status 1")
+			case cmd.Name == "docker" && cmd.Args[0] == "image":
+				return []byte(presentSourceInspect), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":           "myregistry",
+			"image":              "myapp",
+			"source_image":       "myapp:latest",
+			"tags":               []any{"1.0.0"},
+			"isolate_local_tags": true,
+			"auth":               map[string]any{"method": "managed_identity"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	if _, err := p.Execute(context.Background(), req); err == nil {
+		t.Fatal("expected the push failure to be returned")
+	}
+	last := runner.commands[len(runner.commands)-1]
+	if last.Name != "docker" || last.Args[0] != "rmi" || !strings.Contains(last.Args[2], "-tmp-") {
+		t.Errorf("expected the intermediate tag to be removed after a failed push, got %v", last.Args)
+	}
+}
+
+func TestACRPlugin_Execute_IsolateLocalTags_ImportFailure(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			switch {
+			case cmd.Name == "docker" && cmd.Args[0] == "push":
+				return []byte("digest: sha256:" + strings.Repeat("f", 64) + " size: 528"), nil
+			case cmd.Name == "docker" && cmd.Args[0] == "image":
+				return []byte(presentSourceInspect), nil
+			case cmd.Name == "az" && slices.Contains(cmd.Args, "import"):
+				return []byte("throttled"), errors.New("exit status 1")
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":           "myregistry",
+			"image":              "myapp",
+			"source_image":       "myapp:latest",
+			"tags":               []any{"1.0.0"},
+			"isolate_local_tags": true,
+			"auth":               map[string]any{"method": "managed_identity"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	if _, err := p.Execute(context.Background(), req); err == nil {
+		t.Fatal("expected the import failure to be returned")
+	}
+	if !slices.ContainsFunc(runner.commands, func(cmd Command) bool {
+		return cmd.Name == "az" && slices.Contains(cmd.Args, "untag") && strings.Contains(cmd.Args[len(cmd.Args)-1], "-tmp-")
+	}) {
+		t.Error("expected the intermediate tag to be removed from the registry after a failed import")
+	}
+}
+
+func TestACRPlugin_Execute_RecordBaseImage(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("f", 64)
+	tests := []struct {
+		name     string
+		labels   string
+		expected BaseImage
+		attached bool
+	}{
+		{
+			name:     "labelled",
+			labels:   `{"org.opencontainers.image.base.name":"docker.io/library/alpine:3.20","org.opencontainers.image.base.digest":"sha256:abc"}`,
+			expected: BaseImage{Name: "docker.io/library/alpine:3.20", Digest: "sha256:abc"},
+			attached: true,
+		},
+		{
+			name:   "unlabelled",
+			labels: "null",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				respond: func(cmd Command) ([]byte, error) {
+					switch {
+					case cmd.Name == "docker" && cmd.Args[0] == "push":
+						return []byte("digest: " + digest + " size: 528"), nil
+					case cmd.Name == "docker" && slices.Contains(cmd.Args, "{{json .Config.Labels}}"):
+						return []byte(tt.labels + "\n"), nil
+					case cmd.Name == "docker" && cmd.Args[0] == "image":
+						return []byte(presentSourceInspect), nil
+					case cmd.Name == "oras":
+						return []byte("Digest: " + digest + "\n"), nil
+					}
+					return nil, nil
+				},
+			}
+			p := &ACRPlugin{runner: runner}
+
+			req := plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"registry":          "myregistry",
+					"image":             "myapp",
+					"source_image":      "myapp:latest",
+					"record_base_image": true,
+					"attach_base_image": true,
+					"auth":              map[string]any{"method": "token", "token": "access-token"},
+				},
+				Context: plugin.ReleaseContext{
+					Version: "1.0.0",
+				},
+			}
+
+			resp, err := p.Execute(context.Background(), req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := resp.Outputs["base_image"]; got != tt.expected {
+				t.Errorf("expected base image %+v, got %+v", tt.expected, got)
+			}
+			artifacts, _ := resp.Outputs["base_image_artifacts"].(map[string]string)
+			if _, ok := artifacts["myapp"]; ok != tt.attached {
+				t.Errorf("expected attached %v, got %v", tt.attached, artifacts)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_VerifyAnonymousPull(t *testing.T) {
+	tests := []struct {
+		name     string
+		warnOnly bool
+		wantErr  bool
+	}{
+		{name: "mismatch fails", wantErr: true},
+		{name: "mismatch warns", warnOnly: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				respond: func(cmd Command) ([]byte, error) {
+					switch {
+					case cmd.Name == "docker" && cmd.Args[0] == "image":
+						return []byte(presentSourceInspect), nil
+					case cmd.Name == "az" && slices.Contains(cmd.Args, "anonymousPullEnabled"):
+						return []byte("false\n"), nil
+					}
+					return nil, nil
+				},
+			}
+			p := &ACRPlugin{runner: runner}
+
+			req := plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"registry":                 "myregistry",
+					"image":                    "myapp",
+					"source_image":             "myapp:latest",
+					"verify_anonymous_pull":    "enabled",
+					"anonymous_pull_warn_only": tt.warnOnly,
+				},
+				Context: plugin.ReleaseContext{
+					Version: "1.0.0",
+				},
+			}
+
+			resp, err := p.Execute(context.Background(), req)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "expects enabled") {
+					t.Fatalf("expected a mismatch error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := resp.Outputs["anonymous_pull"]; got != "disabled" {
+				t.Errorf("expected anonymous_pull disabled, got %v", got)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_ProtectLabels(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		deleted   []string
+		protected []string
+	}{
+		{
+			name:      "labelled",
+			config:    `{"config":{"Labels":{"retain":"true"}}}`,
+			deleted:   []string{},
+			protected: []string{"myregistry.azurecr.io/myapp:1.0.0"},
+		},
+		{
+			name:      "unlabelled",
+			config:    `{"config":{}}`,
+			deleted:   []string{"myregistry.azurecr.io/myapp:1.0.0"},
+			protected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				respond: func(cmd Command) ([]byte, error) {
+					switch {
+					case cmd.Name == "docker" && cmd.Args[0] == "image":
+						return []byte(presentSourceInspect), nil
+					case cmd.Name == "az" && slices.Contains(cmd.Args, "show-tags"):
+						return []byte("1.0.0\n1.1.0\n1.2.0\n"), nil
+					case cmd.Name == "oras":
+						return []byte(tt.config), nil
+					}
+					return nil, nil
+				},
+			}
+			p := &ACRPlugin{runner: runner}
+
+			req := plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"registry":            "myregistry",
+					"image":               "myapp",
+					"source_image":        "myapp:latest",
+					"tags":                []any{"1.2.0"},
+					"delete_previous_but": 1,
+					"protect_labels":      map[string]any{"retain": "true"},
+				},
+				Context: plugin.ReleaseContext{
+					Version:         "1.2.0",
+					PreviousVersion: "1.1.0",
+				},
+			}
+
+			resp, err := p.Execute(context.Background(), req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, _ := resp.Outputs["untagged_tags"].([]string); !slices.Equal(got, tt.deleted) {
+				t.Errorf("expected untagged tags %v, got %v", tt.deleted, got)
+			}
+			if got, _ := resp.Outputs["protected_tags"].([]string); !slices.Equal(got, tt.protected) {
+				t.Errorf("expected protected tags %v, got %v", tt.protected, got)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_RetentionSharedDigest(t *testing.T) {
+	const shared = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			switch {
+			case cmd.Name == "docker" && cmd.Args[0] == "image":
+				return []byte(presentSourceInspect), nil
+			case cmd.Name == "docker" && cmd.Args[0] == "push":
+				return []byte("1.2.0: digest: " + shared + " size: 1234\n"), nil
+			case cmd.Name == "az" && slices.Contains(cmd.Args, "show-tags"):
+				return []byte("1.0.0\n1.1.0\n1.2.0\n"), nil
+			case cmd.Name == "az" && slices.Contains(cmd.Args, "digest"):
+				// The retired version was re-released unchanged
+				return []byte(shared + "\n"), nil
+			case cmd.Name == "az" && slices.Contains(cmd.Args, "imageSize"):
+				return []byte("52428800\n"), nil
+			case cmd.Name == "az" && slices.Contains(cmd.Args, "show-metadata"):
+				return []byte("1.2.0\n"), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":            "myregistry",
+			"image":               "myapp",
+			"source_image":        "myapp:latest",
+			"tags":                []any{"1.2.0"},
+			"delete_previous_but": 1,
+		},
+		Context: plugin.ReleaseContext{
+			Version:         "1.2.0",
+			PreviousVersion: "1.1.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, _ := resp.Outputs["untagged_tags"].([]string); !slices.Equal(got, []string{"myregistry.azurecr.io/myapp:1.0.0"}) {
+		t.Errorf("unexpected untagged tags %v", got)
+	}
+	if got, _ := resp.Outputs["deleted_tags"].([]string); !slices.Equal(got, []string{"myregistry.azurecr.io/myapp:1.0.0"}) {
+		t.Errorf("expected the deprecated deleted_tags alias to match, got %v", got)
+	}
+	if got := resp.Outputs["reclaimable_bytes"]; got != int64(0) {
+		t.Errorf("expected nothing reclaimable from a digest still in use, got %v", got)
+	}
+	for _, cmd := range runner.commands {
+		if cmd.Name == "az" && slices.Contains(cmd.Args, "delete") {
+			t.Fatalf("expected the manifest to be kept, got %v", cmd.Args)
+		}
+	}
+	if !slices.ContainsFunc(runner.commands, func(cmd Command) bool {
+		return cmd.Name == "az" && slices.Equal(cmd.Args, []string{"acr", "repository", "untag", "--name", "myregistry", "--image", "myapp:1.0.0"})
+	}) {
+		t.Error("expected the retired tag to be untagged")
+	}
+}
+
+func TestACRPlugin_Execute_RetentionRemainingTags(t *testing.T) {
+	const retired = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	tests := []struct {
+		name        string
+		remaining   string
+		reclaimable int64
+	}{
+		{name: "still tagged", remaining: "1.1\tstable\n", reclaimable: 0},
+		{name: "untagged", remaining: "\n", reclaimable: 52428800},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				respond: func(cmd Command) ([]byte, error) {
+					switch {
+					case cmd.Name == "docker" && cmd.Args[0] == "image":
+						return []byte(presentSourceInspect), nil
+					case cmd.Name == "az" && slices.Contains(cmd.Args, "show-tags"):
+						return []byte("1.0.0\n1.1.0\n1.2.0\n"), nil
+					case cmd.Name == "az" && slices.Contains(cmd.Args, "digest"):
+						return []byte(retired + "\n"), nil
+					case cmd.Name == "az" && slices.Contains(cmd.Args, "imageSize"):
+						return []byte("52428800\n"), nil
+					case cmd.Name == "az" && slices.Contains(cmd.Args, "show-metadata"):
+						return []byte(tt.remaining), nil
+					}
+					return nil, nil
+				},
+			}
+			p := &ACRPlugin{runner: runner}
+
+			req := plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"registry":            "myregistry",
+					"image":               "myapp",
+					"source_image":        "myapp:latest",
+					"tags":                []any{"1.2.0"},
+					"delete_previous_but": 1,
+				},
+				Context: plugin.ReleaseContext{
+					Version:         "1.2.0",
+					PreviousVersion: "1.1.0",
+				},
+			}
+
+			resp, err := p.Execute(context.Background(), req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := resp.Outputs["reclaimable_bytes"]; got != tt.reclaimable {
+				t.Errorf("expected %d reclaimable bytes, got %v", tt.reclaimable, got)
+			}
+			if !slices.ContainsFunc(runner.commands, func(cmd Command) bool {
+				return cmd.Name == "az" && slices.Equal(cmd.Args, []string{"acr", "manifest", "show-metadata", "--registry", "myregistry", "--name", "myapp@" + retired, "--query", "tags", "--output", "tsv"})
+			}) {
+				t.Error("expected the remaining tags of the retired manifest to be read")
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_UntaggedRetention(t *testing.T) {
+	tests := []struct {
+		name string
+		sku  string
+		set  bool
+	}{
+		{name: "premium", sku: "Premium", set: true},
+		{name: "standard", sku: "Standard", set: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				respond: func(cmd Command) ([]byte, error) {
+					switch {
+					case cmd.Name == "docker" && cmd.Args[0] == "image":
+						return []byte(presentSourceInspect), nil
+					case cmd.Name == "az" && slices.Equal(cmd.Args[:2], []string{"acr", "show"}) && slices.Contains(cmd.Args, "json"):
+						return []byte(`{"location":"westeurope","sku":{"name":"` + tt.sku + `"}}`), nil
+					}
+					return nil, nil
+				},
+			}
+			p := &ACRPlugin{runner: runner}
+
+			req := plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"registry":           "myregistry",
+					"image":              "myapp",
+					"source_image":       "myapp:latest",
+					"tags":               []any{"1.2.0"},
+					"untagged_retention": 7,
+				},
+				Context: plugin.ReleaseContext{Version: "1.2.0"},
+			}
+
+			if _, err := p.Execute(context.Background(), req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			set := slices.ContainsFunc(runner.commands, func(cmd Command) bool {
+				return cmd.Name == "az" && slices.Equal(cmd.Args, []string{"acr", "config", "retention", "update", "--registry", "myregistry", "--status", "enabled", "--days", "7", "--type", "UntaggedManifests"})
+			})
+			if set != tt.set {
+				t.Errorf("expected retention policy update %v, got %v", tt.set, set)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_MetricsPushgateway(t *testing.T) {
+	var path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			switch {
+			case cmd.Name == "docker" && cmd.Args[0] == "image":
+				return []byte(presentSourceInspect), nil
+			case cmd.Name == "docker" && cmd.Args[0] == "push":
+				return []byte("denied"), errors.New("exit status 1")
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":            "myregistry",
+			"image":               "myapp",
+			"source_image":        "myapp:latest",
+			"auth":                map[string]any{"method": "token", "token": "access-token"},
+			"metrics_pushgateway": map[string]any{"url": server.URL},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	if _, err := p.Execute(context.Background(), req); err == nil {
+		t.Fatal("expected the push failure to be returned")
+	}
+	if path != "/metrics/job/relicta-acr/registry/myregistry.azurecr.io/repository/myapp" {
+		t.Errorf("unexpected grouping path %s", path)
+	}
+	if !strings.Contains(body, `acr_push_failed{result="failure"} 1`) {
+		t.Errorf("expected failure metrics to be pushed, got:\n%s", body)
+	}
+}
+
+func TestACRPlugin_Execute_NormalizeSource(t *testing.T) {
+	runner := &fakeRunner{}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":                "myregistry",
+			"source_image":            "nginx",
+			"pull_source":             true,
+			"normalize_source":        true,
+			"derive_path_from_source": true,
+			"source_registry_mirror":  "mirror.corp.example/dockerhub",
+			"tags":                    []any{"1.0.0"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := resp.Outputs["normalized_source"]; got != "docker.io/library/nginx:latest" {
+		t.Errorf("unexpected normalized source %v", got)
+	}
+	if got := resp.Outputs["source_image"]; got != "mirror.corp.example/dockerhub/library/nginx:latest" {
+		t.Errorf("unexpected mirrored source %v", got)
+	}
+	if got := resp.Outputs["derived_path"]; got != "library/nginx" {
+		t.Errorf("unexpected derived path %v", got)
+	}
+}
+
+func TestACRPlugin_Execute_TagNovelty(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			if cmd.Name == "docker" && cmd.Args[0] == "manifest" && strings.HasSuffix(cmd.Args[2], ":1.0.0") {
+				return []byte("manifest unknown"), errors.New("exit status 1")
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":           "myregistry",
+			"image":              "myapp",
+			"source_image":       "myapp:latest",
+			"tags":               []any{"{{.Version}}", "latest"},
+			"report_tag_novelty": true,
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, _ := resp.Outputs["new_tags"].([]string); !slices.Equal(got, []string{"myregistry.azurecr.io/myapp:1.0.0"}) {
+		t.Errorf("unexpected new tags %v", got)
+	}
+	if got, _ := resp.Outputs["overwritten_tags"].([]string); !slices.Equal(got, []string{"myregistry.azurecr.io/myapp:latest"}) {
+		t.Errorf("unexpected overwritten tags %v", got)
+	}
+	for _, cmd := range runner.commands {
+		if cmd.Name == "docker" && cmd.Args[0] == "push" {
+			t.Errorf("expected no push in dry run, got %v", cmd.Args)
+		}
+	}
+}
+
+func TestACRPlugin_Execute_TagMetadata(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			switch {
+			case cmd.Name == "docker" && cmd.Args[0] == "image" && strings.Contains(cmd.Args[3], ".Os"):
+				return []byte("linux/amd64\n"), nil
+			case cmd.Name == "docker" && cmd.Args[0] == "image":
+				return []byte(presentSourceInspect), nil
+			case cmd.Name == "docker" && cmd.Args[0] == "manifest":
+				return []byte(`{"mediaType": "application/vnd.oci.image.manifest.v1+json", "config": {"size": 100}, "layers": [{"size": 900}]}`), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{
+		runner:        runner,
+		resolveDigest: func(context.Context, string) (string, error) { return "sha256:abc", nil },
+	}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+			"tags":         []any{"{{.Version}}", "latest"},
+			"tag_metadata": true,
+			"auth":         map[string]any{"method": "token", "token": "access-token"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	metadata, _ := resp.Outputs["tag_metadata"].([]TagMetadata)
+	if len(metadata) != 2 {
+		t.Fatalf("expected 2 metadata records, got %+v", metadata)
+	}
+	for i, tag := range []string{"1.0.0", "latest"} {
+		meta := metadata[i]
+		if meta.Tag != tag || meta.Reference != "myregistry.azurecr.io/myapp:"+tag {
+			t.Errorf("record %d: unexpected tag %q reference %q", i, meta.Tag, meta.Reference)
+		}
+		if meta.MediaType != "application/vnd.oci.image.manifest.v1+json" || meta.Size != 1000 || meta.Layers != 1 {
+			t.Errorf("record %d: unexpected manifest details %+v", i, meta)
+		}
+		if !slices.Equal(meta.Platforms, []string{"linux/amd64"}) {
+			t.Errorf("record %d: unexpected platforms %v", i, meta.Platforms)
+		}
+	}
+
+	types, _ := resp.Outputs["manifest_types"].(map[string]string)
+	if len(types) != 2 || types["myregistry.azurecr.io/myapp:latest"] != "application/vnd.oci.image.manifest.v1+json" {
+		t.Errorf("unexpected manifest types %v", types)
+	}
+}
+
+// slowFirstTagRunner delays pushes of the first tag so parallel pushes finish
+// out of order.
+type slowFirstTagRunner struct {
+	firstTag string
+}
+
+// Run implements CommandRunner.
+func (r *slowFirstTagRunner) Run(_ context.Context, cmd Command) ([]byte, error) {
+	if cmd.Name == "docker" && cmd.Args[0] == "image" {
+		return []byte(presentSourceInspect), nil
+	}
+	if cmd.Name == "docker" && cmd.Args[0] == "push" && strings.HasSuffix(cmd.Args[1], ":"+r.firstTag) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return nil, nil
+}
+
+func TestACRPlugin_Execute_OutputOrder(t *testing.T) {
+	p := &ACRPlugin{runner: &slowFirstTagRunner{firstTag: "1.0.0"}}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":           "myregistry",
+			"image":              "myapp",
+			"source_image":       "myapp:latest",
+			"tags":               []any{"{{.Version}}", "stable", "latest"},
+			"additional_images":  []any{map[string]any{"image": "worker"}},
+			"max_parallel":       6,
+			"report_tag_novelty": true,
+			"auth":               map[string]any{"method": "token", "token": "access-token"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"myregistry.azurecr.io/myapp:1.0.0",
+		"myregistry.azurecr.io/myapp:stable",
+		"myregistry.azurecr.io/myapp:latest",
+		"myregistry.azurecr.io/worker:1.0.0",
+		"myregistry.azurecr.io/worker:stable",
+		"myregistry.azurecr.io/worker:latest",
+	}
+	if got, _ := resp.Outputs["tags"].([]string); !slices.Equal(got, []string{"1.0.0", "stable", "latest"}) {
+		t.Errorf("unexpected tags %v", got)
+	}
+	if got, _ := resp.Outputs["pushed_images"].([]string); !slices.Equal(got, expected) {
+		t.Errorf("expected pushed images %v, got %v", expected, got)
+	}
+	if got, _ := resp.Outputs["overwritten_tags"].([]string); !slices.Equal(got, expected) {
+		t.Errorf("expected overwritten tags %v, got %v", expected, got)
+	}
+	references, _ := resp.Outputs["references"].([]ImageReference)
+	for i, ref := range references {
+		if ref.TagRef != expected[i] {
+			t.Errorf("reference %d: expected %s, got %s", i, expected[i], ref.TagRef)
+		}
+	}
+	byImage, _ := resp.Outputs["pushed_by_image"].(map[string][]string)
+	if got := byImage["myapp"]; !slices.Equal(got, expected[:3]) {
+		t.Errorf("unexpected pushed images for myapp %v", got)
+	}
+}
+
+func TestACRPlugin_Execute_VerifyIntegrity(t *testing.T) {
+	tests := []struct {
+		name         string
+		remoteConfig string
+		wantErr      bool
+	}{
+		{name: "match", remoteConfig: "sha256:source"},
+		{name: "mismatch", remoteConfig: "sha256:swapped", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				respond: func(cmd Command) ([]byte, error) {
+					switch {
+					case cmd.Name == "docker" && cmd.Args[0] == "image" && cmd.Args[3] == "{{.Id}}":
+						return []byte("sha256:source\n"), nil
+					case cmd.Name == "docker" && cmd.Args[0] == "image":
+						return []byte(presentSourceInspect), nil
+					case cmd.Name == "docker" && cmd.Args[0] == "manifest":
+						return []byte(`{"config": {"digest": "` + tt.remoteConfig + `"}}`), nil
+					}
+					return nil, nil
+				},
+			}
+			p := &ACRPlugin{runner: runner}
+
+			req := plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"registry":         "myregistry",
+					"image":            "myapp",
+					"source_image":     "myapp:latest",
+					"verify_integrity": true,
+					"auth":             map[string]any{"method": "token", "token": "access-token"},
+				},
+				Context: plugin.ReleaseContext{
+					Version: "1.0.0",
+				},
+			}
+
+			resp, err := p.Execute(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := resp.Outputs["source_digest"]; got != "sha256:source" {
+				t.Errorf("expected source digest sha256:source, got %v", got)
+			}
+			verified, _ := resp.Outputs["verified_digests"].(map[string]string)
+			if got := verified["myregistry.azurecr.io/myapp:1.0.0"]; got != "sha256:source" {
+				t.Errorf("unexpected verified digests %v", verified)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_VerifyIntegrity_ContainerdStore(t *testing.T) {
+	const index = "sha256:index"
+	tests := []struct {
+		name    string
+		auth    map[string]any
+		wantErr bool
+	}{
+		{name: "az session", auth: map[string]any{"method": "managed_identity"}},
+		{name: "token auth", auth: map[string]any{"method": "token", "token": "access-token"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				respond: func(cmd Command) ([]byte, error) {
+					switch {
+					case cmd.Name == "docker" && cmd.Args[0] == "image" && cmd.Args[3] == "{{.Id}}":
+						return []byte(index + "\n"), nil
+					case cmd.Name == "docker" && cmd.Args[0] == "image":
+						return []byte(presentSourceInspect), nil
+					case cmd.Name == "docker" && cmd.Args[0] == "info":
+						return []byte(`[["driver-type","io.containerd.snapshotter.v1"]]`), nil
+					case cmd.Name == "docker" && cmd.Args[0] == "manifest":
+						// A multi-platform index has no config digest
+						return []byte(`{"mediaType": "application/vnd.oci.image.index.v1+json", "manifests": []}`), nil
+					case cmd.Name == "az" && slices.Contains(cmd.Args, "digest"):
+						return []byte(index + "\n"), nil
+					}
+					return nil, nil
+				},
+			}
+			p := &ACRPlugin{runner: runner}
+
+			req := plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"registry":         "myregistry",
+					"image":            "myapp",
+					"source_image":     "myapp:latest",
+					"verify_integrity": true,
+					"auth":             tt.auth,
+				},
+				Context: plugin.ReleaseContext{
+					Version: "1.0.0",
+				},
+			}
+
+			resp, err := p.Execute(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), "containerd image store") {
+					t.Errorf("expected the containerd limitation to be named, got %v", err)
+				}
+				return
+			}
+			verified, _ := resp.Outputs["verified_digests"].(map[string]string)
+			if got := verified["myregistry.azurecr.io/myapp:1.0.0"]; got != index {
+				t.Errorf("unexpected verified digests %v", verified)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_NextSequence(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			if cmd.Name == "az" && slices.Contains(cmd.Args, "show-tags") {
+				return []byte("build-7\nbuild-41\nlatest\n"), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":         "myregistry",
+			"namespace":        "team",
+			"image":            "myapp",
+			"source_image":     "myapp:latest",
+			"tags":             []any{"build-{{.NextSequence}}", "latest"},
+			"sequence_pattern": `^build-(\d+)$`,
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, _ := resp.Outputs["tags"].([]string); !slices.Equal(got, []string{"build-42", "latest"}) {
+		t.Errorf("unexpected tags %v", got)
+	}
+	if got := resp.Outputs["sequence"]; got != 42 {
+		t.Errorf("expected sequence 42, got %v", got)
+	}
+	for _, cmd := range runner.commands {
+		if slices.Contains(cmd.Args, "show-tags") && !slices.Contains(cmd.Args, "team/myapp") {
+			t.Errorf("expected the primary repository to be listed, got %v", cmd.Args)
+		}
+	}
+}
+
+func TestACRPlugin_Execute_CredentialHelper(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			switch {
+			case cmd.Name == "docker" && cmd.Args[0] == "image":
+				return []byte(presentSourceInspect), nil
+			case cmd.Name == "docker" && cmd.Args[0] == "push":
+				return []byte("unauthorized: authentication required"), errors.New("exit status 1")
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+			"auth":         map[string]any{"method": "credential_helper"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	_, err := p.Execute(context.Background(), req)
+	if err == nil || !strings.Contains(err.Error(), "credHelpers entry for myregistry.azurecr.io") {
+		t.Errorf("expected an error pointing at the credential helper, got %v", err)
+	}
+	for _, cmd := range runner.commands {
+		if cmd.Name == "az" || (cmd.Name == "docker" && cmd.Args[0] == "login") {
+			t.Errorf("expected no login with a credential helper, got %s %v", cmd.Name, cmd.Args)
+		}
+	}
+}
+
+func TestACRPlugin_Execute_LifecycleHooks(t *testing.T) {
+	tests := []struct {
+		name     string
+		hook     plugin.Hook
+		enabled  []any
+		expected []string
+	}{
+		{name: "default post-publish", hook: plugin.HookPostPublish, expected: []string{"myregistry.azurecr.io/prod/myapp:1.0.0"}},
+		{name: "pre-publish not enabled", hook: plugin.HookPrePublish, expected: []string{}},
+		{name: "pre-publish staging push", hook: plugin.HookPrePublish, enabled: []any{"pre-publish", "post-publish"}, expected: []string{"myregistry.azurecr.io/staging/myapp:1.0.0"}},
+		{name: "post-publish not enabled", hook: plugin.HookPostPublish, enabled: []any{"pre-publish"}, expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := map[string]any{"repositories": map[string]any{"pre-publish": "staging"}}
+			if tt.enabled != nil {
+				hooks["enabled"] = tt.enabled
+			}
+			p := &ACRPlugin{}
+			req := plugin.ExecuteRequest{
+				Hook:   tt.hook,
+				DryRun: true,
+				Config: map[string]any{
+					"registry":     "myregistry",
+					"repository":   "prod",
+					"image":        "myapp",
+					"source_image": "myapp:latest",
+					"hooks":        hooks,
+				},
+				Context: plugin.ReleaseContext{Version: "1.0.0"},
+			}
+
+			resp, err := p.Execute(context.Background(), req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got, _ := resp.Outputs["pushed_images"].([]string); !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_DerivePathFromSource(t *testing.T) {
+	p := &ACRPlugin{}
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":                "myregistry",
+			"namespace":               "mirror",
+			"source_image":            "docker.io/library/nginx:1.25",
+			"tags":                    []any{"1.25"},
+			"derive_path_from_source": true,
+		},
+		Context: plugin.ReleaseContext{Version: "1.0.0"},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := resp.Outputs["derived_path"]; got != "library/nginx" {
+		t.Errorf("expected derived path library/nginx, got %v", got)
+	}
+	if got, _ := resp.Outputs["pushed_images"].([]string); !slices.Equal(got, []string{"myregistry.azurecr.io/mirror/library/nginx:1.25"}) {
+		t.Errorf("unexpected pushed images %v", got)
+	}
+}
+
+func TestACRPlugin_Execute_PushHooks(t *testing.T) {
+	tests := []struct {
+		name       string
+		failing    string
+		required   bool
+		wantErr    bool
+		wantPushes int
+	}{
+		{name: "both succeed", wantPushes: 1},
+		{name: "pre-push failure aborts", failing: "./pre.sh", wantErr: true, wantPushes: 0},
+		{name: "post-push failure warns", failing: "./post.sh", wantPushes: 1},
+		{name: "post-push failure when required", failing: "./post.sh", required: true, wantErr: true, wantPushes: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				respond: func(cmd Command) ([]byte, error) {
+					switch {
+					case cmd.Name == tt.failing:
+						return []byte("hook failed"), errors.New("exit status 1")
+					case cmd.Name == "docker" && cmd.Args[0] == "image":
+						return []byte(presentSourceInspect), nil
+					}
+					return nil, nil
+				},
+			}
+			p := &ACRPlugin{runner: runner}
+
+			req := plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"registry":          "myregistry",
+					"image":             "myapp",
+					"source_image":      "myapp:latest",
+					"pre_push_command":  "./pre.sh {{.Version}}",
+					"post_push_command": "./post.sh {{.Version}}",
+					"hooks":             map[string]any{"required": tt.required},
+					"auth":              map[string]any{"method": "token", "token": "access-token"},
+				},
+				Context: plugin.ReleaseContext{
+					Version: "1.0.0",
+				},
+			}
+
+			_, err := p.Execute(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			pushes := 0
+			for _, cmd := range runner.commands {
+				if cmd.Name == "docker" && cmd.Args[0] == "push" {
+					pushes++
+				}
+				if cmd.Name == "./post.sh" && !slices.Contains(cmd.Env, "ACR_PUSHED_IMAGES=myregistry.azurecr.io/myapp:1.0.0") {
+					t.Errorf("expected pushed images in the post-push environment, got %v", cmd.Env)
+				}
+			}
+			if pushes != tt.wantPushes {
+				t.Errorf("expected %d pushes, got %d", tt.wantPushes, pushes)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_Execute_Steps(t *testing.T) {
+	p := &ACRPlugin{}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "myapp:latest",
+			"tags":         []any{"1.0.0", "latest"},
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
 		},
-		{
-			name: "admin with credentials",
-			config: map[string]any{
-				"registry":     "myregistry",
-				"image":        "myapp",
-				"source_image": "myapp:latest",
-				"auth": map[string]any{
-					"method":   "admin",
-					"username": "admin",
-					"password": "password123",
-				},
-			},
-			wantErrors:  0,
-			description: "should pass with admin credentials",
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	steps, _ := resp.Outputs["steps"].([]Step)
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+	expected := []string{
+		"authenticating",
+		"tagging myregistry.azurecr.io/myapp:1.0.0",
+		"pushing myregistry.azurecr.io/myapp:1.0.0 1/2",
+		"tagging myregistry.azurecr.io/myapp:latest",
+		"pushing myregistry.azurecr.io/myapp:latest 2/2",
+		"done",
+	}
+	if strings.Join(names, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected steps %v, got %v", expected, names)
+	}
+	if steps[0].Status != stepSkipped {
+		t.Errorf("expected authentication to be skipped in dry run, got %q", steps[0].Status)
+	}
+}
+
+func TestACRPlugin_Execute_PromoteDryRun(t *testing.T) {
+	p := &ACRPlugin{}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"repository":   "prod",
+			"image":        "myapp",
+			"source_image": "staging/myapp:1.0.0",
+			"promote":      true,
+			"tags":         []any{"1.0.0"},
 		},
-		{
-			name: "managed_identity auth",
-			config: map[string]any{
-				"registry":     "myregistry",
-				"image":        "myapp",
-				"source_image": "myapp:latest",
-				"auth":         map[string]any{"method": "managed_identity"},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pushedImages, _ := resp.Outputs["pushed_images"].([]string)
+	if len(pushedImages) != 1 || pushedImages[0] != "myregistry.azurecr.io/prod/myapp:1.0.0" {
+		t.Errorf("unexpected pushed images %v", pushedImages)
+	}
+	if digest := resp.Outputs["promoted_digest"]; digest != "" {
+		t.Errorf("expected empty promoted_digest in dry run, got %v", digest)
+	}
+}
+
+func TestACRPlugin_Execute_PromoteCrossRegistry(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			if cmd.Name == "az" && slices.Contains(cmd.Args, "show") {
+				return []byte("sha256:abc\n"), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	req := plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":     "myregistry",
+			"image":        "myapp",
+			"source_image": "shared.azurecr.io/base/myapp:1.0.0",
+			"promote":      true,
+			"tags":         []any{"1.0.0"},
+			"source_registry": map[string]any{
+				"client_id":     "source-app",
+				"client_secret": "source-secret",
 			},
-			wantErrors:  0,
-			description: "should pass with managed identity auth",
 		},
-		{
-			name:        "empty config",
-			config:      map[string]any{},
-			wantErrors:  3,
-			description: "should fail with multiple errors for empty config",
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
 		},
 	}
 
-	p := &ACRPlugin{}
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, _ := resp.Outputs["imported_images"].([]string); !slices.Equal(got, []string{"myregistry.azurecr.io/myapp:1.0.0"}) {
+		t.Errorf("unexpected imported images %v", got)
+	}
+	imported := false
+	for _, cmd := range runner.commands {
+		if cmd.Name == "az" && slices.Contains(cmd.Args, "import") {
+			imported = true
+			if !slices.Contains(cmd.Args, "source-app") || !slices.Contains(cmd.Args, "source-secret") {
+				t.Errorf("expected source credentials on the import, got %v", cmd.Args)
+			}
+		}
+	}
+	if !imported {
+		t.Error("expected az acr import to run")
+	}
+}
+
+func TestACRPlugin_Execute_AutoServerSide(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		serverSide bool
+	}{
+		{name: "same registry", source: "MyRegistry.azurecr.io/staging/myapp:1.0.0", serverSide: true},
+		{name: "other registry", source: "shared.azurecr.io/staging/myapp:1.0.0", serverSide: false},
+		{name: "local image", source: "myapp:1.0.0", serverSide: false},
+		{name: "untagged", source: "myregistry.azurecr.io/staging/myapp", serverSide: false},
+	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resp, err := p.Validate(context.Background(), tt.config)
+			runner := &fakeRunner{
+				respond: func(cmd Command) ([]byte, error) {
+					if cmd.Name == "docker" && cmd.Args[0] == "image" {
+						return []byte(presentSourceInspect), nil
+					}
+					return nil, nil
+				},
+			}
+			p := &ACRPlugin{runner: runner}
+
+			req := plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"registry":         "myregistry",
+					"image":            "myapp",
+					"source_image":     tt.source,
+					"auto_server_side": true,
+					"tags":             []any{"1.0.0"},
+				},
+				Context: plugin.ReleaseContext{
+					Version: "1.0.0",
+				},
+			}
+
+			resp, err := p.Execute(context.Background(), req)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
 
-			if len(resp.Errors) != tt.wantErrors {
-				t.Errorf("%s: expected %d errors, got %d: %v",
-					tt.description, tt.wantErrors, len(resp.Errors), resp.Errors)
+			if got := resp.Outputs["server_side"]; got != tt.serverSide {
+				t.Errorf("expected server_side %v, got %v", tt.serverSide, got)
+			}
+			imported, pushed := false, false
+			for _, cmd := range runner.commands {
+				imported = imported || (cmd.Name == "az" && slices.Contains(cmd.Args, "import"))
+				pushed = pushed || (cmd.Name == "docker" && cmd.Args[0] == "push")
+			}
+			if imported != tt.serverSide || pushed == tt.serverSide {
+				t.Errorf("expected import %v and push %v, got import %v and push %v", tt.serverSide, !tt.serverSide, imported, pushed)
 			}
 		})
 	}
 }
 
-func TestACRPlugin_Execute_DryRun(t *testing.T) {
+func TestACRPlugin_Execute_Disabled(t *testing.T) {
 	p := &ACRPlugin{}
 
 	req := plugin.ExecuteRequest{
-		Hook:   plugin.HookPostPublish,
-		DryRun: true,
+		Hook: plugin.HookPostPublish,
 		Config: map[string]any{
+			"enabled":      false,
 			"registry":     "myregistry",
 			"image":        "myapp",
 			"source_image": "myapp:latest",
-			"tags":         []any{"1.0.0", "latest"},
 		},
 		Context: plugin.ReleaseContext{
 			Version: "1.0.0",
@@ -184,16 +3120,117 @@ func TestACRPlugin_Execute_DryRun(t *testing.T) {
 	}
 
 	if !resp.Success {
-		t.Error("expected success in dry run")
+		t.Error("expected success when disabled")
+	}
+
+	if !strings.Contains(resp.Message, "disabled") {
+		t.Errorf("expected disabled message, got %q", resp.Message)
 	}
 
 	pushedImages, ok := resp.Outputs["pushed_images"].([]string)
-	if !ok {
-		t.Fatal("expected pushed_images in outputs")
+	if !ok || len(pushedImages) != 0 {
+		t.Errorf("expected empty pushed_images, got %v", resp.Outputs["pushed_images"])
 	}
+}
 
-	if len(pushedImages) != 2 {
-		t.Errorf("expected 2 pushed images, got %d", len(pushedImages))
+func TestACRPlugin_Execute_MaxUploadRateWarns(t *testing.T) {
+	var buf bytes.Buffer
+	warnOutput = &buf
+	defer func() { warnOutput = os.Stderr }()
+
+	p := &ACRPlugin{}
+
+	req := plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"registry":        "myregistry",
+			"image":           "myapp",
+			"source_image":    "myapp:latest",
+			"max_upload_rate": 1048576,
+		},
+		Context: plugin.ReleaseContext{
+			Version: "1.0.0",
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "max_upload_rate") {
+		t.Errorf("expected max_upload_rate warning, got %q", buf.String())
+	}
+
+	if rate, _ := resp.Outputs["upload_rate"].(int); rate != 0 {
+		t.Errorf("expected effective upload rate 0, got %v", resp.Outputs["upload_rate"])
+	}
+}
+
+func TestACRPlugin_Execute_SourceDigestTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolve  func(ctx context.Context, image string) (string, error)
+		expected []string
+	}{
+		{
+			name: "digest resolved",
+			resolve: func(ctx context.Context, image string) (string, error) {
+				return "sha256:abcdef1234567890abcdef1234567890", nil
+			},
+			expected: []string{
+				"myregistry.azurecr.io/myapp:1.0.0",
+				"myregistry.azurecr.io/myapp:sha-abcdef123456",
+			},
+		},
+		{
+			name: "digest unresolved drops tag",
+			resolve: func(ctx context.Context, image string) (string, error) {
+				return "", errors.New("no such image")
+			},
+			expected: []string{
+				"myregistry.azurecr.io/myapp:1.0.0",
+			},
+		},
+	}
+
+	warnOutput = io.Discard
+	defer func() { warnOutput = os.Stderr }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ACRPlugin{resolveDigest: tt.resolve}
+
+			req := plugin.ExecuteRequest{
+				Hook:   plugin.HookPostPublish,
+				DryRun: true,
+				Config: map[string]any{
+					"registry":     "myregistry",
+					"image":        "myapp",
+					"source_image": "myapp:latest",
+					"tags":         []any{"{{.Version}}", "sha-{{.ShortSourceDigest}}"},
+				},
+				Context: plugin.ReleaseContext{
+					Version: "1.0.0",
+				},
+			}
+
+			resp, err := p.Execute(context.Background(), req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			pushedImages, _ := resp.Outputs["pushed_images"].([]string)
+			if len(pushedImages) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, pushedImages)
+			}
+			for i, image := range pushedImages {
+				if image != tt.expected[i] {
+					t.Errorf("image %d: expected %q, got %q", i, tt.expected[i], image)
+				}
+			}
+		})
 	}
 }
 
@@ -240,7 +3277,7 @@ func TestACRPlugin_ProcessTags(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := p.processTags(tt.tags, tt.ctx)
+			result := p.processTags(tt.tags, newTemplateData(tt.ctx))
 
 			if len(result) != len(tt.expected) {
 				t.Errorf("expected %d tags, got %d: %v", len(tt.expected), len(result), result)
@@ -349,6 +3386,39 @@ func TestACRPlugin_ParseConfig(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			name: "execute_timeout config",
+			raw: map[string]any{
+				"registry":        "myregistry",
+				"image":           "myapp",
+				"source_image":    "myapp:latest",
+				"execute_timeout": "90s",
+			},
+			check: func(c *Config) error {
+				if c.ExecuteTimeout != 90*time.Second {
+					return errorf("expected execute_timeout 90s, got %s", c.ExecuteTimeout)
+				}
+				return nil
+			},
+		},
+		{
+			name: "overwrite protection config",
+			raw: map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"no_overwrite": true,
+			},
+			check: func(c *Config) error {
+				if !c.NoOverwrite || c.Force {
+					return errorf("expected no_overwrite without force, got %v/%v", c.NoOverwrite, c.Force)
+				}
+				if len(c.FloatingTags) != 1 || c.FloatingTags[0] != "latest" {
+					return errorf("expected default floating tags [latest], got %v", c.FloatingTags)
+				}
+				return nil
+			},
+		},
 		{
 			name: "dry_run config",
 			raw: map[string]any{
@@ -361,6 +3431,94 @@ func TestACRPlugin_ParseConfig(t *testing.T) {
 				if !c.DryRun {
 					return errorf("expected dry_run to be true")
 				}
+				if c.DryRunMode != "full" {
+					return errorf("expected default dry_run_mode 'full', got %q", c.DryRunMode)
+				}
+				return nil
+			},
+		},
+		{
+			name: "comma-separated tags",
+			raw: map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"tags":         " 1.2.3 ,latest,, {{.Branch}} ",
+			},
+			check: func(c *Config) error {
+				expected := []string{"1.2.3", "latest", "{{.Branch}}"}
+				if len(c.Tags) != len(expected) {
+					return errorf("expected tags %v, got %v", expected, c.Tags)
+				}
+				for i := range expected {
+					if c.Tags[i] != expected[i] {
+						return errorf("expected tags %v, got %v", expected, c.Tags)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			name: "empty comma-separated tags",
+			raw: map[string]any{
+				"registry":     "myregistry",
+				"image":        "myapp",
+				"source_image": "myapp:latest",
+				"tags":         " , ",
+			},
+			check: func(c *Config) error {
+				if len(c.Tags) != 1 || c.Tags[0] != "{{.Version}}" {
+					return errorf("expected default tag [{{.Version}}], got %v", c.Tags)
+				}
+				return nil
+			},
+		},
+		{
+			name: "disable_default_tag",
+			raw: map[string]any{
+				"registry":            "myregistry",
+				"image":               "myapp",
+				"source_image":        "myapp:latest",
+				"disable_default_tag": true,
+			},
+			check: func(c *Config) error {
+				if len(c.Tags) != 0 {
+					return errorf("expected no tags, got %v", c.Tags)
+				}
+				if !c.RequireTags {
+					return errorf("expected require_tags to default to true")
+				}
+				return nil
+			},
+		},
+		{
+			name: "disable_default_tag keeps explicit tags",
+			raw: map[string]any{
+				"registry":            "myregistry",
+				"image":               "myapp",
+				"source_image":        "myapp:latest",
+				"tags":                []any{"latest-stable"},
+				"disable_default_tag": true,
+			},
+			check: func(c *Config) error {
+				if len(c.Tags) != 1 || c.Tags[0] != "latest-stable" {
+					return errorf("expected [latest-stable], got %v", c.Tags)
+				}
+				return nil
+			},
+		},
+		{
+			name: "cleanup_local_tags config",
+			raw: map[string]any{
+				"registry":           "myregistry",
+				"image":              "myapp",
+				"source_image":       "myapp:latest",
+				"cleanup_local_tags": true,
+			},
+			check: func(c *Config) error {
+				if !c.CleanupLocalTags {
+					return errorf("expected cleanup_local_tags to be true")
+				}
 				return nil
 			},
 		},
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// loginServer normalizes a registry name or login server to its lowercase
+// login server, so "MyRegistry" and "myregistry.azurecr.io" compare equal.
+func loginServer(registry string) string {
+	registry = strings.ToLower(strings.TrimSpace(registry))
+	if registry == "" || strings.HasSuffix(registry, ".azurecr.io") {
+		return registry
+	}
+	return registry + ".azurecr.io"
+}
+
+// checkAllowedRegistry fails when allowed is non-empty and does not contain the
+// login server of registry.
+func checkAllowedRegistry(registry string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	target := loginServer(registry)
+	servers := make([]string, 0, len(allowed))
+	for _, entry := range allowed {
+		server := loginServer(entry)
+		if server == target {
+			return nil
+		}
+		servers = append(servers, server)
+	}
+	return fmt.Errorf("registry %s is not allowed; allowed registries: %s", target, strings.Join(servers, ", "))
+}
+
+// checkRegistryPolicy checks registry against each non-empty allow list, so
+// a registry must be in all of them.
+func checkRegistryPolicy(registry string, lists ...[]string) error {
+	for _, allowed := range lists {
+		if err := checkAllowedRegistry(registry, allowed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultMutableTags are the tags require_immutable_tag treats as floating
+// unless mutable_tags is configured.
+var defaultMutableTags = []string{"latest", "edge", "stable", "main"}
+
+// mutableTags returns the tags require_immutable_tag treats as floating:
+// mutable plus every floating tag, since a tag no_overwrite lets move between
+// releases is floating too.
+func mutableTags(mutable, floating []string) []string {
+	tags := slices.Clone(mutable)
+	for _, tag := range floating {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// checkImmutableTag fails when tags is non-empty and every tag is in mutable,
+// naming the tags that were considered floating.
+func checkImmutableTag(tags, mutable []string) error {
+	for _, tag := range tags {
+		if !isFloatingTag(tag, mutable) {
+			return nil
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return fmt.Errorf("require_immutable_tag: every tag is floating (%s); add a version-specific tag", strings.Join(tags, ", "))
+}
+
+// BranchRule limits pushes to repositories matching Repository to releases
+// whose branch matches one of Branches. A "tag:" prefixed pattern matches the
+// release tag instead of the branch.
+type BranchRule struct {
+	Repository string
+	Branches   []string
+}
+
+// parseBranchPolicy reads branch_policy, a map of repository patterns to one
+// or more branch patterns. Rules are sorted by repository pattern.
+func parseBranchPolicy(raw map[string]any) []BranchRule {
+	rules := make([]BranchRule, 0, len(raw))
+	for repository, value := range raw {
+		rule := BranchRule{Repository: repository}
+		switch v := value.(type) {
+		case string:
+			rule.Branches = splitList(v)
+		case []any:
+			for _, item := range v {
+				if branch := strings.TrimSpace(fmt.Sprint(item)); branch != "" {
+					rule.Branches = append(rule.Branches, branch)
+				}
+			}
+		case []string:
+			rule.Branches = v
+		}
+		rules = append(rules, rule)
+	}
+	slices.SortFunc(rules, func(a, b BranchRule) int { return strings.Compare(a.Repository, b.Repository) })
+	return rules
+}
+
+// validateBranchPolicy checks that every rule has valid patterns and allows at
+// least one branch.
+func validateBranchPolicy(rules []BranchRule) error {
+	for _, rule := range rules {
+		if _, err := path.Match(rule.Repository, ""); err != nil {
+			return fmt.Errorf("invalid repository pattern %q: %w", rule.Repository, err)
+		}
+		if len(rule.Branches) == 0 {
+			return fmt.Errorf("repository pattern %q allows no branches", rule.Repository)
+		}
+		for _, branch := range rule.Branches {
+			if _, err := path.Match(strings.TrimPrefix(branch, "tag:"), ""); err != nil {
+				return fmt.Errorf("invalid branch pattern %q: %w", branch, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchRepository reports whether pattern matches repository or one of its
+// parent paths, so "prod" and "prod/*" both cover "prod/team/app".
+func matchRepository(pattern, repository string) bool {
+	parts := strings.Split(repository, "/")
+	for i := len(parts); i > 0; i-- {
+		if ok, _ := path.Match(pattern, strings.Join(parts[:i], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBranchPolicy fails when repository matches a rule whose patterns match
+// neither branch nor, for "tag:" patterns, tagName. Every matching rule must
+// allow the release.
+func checkBranchPolicy(rules []BranchRule, repository, branch, tagName string) error {
+	for _, rule := range rules {
+		if !matchRepository(rule.Repository, repository) {
+			continue
+		}
+		allowed := false
+		for _, pattern := range rule.Branches {
+			value := branch
+			if tagPattern, ok := strings.CutPrefix(pattern, "tag:"); ok {
+				pattern, value = tagPattern, tagName
+			}
+			if ok, _ := path.Match(pattern, value); ok && value != "" {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("branch_policy: repository %s may not be pushed from branch %q (tag %q); allowed: %s",
+				repository, branch, tagName, strings.Join(rule.Branches, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCheckAllowedRegistry(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry string
+		allowed  []string
+		wantErr  bool
+	}{
+		{name: "no policy", registry: "anything"},
+		{name: "allowed", registry: "prodregistry", allowed: []string{"devregistry", "prodregistry"}},
+		{name: "disallowed", registry: "otherregistry", allowed: []string{"prodregistry"}, wantErr: true},
+		{name: "suffix on registry", registry: "prodregistry.azurecr.io", allowed: []string{"prodregistry"}},
+		{name: "suffix on policy", registry: "prodregistry", allowed: []string{"prodregistry.azurecr.io"}},
+		{name: "case and whitespace", registry: "ProdRegistry", allowed: []string{" prodregistry.AzureCR.io "}},
+		{name: "suffix is not a prefix match", registry: "prodregistry2", allowed: []string{"prodregistry"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAllowedRegistry(tt.registry, tt.allowed)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckAllowedRegistry_Message(t *testing.T) {
+	err := checkAllowedRegistry("otherregistry", []string{"prodregistry", "devregistry.azurecr.io"})
+	expected := "registry otherregistry.azurecr.io is not allowed; allowed registries: prodregistry.azurecr.io, devregistry.azurecr.io"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestCheckRegistryPolicy(t *testing.T) {
+	env := []string{"prodregistry", "devregistry"}
+	if err := checkRegistryPolicy("prodregistry", env, []string{"prodregistry"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := checkRegistryPolicy("devregistry", env, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := checkRegistryPolicy("otherregistry", env, []string{"otherregistry"}); err == nil {
+		t.Error("expected the environment policy to reject a registry the config allows")
+	}
+	if err := checkRegistryPolicy("devregistry", env, []string{"prodregistry"}); err == nil {
+		t.Error("expected the config to narrow the environment policy")
+	}
+}
+
+func TestCheckImmutableTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		mutable []string
+		wantErr bool
+	}{
+		{name: "no tags", mutable: defaultMutableTags},
+		{name: "version tag", tags: []string{"1.2.3", "latest"}, mutable: defaultMutableTags},
+		{name: "latest only", tags: []string{"latest"}, mutable: defaultMutableTags, wantErr: true},
+		{name: "all floating", tags: []string{"latest", "edge", "main"}, mutable: defaultMutableTags, wantErr: true},
+		{name: "custom set", tags: []string{"nightly"}, mutable: []string{"nightly"}, wantErr: true},
+		{name: "custom set excludes latest", tags: []string{"latest"}, mutable: []string{"nightly"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkImmutableTag(tt.tags, tt.mutable)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckImmutableTag_Message(t *testing.T) {
+	err := checkImmutableTag([]string{"latest", "edge"}, defaultMutableTags)
+	expected := "require_immutable_tag: every tag is floating (latest, edge); add a version-specific tag"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestCheckBranchPolicy(t *testing.T) {
+	rules := parseBranchPolicy(map[string]any{
+		"prod/*":  []any{"main", "tag:v*"},
+		"staging": "main, release/*",
+	})
+
+	tests := []struct {
+		name       string
+		repository string
+		branch     string
+		tagName    string
+		wantErr    bool
+	}{
+		{name: "unmatched repository", repository: "dev/app", branch: "feature/x"},
+		{name: "prod from main", repository: "prod/app", branch: "main"},
+		{name: "prod from release tag", repository: "prod/app", tagName: "v1.2.0"},
+		{name: "prod from feature branch", repository: "prod/app", branch: "feature/x", tagName: "release-1", wantErr: true},
+		{name: "prod without branch or tag", repository: "prod/app", wantErr: true},
+		{name: "nested prod path", repository: "prod/team/app", branch: "main"},
+		{name: "nested prod path denied", repository: "prod/team/app", branch: "develop", wantErr: true},
+		{name: "prefix is not a path match", repository: "production/app", branch: "develop"},
+		{name: "staging from release branch", repository: "staging/app", branch: "release/1.2"},
+		{name: "staging from main", repository: "staging", branch: "main"},
+		{name: "staging from feature branch", repository: "staging/app", branch: "feature/x", wantErr: true},
+		{name: "branch pattern does not match tag", repository: "staging/app", tagName: "main", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBranchPolicy(rules, tt.repository, tt.branch, tt.tagName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	if err := checkBranchPolicy(nil, "prod/app", "feature/x", ""); err != nil {
+		t.Errorf("expected no policy to allow every branch, got %v", err)
+	}
+}
+
+func TestCheckBranchPolicy_Message(t *testing.T) {
+	rules := parseBranchPolicy(map[string]any{"prod/*": []any{"main", "tag:v*"}})
+	err := checkBranchPolicy(rules, "prod/app", "feature/x", "")
+	expected := `branch_policy: repository prod/app may not be pushed from branch "feature/x" (tag ""); allowed: main, tag:v*`
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestParseBranchPolicy(t *testing.T) {
+	rules := parseBranchPolicy(map[string]any{
+		"prod/*":  []any{"main", " tag:v* "},
+		"staging": "main,release/*",
+		"qa":      []string{"develop"},
+	})
+	expected := []BranchRule{
+		{Repository: "prod/*", Branches: []string{"main", "tag:v*"}},
+		{Repository: "qa", Branches: []string{"develop"}},
+		{Repository: "staging", Branches: []string{"main", "release/*"}},
+	}
+	if !slices.EqualFunc(rules, expected, func(a, b BranchRule) bool {
+		return a.Repository == b.Repository && slices.Equal(a.Branches, b.Branches)
+	}) {
+		t.Errorf("expected %+v, got %+v", expected, rules)
+	}
+}
+
+func TestValidateBranchPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []BranchRule
+		wantErr bool
+	}{
+		{name: "empty"},
+		{name: "valid", rules: []BranchRule{{Repository: "prod/*", Branches: []string{"main", "tag:v*"}}}},
+		{name: "no branches", rules: []BranchRule{{Repository: "prod/*"}}, wantErr: true},
+		{name: "bad repository pattern", rules: []BranchRule{{Repository: "prod/[", Branches: []string{"main"}}}, wantErr: true},
+		{name: "bad branch pattern", rules: []BranchRule{{Repository: "prod", Branches: []string{"tag:v["}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBranchPolicy(tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestMutableTags(t *testing.T) {
+	got := mutableTags([]string{"nightly", "latest"}, []string{"latest", "stable"})
+	if !slices.Equal(got, []string{"nightly", "latest", "stable"}) {
+		t.Errorf("expected floating tags to be added once, got %v", got)
+	}
+}
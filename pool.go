@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// pushUnit is one image reference in the push matrix.
+type pushUnit struct {
+	Target ImageTarget
+	Tag    string
+
+	// Index is the unit's position in the matrix, used for progress reporting.
+	Index int
+}
+
+// pushUnits flattens targets × tags into push units, skipping empty tags.
+func pushUnits(targets []ImageTarget, tags []string) []pushUnit {
+	units := []pushUnit{}
+	for _, target := range targets {
+		for _, tag := range tags {
+			if tag == "" {
+				continue
+			}
+			units = append(units, pushUnit{Target: target, Tag: tag, Index: len(units)})
+		}
+	}
+	return units
+}
+
+// matrixOrder maps the reference of each unit to its position in the matrix.
+func matrixOrder(registryURL string, units []pushUnit) map[string]int {
+	order := make(map[string]int, len(units))
+	for _, unit := range units {
+		order[fmt.Sprintf("%s/%s:%s", registryURL, unit.Target.Path(), unit.Tag)] = unit.Index
+	}
+	return order
+}
+
+// sortByMatrix orders items by the matrix position of their reference, so
+// results recorded in completion order follow the configured tag order.
+// References outside the matrix keep their relative order at the end.
+func sortByMatrix[T any](items []T, order map[string]int, ref func(T) string) {
+	position := func(item T) int {
+		if i, ok := order[ref(item)]; ok {
+			return i
+		}
+		return len(order)
+	}
+	slices.SortStableFunc(items, func(a, b T) int { return position(a) - position(b) })
+}
+
+// splitFirstTags separates the first unit of each target from the rest,
+// preserving order within both.
+func splitFirstTags(units []pushUnit) (first, rest []pushUnit) {
+	seen := map[string]bool{}
+	for _, unit := range units {
+		if path := unit.Target.Path(); !seen[path] {
+			seen[path] = true
+			first = append(first, unit)
+		} else {
+			rest = append(rest, unit)
+		}
+	}
+	return first, rest
+}
+
+// runPushUnits runs fn for every unit on at most maxParallel workers. The first
+// failure cancels the context handed to the remaining units and is returned.
+func runPushUnits(ctx context.Context, units []pushUnit, maxParallel int, fn func(context.Context, pushUnit) error) error {
+	if maxParallel < 1 {
+		maxParallel = 1
+	}
+	if maxParallel > len(units) {
+		maxParallel = len(units)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		once     sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	queue := make(chan pushUnit)
+	for i := 0; i < maxParallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for unit := range queue {
+				// Drain the queue without running anything once cancelled
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := fn(ctx, unit); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, unit := range units {
+		select {
+		case queue <- unit:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPushUnits(t *testing.T) {
+	targets := []ImageTarget{{Repository: "app"}, {Repository: "app", Image: "worker"}}
+	units := pushUnits(targets, []string{"1.0.0", "", "latest"})
+
+	expected := []string{"app:1.0.0", "app:latest", "app/worker:1.0.0", "app/worker:latest"}
+	if len(units) != len(expected) {
+		t.Fatalf("expected %d units, got %d", len(expected), len(units))
+	}
+	for i, unit := range units {
+		if got := unit.Target.Path() + ":" + unit.Tag; got != expected[i] {
+			t.Errorf("unit %d: expected %s, got %s", i, expected[i], got)
+		}
+		if unit.Index != i {
+			t.Errorf("unit %d: expected index %d, got %d", i, i, unit.Index)
+		}
+	}
+}
+
+func TestSplitFirstTags(t *testing.T) {
+	targets := []ImageTarget{{Repository: "app"}, {Repository: "app", Image: "worker"}}
+	first, rest := splitFirstTags(pushUnits(targets, []string{"1.0.0", "latest", "stable"}))
+
+	paths := func(units []pushUnit) []string {
+		out := []string{}
+		for _, unit := range units {
+			out = append(out, unit.Target.Path()+":"+unit.Tag)
+		}
+		return out
+	}
+	if got := paths(first); !slices.Equal(got, []string{"app:1.0.0", "app/worker:1.0.0"}) {
+		t.Errorf("unexpected first units %v", got)
+	}
+	if got := paths(rest); !slices.Equal(got, []string{"app:latest", "app:stable", "app/worker:latest", "app/worker:stable"}) {
+		t.Errorf("unexpected remaining units %v", got)
+	}
+}
+
+func TestSortByMatrix(t *testing.T) {
+	targets := []ImageTarget{{Repository: "app"}, {Repository: "app", Image: "worker"}}
+	order := matrixOrder("r.azurecr.io", pushUnits(targets, []string{"1.0.0", "latest"}))
+
+	refs := []string{
+		"r.azurecr.io/app/worker:latest",
+		"r.azurecr.io/other:1.0.0",
+		"r.azurecr.io/app:latest",
+		"r.azurecr.io/app/worker:1.0.0",
+		"r.azurecr.io/app:1.0.0",
+	}
+	sortByMatrix(refs, order, func(ref string) string { return ref })
+
+	expected := []string{
+		"r.azurecr.io/app:1.0.0",
+		"r.azurecr.io/app:latest",
+		"r.azurecr.io/app/worker:1.0.0",
+		"r.azurecr.io/app/worker:latest",
+		"r.azurecr.io/other:1.0.0",
+	}
+	if !slices.Equal(refs, expected) {
+		t.Errorf("expected %v, got %v", expected, refs)
+	}
+}
+
+func TestRunPushUnits(t *testing.T) {
+	units := pushUnits([]ImageTarget{{Repository: "app"}}, []string{"a", "b", "c", "d", "e", "f"})
+
+	t.Run("bounds concurrency", func(t *testing.T) {
+		var inFlight, peak int32
+		var mu sync.Mutex
+		seen := map[string]bool{}
+		err := runPushUnits(context.Background(), units, 2, func(ctx context.Context, u pushUnit) error {
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			seen[u.Tag] = true
+			mu.Unlock()
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if peak > 2 {
+			t.Errorf("expected at most 2 pushes in flight, saw %d", peak)
+		}
+		if len(seen) != len(units) {
+			t.Errorf("expected %d units to run, got %d", len(units), len(seen))
+		}
+	})
+
+	t.Run("first failure cancels the rest", func(t *testing.T) {
+		failure := errors.New("push failed")
+		var ran int32
+		err := runPushUnits(context.Background(), units, 1, func(ctx context.Context, u pushUnit) error {
+			atomic.AddInt32(&ran, 1)
+			if u.Tag == "b" {
+				return failure
+			}
+			return nil
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("expected push failure, got %v", err)
+		}
+		if ran != 2 {
+			t.Errorf("expected sequential run to stop after the failure, ran %d units", ran)
+		}
+	})
+
+	t.Run("parent cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := runPushUnits(ctx, units, 3, func(ctx context.Context, u pushUnit) error {
+			return ctx.Err()
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validatePromotionSource checks that a promote source is a tagged or
+// digest-pinned image in an Azure Container Registry.
+func validatePromotionSource(source string) error {
+	ref, err := parseImageReference(source)
+	if err != nil {
+		return err
+	}
+	if ref.Domain != "" && !strings.HasSuffix(ref.Domain, ".azurecr.io") {
+		return fmt.Errorf("promote source %q must be in an Azure Container Registry", source)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		return fmt.Errorf("promote source %q must include a tag or digest", source)
+	}
+	return nil
+}
+
+// isCrossRegistrySource reports whether source names a registry other than
+// registry.
+func isCrossRegistrySource(source, registry string) bool {
+	ref, err := parseImageReference(source)
+	return err == nil && ref.Domain != "" && loginServer(ref.Domain) != loginServer(registry)
+}
+
+// isSameRegistrySource reports whether source is qualified with the login
+// server of registry, so tagging it needs no pull or push.
+func isSameRegistrySource(source, registry string) bool {
+	ref, err := parseImageReference(source)
+	return err == nil && ref.Domain != "" && loginServer(ref.Domain) == loginServer(registry)
+}
+
+// applyAutoServerSide switches cfg to promote when auto_server_side is set and
+// source_image, as normalize_source would leave it, is a tagged or
+// digest-pinned image in the target registry. Validate and Execute both call
+// it, so the mode that is validated is the mode that runs.
+func (cfg *Config) applyAutoServerSide() bool {
+	if !cfg.AutoServerSide || cfg.Promote || cfg.SourceStdin {
+		return false
+	}
+	source := cfg.SourceImage
+	if cfg.NormalizeSource {
+		if normalized, err := normalizeSource(source, cfg.PullSource); err == nil {
+			source = normalized
+		}
+	}
+	if !isSameRegistrySource(source, cfg.Registry) || validatePromotionSource(source) != nil {
+		return false
+	}
+	cfg.Promote, cfg.PullSource = true, false
+	return true
+}
+
+// validateSourceCredentials checks source_registry: it only applies to a
+// promote source in another registry and needs both a username and password.
+func validateSourceCredentials(creds SourceCredentials, source, registry string, promote bool) error {
+	if creds == (SourceCredentials{}) {
+		return nil
+	}
+	if !promote || !isCrossRegistrySource(source, registry) {
+		return fmt.Errorf("source_registry only applies when promote imports source_image from another registry")
+	}
+	if creds.Username == "" || creds.Password == "" {
+		return fmt.Errorf("source_registry requires username and password, or client_id and client_secret")
+	}
+	return nil
+}
+
+// promotionSource qualifies a promote source with the target registry when it
+// names none, and returns the reference relative to its own registry.
+func promotionSource(source, registryURL string) (qualified, relative string) {
+	ref, err := parseImageReference(source)
+	if err != nil {
+		return source, source
+	}
+
+	relative = ref.Path
+	if ref.Digest != "" {
+		relative += "@" + ref.Digest
+	} else {
+		relative += ":" + ref.Tag
+	}
+
+	domain := ref.Domain
+	if domain == "" {
+		domain = registryURL
+	}
+	return domain + "/" + relative, relative
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePromotionSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr bool
+	}{
+		{name: "same registry tag", source: "staging/app:1.0.0", wantErr: false},
+		{name: "qualified acr reference", source: "myregistry.azurecr.io/staging/app:1.0.0", wantErr: false},
+		{name: "digest", source: "staging/app@sha256:" + strings.Repeat("a", 64), wantErr: false},
+		{name: "other registry", source: "ghcr.io/org/app:1.0.0", wantErr: true},
+		{name: "untagged", source: "staging/app", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePromotionSource(tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePromotionSource(%q) error = %v, wantErr %v", tt.source, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPromotionSource(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	tests := []struct {
+		name              string
+		source            string
+		expectedQualified string
+		expectedRelative  string
+	}{
+		{
+			name:              "unqualified tag",
+			source:            "staging/app:1.0.0",
+			expectedQualified: "myregistry.azurecr.io/staging/app:1.0.0",
+			expectedRelative:  "staging/app:1.0.0",
+		},
+		{
+			name:              "other acr",
+			source:            "shared.azurecr.io/base/app:2.0",
+			expectedQualified: "shared.azurecr.io/base/app:2.0",
+			expectedRelative:  "base/app:2.0",
+		},
+		{
+			name:              "digest wins over tag",
+			source:            "staging/app:1.0.0@" + digest,
+			expectedQualified: "myregistry.azurecr.io/staging/app@" + digest,
+			expectedRelative:  "staging/app@" + digest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qualified, relative := promotionSource(tt.source, "myregistry.azurecr.io")
+			if qualified != tt.expectedQualified {
+				t.Errorf("expected qualified %q, got %q", tt.expectedQualified, qualified)
+			}
+			if relative != tt.expectedRelative {
+				t.Errorf("expected relative %q, got %q", tt.expectedRelative, relative)
+			}
+		})
+	}
+}
+
+func TestValidateSourceCredentials(t *testing.T) {
+	creds := SourceCredentials{Username: "puller", Password: "secret"}
+
+	tests := []struct {
+		name    string
+		creds   SourceCredentials
+		source  string
+		promote bool
+		wantErr bool
+	}{
+		{name: "none", source: "staging/app:1.0.0", promote: true},
+		{name: "cross-registry promote", creds: creds, source: "shared.azurecr.io/base/app:1.0", promote: true},
+		{name: "same registry", creds: creds, source: "myregistry.azurecr.io/staging/app:1.0.0", promote: true, wantErr: true},
+		{name: "unqualified source", creds: creds, source: "staging/app:1.0.0", promote: true, wantErr: true},
+		{name: "without promote", creds: creds, source: "shared.azurecr.io/base/app:1.0", wantErr: true},
+		{name: "missing password", creds: SourceCredentials{Username: "puller"}, source: "shared.azurecr.io/base/app:1.0", promote: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSourceCredentials(tt.creds, tt.source, "myregistry", tt.promote)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsSameRegistrySource(t *testing.T) {
+	tests := []struct {
+		source   string
+		registry string
+		expected bool
+	}{
+		{"myregistry.azurecr.io/app:1.0", "myregistry", true},
+		{"MyRegistry.azurecr.io/app:1.0", "myregistry.azurecr.io", true},
+		{"other.azurecr.io/app:1.0", "myregistry", false},
+		{"app:1.0", "myregistry", false},
+		{"docker.io/library/app:1.0", "myregistry", false},
+	}
+
+	for _, tt := range tests {
+		if got := isSameRegistrySource(tt.source, tt.registry); got != tt.expected {
+			t.Errorf("isSameRegistrySource(%q, %q) = %v, want %v", tt.source, tt.registry, got, tt.expected)
+		}
+	}
+}
+
+func TestConfig_ApplyAutoServerSide(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        Config
+		serverSide bool
+	}{
+		{name: "same registry", cfg: Config{AutoServerSide: true, Registry: "myregistry", SourceImage: "myregistry.azurecr.io/app:1.0.0", PullSource: true}, serverSide: true},
+		{name: "disabled", cfg: Config{Registry: "myregistry", SourceImage: "myregistry.azurecr.io/app:1.0.0"}},
+		{name: "other registry", cfg: Config{AutoServerSide: true, Registry: "myregistry", SourceImage: "shared.azurecr.io/app:1.0.0"}},
+		{name: "untagged", cfg: Config{AutoServerSide: true, Registry: "myregistry", SourceImage: "myregistry.azurecr.io/app"}},
+		{name: "untagged but normalized", cfg: Config{AutoServerSide: true, NormalizeSource: true, Registry: "myregistry", SourceImage: "myregistry.azurecr.io/app"}, serverSide: true},
+		{name: "streamed", cfg: Config{AutoServerSide: true, SourceStdin: true, Registry: "myregistry", SourceImage: "myregistry.azurecr.io/app:1.0.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if got := cfg.applyAutoServerSide(); got != tt.serverSide {
+				t.Errorf("expected %v, got %v", tt.serverSide, got)
+			}
+			if cfg.Promote != tt.serverSide || (tt.serverSide && cfg.PullSource) {
+				t.Errorf("unexpected mode promote=%v pull_source=%v", cfg.Promote, cfg.PullSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// slsaProvenancePredicateType is the SLSA v1 provenance predicate type.
+const slsaProvenancePredicateType = "https://slsa.dev/provenance/v1"
+
+// defaultProvenanceBuildType describes a release pushed by this plugin.
+const defaultProvenanceBuildType = "https://github.com/relicta-tech/plugin-acr/buildtypes/push@v1"
+
+// ProvenanceConfig configures the SLSA provenance attestation attached to each pushed image.
+type ProvenanceConfig struct {
+	Enabled   bool
+	BuilderID string
+	BuildType string
+
+	// Key is a cosign key reference; empty signs keylessly.
+	Key string
+}
+
+// inTotoStatement is an in-toto v1 statement.
+type inTotoStatement struct {
+	Type          string          `json:"_type"`
+	Subject       []inTotoSubject `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     slsaProvenance  `json:"predicate"`
+}
+
+// inTotoSubject is an artifact an in-toto statement is about.
+type inTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// slsaProvenance is the SLSA v1 provenance predicate.
+type slsaProvenance struct {
+	BuildDefinition slsaBuildDefinition `json:"buildDefinition"`
+	RunDetails      slsaRunDetails      `json:"runDetails"`
+}
+
+// slsaBuildDefinition describes the inputs of the build.
+type slsaBuildDefinition struct {
+	BuildType            string                   `json:"buildType"`
+	ExternalParameters   map[string]string        `json:"externalParameters"`
+	ResolvedDependencies []slsaResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+// slsaResourceDescriptor identifies a build material.
+type slsaResourceDescriptor struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// slsaRunDetails describes the builder and the run.
+type slsaRunDetails struct {
+	Builder  slsaBuilder  `json:"builder"`
+	Metadata slsaMetadata `json:"metadata"`
+}
+
+// slsaBuilder identifies the builder.
+type slsaBuilder struct {
+	ID string `json:"id"`
+}
+
+// slsaMetadata holds run metadata.
+type slsaMetadata struct {
+	InvocationID string    `json:"invocationId,omitempty"`
+	StartedOn    time.Time `json:"startedOn"`
+}
+
+// buildProvenance builds the provenance statement for a pushed image from the
+// release context. invocationID identifies the CI run and may be empty.
+func buildProvenance(cfg ProvenanceConfig, subject, digest string, release *plugin.ReleaseContext, invocationID string, startedOn time.Time) inTotoStatement {
+	buildType := cfg.BuildType
+	if buildType == "" {
+		buildType = defaultProvenanceBuildType
+	}
+
+	predicate := slsaProvenance{
+		BuildDefinition: slsaBuildDefinition{
+			BuildType: buildType,
+			ExternalParameters: map[string]string{
+				"repository": release.RepositoryURL,
+				"version":    release.Version,
+				"tag":        release.TagName,
+				"branch":     release.Branch,
+			},
+		},
+		RunDetails: slsaRunDetails{
+			Builder:  slsaBuilder{ID: cfg.BuilderID},
+			Metadata: slsaMetadata{InvocationID: invocationID, StartedOn: startedOn.UTC()},
+		},
+	}
+	if release.RepositoryURL != "" && release.CommitSHA != "" {
+		predicate.BuildDefinition.ResolvedDependencies = []slsaResourceDescriptor{{
+			URI:    "git+" + release.RepositoryURL,
+			Digest: map[string]string{"gitCommit": release.CommitSHA},
+		}}
+	}
+
+	return inTotoStatement{
+		Type:          "https://in-toto.io/Statement/v1",
+		Subject:       []inTotoSubject{{Name: subject, Digest: map[string]string{"sha256": digestHex(digest)}}},
+		PredicateType: slsaProvenancePredicateType,
+		Predicate:     predicate,
+	}
+}
+
+// marshalPredicate encodes the predicate for cosign, which wraps it in its own statement.
+func (s inTotoStatement) marshalPredicate() ([]byte, error) {
+	data, err := json.Marshal(s.Predicate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode provenance: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestBuildProvenance(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	release := &plugin.ReleaseContext{
+		Version:       "1.2.0",
+		TagName:       "v1.2.0",
+		Branch:        "main",
+		RepositoryURL: "https://github.com/myorg/app",
+		CommitSHA:     "0123456789abcdef",
+	}
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	cfg := ProvenanceConfig{BuilderID: "https://github.com/myorg/app/.github/workflows/release.yml"}
+
+	statement := buildProvenance(cfg, "myregistry.azurecr.io/app", digest, release, "42", started)
+
+	if statement.PredicateType != slsaProvenancePredicateType {
+		t.Errorf("unexpected predicate type %q", statement.PredicateType)
+	}
+	if len(statement.Subject) != 1 || statement.Subject[0].Digest["sha256"] != strings.Repeat("a", 64) {
+		t.Errorf("unexpected subject %+v", statement.Subject)
+	}
+	if statement.Predicate.BuildDefinition.BuildType != defaultProvenanceBuildType {
+		t.Errorf("expected default build type, got %q", statement.Predicate.BuildDefinition.BuildType)
+	}
+	deps := statement.Predicate.BuildDefinition.ResolvedDependencies
+	if len(deps) != 1 || deps[0].URI != "git+https://github.com/myorg/app" || deps[0].Digest["gitCommit"] != "0123456789abcdef" {
+		t.Errorf("unexpected materials %+v", deps)
+	}
+	if statement.Predicate.RunDetails.Builder.ID != cfg.BuilderID {
+		t.Errorf("unexpected builder %q", statement.Predicate.RunDetails.Builder.ID)
+	}
+
+	predicate, err := statement.marshalPredicate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(predicate, &decoded); err != nil {
+		t.Fatalf("predicate is not JSON: %v", err)
+	}
+	if _, ok := decoded["buildDefinition"]; !ok {
+		t.Errorf("expected the predicate alone, got %s", predicate)
+	}
+}
+
+func TestBuildProvenance_NoCommit(t *testing.T) {
+	statement := buildProvenance(ProvenanceConfig{BuilderID: "ci"}, "r/app", "sha256:abc", &plugin.ReleaseContext{Version: "1.0.0"}, "", time.Now())
+	if len(statement.Predicate.BuildDefinition.ResolvedDependencies) != 0 {
+		t.Errorf("expected no materials without a commit, got %+v", statement.Predicate.BuildDefinition.ResolvedDependencies)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProxyConfig routes HTTP calls and external commands through a proxy.
+type ProxyConfig struct {
+	HTTP    string
+	HTTPS   string
+	NoProxy string
+}
+
+// enabled reports whether any proxy is configured.
+func (c ProxyConfig) enabled() bool {
+	return c.HTTP != "" || c.HTTPS != ""
+}
+
+// env returns the proxy variables for subprocess environments, in both the
+// upper- and lowercase spellings tools look for.
+func (c ProxyConfig) env() []string {
+	var env []string
+	for _, v := range []struct{ name, value string }{
+		{"HTTP_PROXY", c.HTTP},
+		{"HTTPS_PROXY", c.HTTPS},
+		{"NO_PROXY", c.NoProxy},
+	} {
+		if v.value != "" {
+			env = append(env, v.name+"="+v.value, strings.ToLower(v.name)+"="+v.value)
+		}
+	}
+	return env
+}
+
+// proxy selects the proxy for a request by scheme, bypassing no_proxy hosts.
+func (c ProxyConfig) proxy(req *http.Request) (*url.URL, error) {
+	raw := c.HTTP
+	if req.URL.Scheme == "https" {
+		raw = c.HTTPS
+	}
+	if raw == "" || matchNoProxy(req.URL.Host, c.NoProxy) {
+		return nil, nil
+	}
+	return url.Parse(raw)
+}
+
+// validateProxyURL checks that raw is an absolute http, https or socks5 URL.
+func validateProxyURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("proxy URL must use http, https or socks5, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("proxy URL %s has no host", u.Redacted())
+	}
+	return nil
+}
+
+// redactProxyURL hides the password in a proxy URL for logging.
+func redactProxyURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "***"
+	}
+	return u.Redacted()
+}
+
+// matchNoProxy reports whether host (with optional port) matches a
+// comma-separated no_proxy list of hosts, domain suffixes, IPs or "*".
+func matchNoProxy(host, noProxy string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		case host == strings.TrimPrefix(entry, "."):
+			return true
+		case strings.HasSuffix(host, "."+strings.TrimPrefix(entry, ".")):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pushRun carries the push matrix of one Execute run. Units push in parallel
+// and record their results under mu; the stages after the push read them once
+// every unit is done.
+type pushRun struct {
+	cfg          *Config
+	runner       CommandRunner
+	client       *ACRClient
+	docker       *DockerClient
+	steps        *stepRecorder
+	audit        *AuditLog
+	registryURL  string
+	targets      []ImageTarget
+	tags         []string
+	units        []pushUnit
+	simulateOnly bool
+
+	// promoteSource is the registry-relative source promote imports
+	promoteSource string
+	// pushedFirst marks the units efficient_tagging pushes before copying the
+	// other tags from their digest
+	pushedFirst map[int]bool
+	// integritySource is the digest verify_integrity expects: the manifest
+	// digest when integrityByManifest, the config digest otherwise
+	integritySource     string
+	integrityByManifest bool
+
+	mu               sync.Mutex
+	pushAttempts     int
+	pushedImages     []string
+	pushedByImage    map[string][]string
+	digests          map[string]string
+	imageDigests     map[string]string
+	references       []ImageReference
+	promotedDigest   string
+	importedImages   []string
+	newTags          []string
+	overwrittenTags  []string
+	pushDurations    map[string]time.Duration
+	integrityDigests map[string]string
+}
+
+// newPushRun prepares the results of a run over targets × tags.
+func newPushRun(cfg *Config, targets []ImageTarget, tags []string) *pushRun {
+	return &pushRun{
+		cfg:              cfg,
+		targets:          targets,
+		tags:             tags,
+		units:            pushUnits(targets, tags),
+		pushedFirst:      map[int]bool{},
+		pushedImages:     []string{},
+		pushedByImage:    make(map[string][]string, len(targets)),
+		digests:          map[string]string{},
+		imageDigests:     map[string]string{},
+		references:       []ImageReference{},
+		importedImages:   []string{},
+		newTags:          []string{},
+		overwrittenTags:  []string{},
+		pushDurations:    map[string]time.Duration{},
+		integrityDigests: map[string]string{},
+	}
+}
+
+// verifyIntegrity compares what landed in the registry with the captured
+// source digest: the manifest digest for promote, the config digest (image ID)
+// otherwise.
+func (r *pushRun) verifyIntegrity(ctx context.Context, imagePath, tag string) error {
+	targetImage := fmt.Sprintf("%s/%s:%s", r.registryURL, imagePath, tag)
+	var digest string
+	var err error
+	if r.integrityByManifest {
+		digest, err = r.client.ManifestDigest(ctx, imagePath+":"+tag)
+	} else {
+		var manifest []byte
+		if manifest, err = r.docker.InspectManifest(ctx, targetImage); err == nil {
+			digest, err = manifestConfigDigest(manifest)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("verify_integrity: failed to fetch the digest of %s: %w", targetImage, err)
+	}
+	if err := checkIntegrity(targetImage, r.integritySource, digest); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.integrityDigests[targetImage] = digest
+	r.mu.Unlock()
+	return nil
+}
+
+// push tags and pushes, copies or imports one unit of the matrix.
+func (r *pushRun) push(ctx context.Context, unit pushUnit) error {
+	imagePath := unit.Target.Path()
+	tag := unit.Tag
+
+	copyFrom := ""
+	if r.cfg.EfficientTagging && !r.cfg.Promote && !r.pushedFirst[unit.Index] {
+		r.mu.Lock()
+		if digest := r.imageDigests[imagePath]; digest != "" {
+			copyFrom = fmt.Sprintf("%s/%s@%s", r.registryURL, imagePath, digest)
+		}
+		r.mu.Unlock()
+	}
+
+	targetImage := fmt.Sprintf("%s/%s:%s", r.registryURL, imagePath, tag)
+
+	// Classify the tag before this run can overwrite it
+	exists, existsKnown := false, false
+	if r.cfg.ReportTagNovelty {
+		var err error
+		if exists, err = r.docker.ManifestExists(ctx, targetImage); err != nil {
+			warnf("could not check whether %s exists: %v", targetImage, err)
+		} else {
+			existsKnown = true
+			r.mu.Lock()
+			if exists {
+				r.overwrittenTags = append(r.overwrittenTags, targetImage)
+			} else {
+				r.newTags = append(r.newTags, targetImage)
+			}
+			r.mu.Unlock()
+		}
+	}
+
+	unitStart := time.Now()
+	tagDone := r.steps.begin("tagging " + targetImage)
+	pushStep := fmt.Sprintf("pushing %s %d/%d", targetImage, unit.Index+1, len(r.units))
+
+	if r.simulateOnly && r.cfg.Promote {
+		fmt.Printf("[dry-run] Would import %s as %s\n", r.promoteSource, targetImage)
+		tagDone(stepSimulated)
+		r.steps.begin(pushStep)(stepSimulated)
+	} else if r.simulateOnly {
+		fmt.Printf("[dry-run] Would tag %s as %s\n", r.cfg.SourceImage, targetImage)
+		tagDone(stepSimulated)
+		fmt.Printf("[dry-run] Would push %s\n", targetImage)
+		r.steps.begin(pushStep)(stepSimulated)
+	} else {
+		// Refuse to clobber an existing release tag
+		if r.cfg.NoOverwrite && !r.cfg.Force && !isFloatingTag(tag, r.cfg.FloatingTags) {
+			if !existsKnown {
+				var err error
+				if exists, err = r.docker.ManifestExists(ctx, targetImage); err != nil {
+					return fmt.Errorf("failed to check for existing tag: %w", err)
+				}
+			}
+			if exists {
+				return fmt.Errorf("tag %s already exists; set force: true to overwrite it", targetImage)
+			}
+		}
+
+		// Stop a runaway matrix once the attempt budget is spent
+		if r.cfg.MaxPushes > 0 && !r.cfg.DryRun {
+			r.mu.Lock()
+			capped := r.pushAttempts >= r.cfg.MaxPushes
+			if !capped {
+				r.pushAttempts++
+			}
+			r.mu.Unlock()
+			if capped {
+				tagDone(stepSkipped)
+				return errPushCapReached
+			}
+		}
+
+		if r.cfg.Promote {
+			// Copy the source server-side; there is no local tag to create
+			tagDone(stepSkipped)
+			if r.cfg.DryRun {
+				fmt.Printf("[dry-run] Would import %s as %s\n", r.promoteSource, targetImage)
+				r.steps.begin(pushStep)(stepSkipped)
+			} else {
+				pushDone := r.steps.begin(pushStep)
+				if err := r.client.Import(ctx, r.promoteSource, imagePath+":"+tag); err != nil {
+					return fmt.Errorf("failed to promote image: %w", err)
+				}
+				r.mu.Lock()
+				r.importedImages = append(r.importedImages, targetImage)
+				r.mu.Unlock()
+				digest, err := r.client.ManifestDigest(ctx, imagePath+":"+tag)
+				if err != nil {
+					// The import went through; record it without a digest
+					warnf("could not resolve digest of %s: %v", targetImage, err)
+					digest = ""
+				}
+				r.audit.Record(auditPromote, r.registryURL, targetImage, digest)
+				if digest != "" {
+					r.mu.Lock()
+					r.digests[targetImage] = digest
+					r.imageDigests[imagePath] = digest
+					if r.promotedDigest == "" {
+						r.promotedDigest = digest
+					}
+					r.mu.Unlock()
+				}
+				if r.cfg.VerifyAfterPush {
+					if err := waitForManifest(ctx, r.docker.ManifestExists, targetImage, r.cfg.VerifyTimeout); err != nil {
+						return err
+					}
+				}
+				if r.cfg.VerifyIntegrity {
+					if err := r.verifyIntegrity(ctx, imagePath, tag); err != nil {
+						return err
+					}
+				}
+				fmt.Printf("Promoted: %s -> %s\n", r.promoteSource, targetImage)
+				pushDone(stepCompleted)
+			}
+		} else if copyFrom != "" {
+			// Point the new tag at the pushed manifest without re-uploading
+			tagDone(stepSkipped)
+			pushDone := r.steps.begin(pushStep)
+			if err := r.client.Import(ctx, copyFrom, imagePath+":"+tag); err != nil {
+				return fmt.Errorf("failed to copy tag server-side: %w", err)
+			}
+			digest := copyFrom[strings.LastIndex(copyFrom, "@")+1:]
+			r.audit.Record(auditPush, r.registryURL, targetImage, digest)
+			r.mu.Lock()
+			r.digests[targetImage] = digest
+			r.mu.Unlock()
+			if r.cfg.VerifyAfterPush {
+				if err := waitForManifest(ctx, r.docker.ManifestExists, targetImage, r.cfg.VerifyTimeout); err != nil {
+					return err
+				}
+			}
+			if r.cfg.VerifyIntegrity {
+				if err := r.verifyIntegrity(ctx, imagePath, tag); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("Copied: %s -> %s\n", copyFrom, targetImage)
+			pushDone(stepCompleted)
+		} else {
+			// Tag the image, under a name of its own when sharing the daemon
+			localImage := targetImage
+			if r.cfg.IsolateLocalTags {
+				var err error
+				if localImage, err = intermediateReference(targetImage); err != nil {
+					return err
+				}
+				defer func() {
+					if err := r.docker.RemoveTag(context.WithoutCancel(ctx), localImage); err != nil {
+						warnf("failed to remove intermediate tag %s: %v", localImage, err)
+					}
+				}()
+			}
+			if err := r.docker.Tag(ctx, r.cfg.SourceImage, localImage); err != nil {
+				return fmt.Errorf("failed to tag image: %w", err)
+			}
+			tagDone(stepCompleted)
+
+			if r.cfg.DryRun {
+				fmt.Printf("[dry-run] Tagged %s, would push it\n", localImage)
+				if localImage == targetImage {
+					if err := r.docker.RemoveTag(ctx, targetImage); err != nil {
+						warnf("failed to remove local tag %s: %v", targetImage, err)
+					}
+				}
+				r.steps.begin(pushStep)(stepSkipped)
+			} else {
+				// Push the image
+				pushDone := r.steps.begin(pushStep)
+				digest, err := r.docker.Push(ctx, localImage)
+				if err != nil {
+					if r.cfg.AuthMethod == "credential_helper" && isAuthFailure(err.Error()) {
+						return fmt.Errorf("failed to push image: the registry rejected the credentials from the r.docker credential helper; "+
+							"check the credHelpers entry for %s in the r.docker config: %w", r.registryURL, err)
+					}
+					return fmt.Errorf("failed to push image: %w", err)
+				}
+
+				// Point the release tag at exactly the digest this run pushed
+				if localImage != targetImage {
+					r.audit.Record(auditPush, r.registryURL, localImage, digest)
+					// The intermediate tag goes whether or not the import succeeds
+					intermediate := imagePath + localImage[strings.LastIndex(localImage, ":"):]
+					defer func() {
+						if err := r.client.Untag(context.WithoutCancel(ctx), intermediate); err != nil {
+							warnf("failed to remove intermediate tag %s from the registry: %v", intermediate, err)
+							return
+						}
+						r.audit.Record(auditDelete, r.registryURL, localImage, digest)
+					}()
+					if digest == "" {
+						return fmt.Errorf("failed to push image: no digest reported for intermediate tag %s", localImage)
+					}
+					if err := r.client.Import(ctx, fmt.Sprintf("%s/%s@%s", r.registryURL, imagePath, digest), imagePath+":"+tag); err != nil {
+						return fmt.Errorf("failed to move %s to the pushed digest: %w", targetImage, err)
+					}
+					r.audit.Record(auditImport, r.registryURL, targetImage, digest)
+				} else {
+					r.audit.Record(auditPush, r.registryURL, targetImage, digest)
+				}
+				if digest != "" {
+					r.mu.Lock()
+					r.digests[targetImage] = digest
+					r.imageDigests[imagePath] = digest
+					r.mu.Unlock()
+				}
+
+				// Wait out registry eventual consistency before reporting the tag
+				if r.cfg.VerifyAfterPush {
+					if err := waitForManifest(ctx, r.docker.ManifestExists, targetImage, r.cfg.VerifyTimeout); err != nil {
+						return err
+					}
+				}
+
+				if r.cfg.VerifyIntegrity {
+					if err := r.verifyIntegrity(ctx, imagePath, tag); err != nil {
+						return err
+					}
+				}
+
+				fmt.Printf("Pushed: %s\n", targetImage)
+
+				// Drop the local reference; the image itself stays
+				if r.cfg.CleanupLocalTags && localImage == targetImage {
+					if err := r.docker.RemoveTag(ctx, targetImage); err != nil {
+						warnf("failed to remove local tag %s: %v", targetImage, err)
+					}
+				}
+				pushDone(stepCompleted)
+			}
+		}
+	}
+
+	r.mu.Lock()
+	r.pushDurations[targetImage] = time.Since(unitStart)
+	r.pushedImages = append(r.pushedImages, targetImage)
+	r.pushedByImage[imagePath] = append(r.pushedByImage[imagePath], targetImage)
+	r.references = append(r.references, newImageReference(r.registryURL, imagePath, tag, r.digests[targetImage]))
+	r.mu.Unlock()
+	return nil
+}
+
+// subjectDigest returns the manifest digest artifacts are attached to for an
+// image: the one its push reported, or else the one the registry resolves for
+// its first pushed tag. ok is false when nothing was pushed to the image.
+func (r *pushRun) subjectDigest(ctx context.Context, imagePath string) (digest string, ok bool, err error) {
+	if digest := r.imageDigests[imagePath]; digest != "" {
+		return digest, true, nil
+	}
+	pushed := r.pushedByImage[imagePath]
+	if len(pushed) == 0 {
+		return "", false, nil
+	}
+	if usesAzSession(r.cfg.AuthMethod) {
+		digest, err = r.client.ManifestDigest(ctx, strings.TrimPrefix(pushed[0], r.registryURL+"/"))
+	} else {
+		digest, err = r.docker.RemoteDigest(ctx, pushed[0])
+	}
+	if err == nil && digest == "" {
+		err = fmt.Errorf("the registry reported no digest")
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to resolve the digest of %s: %w", pushed[0], err)
+	}
+	r.imageDigests[imagePath] = digest
+	return digest, true, nil
+}
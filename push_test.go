@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTestPushRun prepares a run of the primary image of config against runner.
+func newTestPushRun(config map[string]any, tags []string, runner *fakeRunner) *pushRun {
+	cfg := (&ACRPlugin{}).parseConfig(config)
+	run := newPushRun(cfg, []ImageTarget{{Image: cfg.Image}}, tags)
+	run.runner = runner
+	run.client = NewACRClient(cfg.Registry)
+	run.client.SetRunner(runner)
+	run.docker = NewDockerClient()
+	run.docker.SetRunner(runner)
+	run.steps = &stepRecorder{}
+	run.registryURL = run.client.GetRegistryURL()
+	return run
+}
+
+func TestPushRun_Push(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			if cmd.Args[0] == "push" {
+				return []byte("1.0.0: digest: " + digest + " size: 528\n"), nil
+			}
+			return nil, nil
+		},
+	}
+	run := newTestPushRun(map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest"}, []string{"1.0.0"}, runner)
+
+	if err := run.push(context.Background(), run.units[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ref := "myregistry.azurecr.io/myapp:1.0.0"
+	if !slices.Equal(run.pushedImages, []string{ref}) {
+		t.Errorf("unexpected pushed images %v", run.pushedImages)
+	}
+	if run.digests[ref] != digest || run.imageDigests["myapp"] != digest {
+		t.Errorf("expected digest %s to be recorded, got %v and %v", digest, run.digests, run.imageDigests)
+	}
+	if !slices.Equal(run.pushedByImage["myapp"], []string{ref}) {
+		t.Errorf("unexpected pushed_by_image %v", run.pushedByImage)
+	}
+	runner.assertCommands(t, []Command{
+		{Name: "docker", Args: []string{"tag", "myapp:latest", ref}},
+		{Name: "docker", Args: []string{"push", ref}},
+	})
+}
+
+func TestPushRun_SubjectDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("b", 64)
+	runner := &fakeRunner{
+		respond: func(Command) ([]byte, error) { return []byte(digest + "\n"), nil },
+	}
+	run := newTestPushRun(map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest"}, []string{"1.0.0"}, runner)
+
+	if _, ok, err := run.subjectDigest(context.Background(), "myapp"); ok || err != nil {
+		t.Fatalf("expected nothing to attach to before a push, got %v, %v", ok, err)
+	}
+
+	run.pushedByImage["myapp"] = []string{"myregistry.azurecr.io/myapp:1.0.0"}
+	got, ok, err := run.subjectDigest(context.Background(), "myapp")
+	if err != nil || !ok {
+		t.Fatalf("unexpected result %v, %v", ok, err)
+	}
+	if got != digest {
+		t.Errorf("expected %s, got %s", digest, got)
+	}
+	// The resolved digest is kept for the next stage
+	if _, _, err := run.subjectDigest(context.Background(), "myapp"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runner.assertCommands(t, []Command{
+		{Name: "az", Args: []string{"acr", "repository", "show", "--name", "myregistry", "--image", "myapp:1.0.0", "--query", "digest", "--output", "tsv"}},
+	})
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// referenceDomainPattern matches a registry host with an optional port.
+	referenceDomainPattern = regexp.MustCompile(
+		`^(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?$`)
+
+	// referenceNamePattern matches a repository path, optional tag and optional digest.
+	referenceNamePattern = regexp.MustCompile(
+		`^([a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*)` +
+			`(?::([\w][\w.-]{0,127}))?` +
+			`(?:@([A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}))?$`)
+)
+
+// imageReference is a parsed container image reference.
+type imageReference struct {
+	Domain string
+	Path   string
+	Tag    string
+	Digest string
+}
+
+// parseImageReference parses an image reference such as
+// "registry.example.com:5000/team/app:1.0@sha256:...".
+func parseImageReference(ref string) (imageReference, error) {
+	if ref == "" {
+		return imageReference{}, fmt.Errorf("image reference is empty")
+	}
+	if strings.Contains(ref, "://") {
+		return imageReference{}, fmt.Errorf("invalid image reference %q: must not include a URL scheme", ref)
+	}
+	if strings.ContainsAny(ref, " \t\n") {
+		return imageReference{}, fmt.Errorf("invalid image reference %q: must not contain whitespace", ref)
+	}
+
+	var parsed imageReference
+	remainder := ref
+
+	// The first component is a registry host only if it looks like one
+	if host, rest, found := strings.Cut(ref, "/"); found &&
+		(strings.ContainsAny(host, ".:") || host == "localhost" || host != strings.ToLower(host)) {
+		if !referenceDomainPattern.MatchString(host) {
+			return imageReference{}, fmt.Errorf("invalid image reference %q: invalid registry host %q", ref, host)
+		}
+		parsed.Domain = host
+		remainder = rest
+	}
+
+	m := referenceNamePattern.FindStringSubmatch(remainder)
+	if m == nil {
+		return imageReference{}, fmt.Errorf("invalid image reference %q: expected [registry/]name[:tag][@digest] "+
+			"with a lowercase name", ref)
+	}
+	parsed.Path, parsed.Tag, parsed.Digest = m[1], m[2], m[3]
+
+	return parsed, nil
+}
+
+// normalizeReference expands a reference the way Docker does: the registry
+// defaults to docker.io, Docker Hub official images gain library/ and the tag
+// defaults to latest unless a digest pins the image. "nginx" becomes
+// "docker.io/library/nginx:latest".
+func normalizeReference(ref string) (string, error) {
+	parsed, err := parseImageReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	domain := parsed.Domain
+	if domain == "" || dockerHubDomains[domain] {
+		domain = "docker.io"
+		if !strings.Contains(parsed.Path, "/") {
+			parsed.Path = "library/" + parsed.Path
+		}
+	}
+	if parsed.Tag == "" && parsed.Digest == "" {
+		parsed.Tag = "latest"
+	}
+
+	normalized := domain + "/" + parsed.Path
+	if parsed.Tag != "" {
+		normalized += ":" + parsed.Tag
+	}
+	if parsed.Digest != "" {
+		normalized += "@" + parsed.Digest
+	}
+	return normalized, nil
+}
+
+// normalizeSource normalizes a source reference. Unqualified references are
+// left alone unless pulled, since they otherwise name local images, matching
+// rewriteSource and sourcePath.
+func normalizeSource(source string, pulled bool) (string, error) {
+	parsed, err := parseImageReference(source)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Domain == "" && !pulled {
+		return source, nil
+	}
+	return normalizeReference(source)
+}
+
+// splitImagePath splits a repository path into its repository and image name.
+func splitImagePath(path string) (repository, image string) {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i], path[i+1:]
+	}
+	return "", path
+}
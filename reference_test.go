@@ -0,0 +1,123 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseImageReference(t *testing.T) {
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name     string
+		ref      string
+		expected imageReference
+		wantErr  bool
+	}{
+		{
+			name:     "name and tag",
+			ref:      "myapp:latest",
+			expected: imageReference{Path: "myapp", Tag: "latest"},
+		},
+		{
+			name:     "bare name",
+			ref:      "myapp",
+			expected: imageReference{Path: "myapp"},
+		},
+		{
+			name:     "nested path without registry",
+			ref:      "team/myapp:1.0.0",
+			expected: imageReference{Path: "team/myapp", Tag: "1.0.0"},
+		},
+		{
+			name:     "registry with port",
+			ref:      "localhost:5000/myapp:dev",
+			expected: imageReference{Domain: "localhost:5000", Path: "myapp", Tag: "dev"},
+		},
+		{
+			name:     "tag and digest",
+			ref:      "ghcr.io/org/myapp:1.0@" + digest,
+			expected: imageReference{Domain: "ghcr.io", Path: "org/myapp", Tag: "1.0", Digest: digest},
+		},
+		{name: "whitespace", ref: "my image:tag", wantErr: true},
+		{name: "url scheme", ref: "https://myregistry.azurecr.io/myapp:1.0", wantErr: true},
+		{name: "trailing colon", ref: "myapp:", wantErr: true},
+		{name: "uppercase name", ref: "MyApp:1.0", wantErr: true},
+		{name: "empty", ref: "", wantErr: true},
+		{name: "short digest", ref: "myapp@sha256:abc", wantErr: true},
+		{name: "invalid tag characters", ref: "myapp:v1/2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImageReference(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseImageReference(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.expected {
+				t.Errorf("parseImageReference(%q) = %+v, want %+v", tt.ref, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplitImagePath(t *testing.T) {
+	for path, expected := range map[string][2]string{
+		"nginx":         {"", "nginx"},
+		"library/nginx": {"library", "nginx"},
+		"org/team/app":  {"org/team", "app"},
+	} {
+		if repository, image := splitImagePath(path); repository != expected[0] || image != expected[1] {
+			t.Errorf("splitImagePath(%q) = %q, %q; expected %q, %q", path, repository, image, expected[0], expected[1])
+		}
+	}
+}
+
+func TestNormalizeReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	tests := []struct {
+		name     string
+		ref      string
+		expected string
+	}{
+		{name: "bare name", ref: "nginx", expected: "docker.io/library/nginx:latest"},
+		{name: "official image with tag", ref: "nginx:1.25", expected: "docker.io/library/nginx:1.25"},
+		{name: "official image with library", ref: "library/nginx", expected: "docker.io/library/nginx:latest"},
+		{name: "namespaced image", ref: "bitnami/redis:7", expected: "docker.io/bitnami/redis:7"},
+		{name: "docker hub host", ref: "docker.io/nginx", expected: "docker.io/library/nginx:latest"},
+		{name: "index host", ref: "index.docker.io/library/nginx:1.25", expected: "docker.io/library/nginx:1.25"},
+		{name: "digest only", ref: "nginx@" + digest, expected: "docker.io/library/nginx@" + digest},
+		{name: "fully qualified", ref: "myregistry.azurecr.io/team/app:1.0", expected: "myregistry.azurecr.io/team/app:1.0"},
+		{name: "other registry without tag", ref: "ghcr.io/org/app", expected: "ghcr.io/org/app:latest"},
+		{name: "registry with port", ref: "localhost:5000/app", expected: "localhost:5000/app:latest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeReference(tt.ref)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := normalizeReference("Not/Valid"); err == nil {
+		t.Error("expected an error for an invalid reference")
+	}
+}
+
+func TestNormalizeSource(t *testing.T) {
+	if got, _ := normalizeSource("myapp:latest", false); got != "myapp:latest" {
+		t.Errorf("expected a local image to be left alone, got %q", got)
+	}
+	if got, _ := normalizeSource("nginx", true); got != "docker.io/library/nginx:latest" {
+		t.Errorf("expected a pulled image to be normalized, got %q", got)
+	}
+	if got, _ := normalizeSource("docker.io/nginx", false); got != "docker.io/library/nginx:latest" {
+		t.Errorf("expected a qualified image to be normalized, got %q", got)
+	}
+}
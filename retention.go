@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// versionToRetire returns the tag that falls out of a window of keep versions
+// before previous: the keep-th newest semver tag older than previous. Tags in
+// protected are never returned.
+func versionToRetire(tags []string, previous string, keep int, protected []string) (string, bool) {
+	prev, ok := parseSemver(previous)
+	if !ok || keep < 1 {
+		return "", false
+	}
+
+	type versionTag struct {
+		tag     string
+		version semver
+	}
+	var older []versionTag
+	for _, tag := range tags {
+		v, ok := parseSemver(tag)
+		if !ok || v.compare(prev) >= 0 {
+			continue
+		}
+		older = append(older, versionTag{tag: tag, version: v})
+	}
+	sort.Slice(older, func(i, j int) bool { return older[i].version.compare(older[j].version) > 0 })
+
+	if len(older) < keep {
+		return "", false
+	}
+	candidate := older[keep-1].tag
+	for _, tag := range protected {
+		if tag == candidate {
+			return "", false
+		}
+	}
+	return candidate, true
+}
+
+// parseConfigLabels extracts the labels from an OCI image config blob.
+func parseConfigLabels(config []byte) (map[string]string, error) {
+	var raw struct {
+		Config struct {
+			Labels map[string]string `json:"Labels"`
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(config, &raw); err != nil {
+		return nil, fmt.Errorf("invalid image config: %w", err)
+	}
+	return raw.Config.Labels, nil
+}
+
+// protectedByLabels returns the first protect_labels entry the labels carry,
+// as "key=value". A protected value of "*" matches any value of the key.
+func protectedByLabels(labels, protect map[string]string) (string, bool) {
+	keys := make([]string, 0, len(protect))
+	for key := range protect {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, ok := labels[key]
+		if ok && (protect[key] == "*" || protect[key] == value) {
+			return key + "=" + value, true
+		}
+	}
+	return "", false
+}
+
+// retirement is what the retention stage did with the version that fell out
+// of the delete_previous_but window.
+type retirement struct {
+	Untagged         []string
+	Protected        []string
+	ReclaimableBytes int64
+}
+
+// retire untags, in every image path, the version delete_previous_but retires
+// relative to previousVersion. Failures are warnings.
+func (r *pushRun) retire(ctx context.Context, previousVersion string) *retirement {
+	retired := &retirement{Untagged: []string{}, Protected: []string{}}
+	if r.cfg.DeletePreviousBut == 0 {
+		return retired
+	}
+	switch {
+	case previousVersion == "":
+		warnf("delete_previous_but is set but the release has no previous version; nothing untagged")
+	case r.simulateOnly:
+		fmt.Printf("[dry-run] Would untag the version %d release(s) before %s\n",
+			r.cfg.DeletePreviousBut, previousVersion)
+	default:
+		for _, target := range r.targets {
+			imagePath := target.Path()
+			existing, err := r.client.ListTags(ctx, imagePath)
+			if err != nil {
+				warnf("failed to list tags of %s: %v", imagePath, err)
+				continue
+			}
+			tag, ok := versionToRetire(existing, previousVersion, r.cfg.DeletePreviousBut, r.tags)
+			if !ok {
+				continue
+			}
+			ref := imagePath + ":" + tag
+
+			// Keep labelled images; when the labels cannot be read, keep it too
+			if len(r.cfg.ProtectLabels) > 0 {
+				oras := NewOrasClient()
+				oras.SetRunner(r.runner)
+				config, err := oras.FetchConfig(ctx, fmt.Sprintf("%s/%s", r.registryURL, ref))
+				var labels map[string]string
+				if err == nil {
+					labels, err = parseConfigLabels(config)
+				}
+				if err != nil {
+					warnf("keeping %s/%s: could not read its labels: %v", r.registryURL, ref, err)
+					continue
+				}
+				if label, ok := protectedByLabels(labels, r.cfg.ProtectLabels); ok {
+					fmt.Printf("Keeping %s/%s: protected by label %s\n", r.registryURL, ref, label)
+					retired.Protected = append(retired.Protected, fmt.Sprintf("%s/%s", r.registryURL, ref))
+					continue
+				}
+			}
+
+			if r.cfg.DryRun {
+				fmt.Printf("[dry-run] Would untag %s/%s\n", r.registryURL, ref)
+				continue
+			}
+			// Size it first; layers shared with kept images make this an upper bound
+			size, err := r.client.ManifestSize(ctx, ref)
+			if err != nil {
+				warnf("failed to size %s: %v", ref, err)
+			}
+			// Capture what the tag pointed to before it is gone
+			untaggedDigest, _ := r.client.ManifestDigest(ctx, ref)
+			// Only the tag is retired: deleting the manifest would also drop
+			// every other tag on it, including ones pushed by this release
+			if err := r.client.Untag(ctx, ref); err != nil {
+				warnf("failed to untag %s: %v", ref, err)
+				continue
+			}
+			r.audit.Record(auditUntag, r.registryURL, fmt.Sprintf("%s/%s", r.registryURL, ref), untaggedDigest)
+			// Only a manifest left without tags is ever purged
+			if untaggedDigest != "" {
+				remaining, err := r.client.ManifestTags(ctx, imagePath+"@"+untaggedDigest)
+				switch {
+				case err != nil:
+					warnf("failed to read the remaining tags of %s: %v", ref, err)
+				case len(remaining) == 0:
+					retired.ReclaimableBytes += size
+				}
+			}
+			fmt.Printf("Untagged: %s/%s\n", r.registryURL, ref)
+			retired.Untagged = append(retired.Untagged, fmt.Sprintf("%s/%s", r.registryURL, ref))
+		}
+	}
+	return retired
+}
+
+// applyUntaggedRetention enables the untagged-manifest retention policy when
+// untagged_retention is set. info is the registry metadata already read, if
+// any; a registry other than Premium is left unchanged with a warning.
+func (r *pushRun) applyUntaggedRetention(ctx context.Context, info *RegistryInfo) {
+	if r.cfg.UntaggedRetention == 0 {
+		return
+	}
+	if r.cfg.DryRun {
+		fmt.Printf("[dry-run] Would purge untagged manifests after %d day(s)\n", r.cfg.UntaggedRetention)
+		return
+	}
+	if info == nil {
+		var err error
+		if info, err = r.client.ShowRegistry(ctx); err != nil {
+			warnf("failed to read the SKU of %s: %v", r.registryURL, err)
+			return
+		}
+	}
+	if !strings.EqualFold(info.SKU, "Premium") {
+		warnf("untagged_retention needs a Premium registry, %s is %s; purge untagged manifests with acr purge instead", r.registryURL, info.SKU)
+		return
+	}
+	if err := r.client.SetUntaggedRetention(ctx, r.cfg.UntaggedRetention); err != nil {
+		warnf("failed to set the untagged-manifest retention policy: %v", err)
+		return
+	}
+	fmt.Printf("Untagged manifests on %s are purged after %d day(s)\n", r.registryURL, r.cfg.UntaggedRetention)
+}
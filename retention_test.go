@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestVersionToRetire(t *testing.T) {
+	tags := []string{"latest", "1.0.0", "1.1.0", "1.2.0-rc.1", "1.2.0", "1.2.1", "1.2.3"}
+
+	tests := []struct {
+		name      string
+		previous  string
+		keep      int
+		protected []string
+		expected  string
+		found     bool
+	}{
+		{name: "version before previous", previous: "1.2.1", keep: 1, expected: "1.2.0", found: true},
+		{name: "keep two", previous: "1.2.1", keep: 2, expected: "1.2.0-rc.1", found: true},
+		{name: "nothing older", previous: "1.0.0", keep: 1, found: false},
+		{name: "no previous version", previous: "", keep: 1, found: false},
+		{name: "protected tag", previous: "1.2.1", keep: 1, protected: []string{"1.2.0"}, found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := versionToRetire(tags, tt.previous, tt.keep, tt.protected)
+			if found != tt.found || got != tt.expected {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tt.expected, tt.found, got, found)
+			}
+		})
+	}
+}
+
+func TestParseConfigLabels(t *testing.T) {
+	labels, err := parseConfigLabels([]byte(`{"architecture":"amd64","config":{"Labels":{"retain":"true"}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if labels["retain"] != "true" {
+		t.Errorf("unexpected labels %v", labels)
+	}
+
+	if labels, err := parseConfigLabels([]byte(`{"config":{}}`)); err != nil || len(labels) != 0 {
+		t.Errorf("expected no labels, got %v (%v)", labels, err)
+	}
+	if _, err := parseConfigLabels([]byte("not json")); err == nil {
+		t.Error("expected an error for an invalid config")
+	}
+}
+
+func TestProtectedByLabels(t *testing.T) {
+	labels := map[string]string{"retain": "true", "team": "payments"}
+
+	tests := []struct {
+		name     string
+		protect  map[string]string
+		expected string
+		found    bool
+	}{
+		{name: "exact match", protect: map[string]string{"retain": "true"}, expected: "retain=true", found: true},
+		{name: "any value", protect: map[string]string{"team": "*"}, expected: "team=payments", found: true},
+		{name: "different value", protect: map[string]string{"retain": "false"}, found: false},
+		{name: "missing label", protect: map[string]string{"lts": "*"}, found: false},
+		{name: "first key wins", protect: map[string]string{"team": "payments", "retain": "true"}, expected: "retain=true", found: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := protectedByLabels(labels, tt.protect)
+			if found != tt.found || got != tt.expected {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tt.expected, tt.found, got, found)
+			}
+		})
+	}
+}
+
+func TestPushRun_Retire(t *testing.T) {
+	const retired = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			switch {
+			case slices.Contains(cmd.Args, "show-tags"):
+				return []byte("1.0.0\n1.1.0\n1.2.0\n"), nil
+			case slices.Contains(cmd.Args, "digest"):
+				return []byte(retired + "\n"), nil
+			case slices.Contains(cmd.Args, "imageSize"):
+				return []byte("1024\n"), nil
+			}
+			return nil, nil
+		},
+	}
+	run := newTestPushRun(map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "delete_previous_but": 1}, []string{"1.2.0"}, runner)
+
+	result := run.retire(context.Background(), "1.1.0")
+
+	if !slices.Equal(result.Untagged, []string{"myregistry.azurecr.io/myapp:1.0.0"}) {
+		t.Errorf("unexpected untagged tags %v", result.Untagged)
+	}
+	if result.ReclaimableBytes != 1024 {
+		t.Errorf("expected the untagged manifest to be reclaimable, got %d", result.ReclaimableBytes)
+	}
+	runner.assertCommands(t, []Command{
+		{Name: "az", Args: []string{"acr", "repository", "show-tags", "--name", "myregistry", "--repository", "myapp", "--output", "tsv"}},
+		{Name: "az", Args: []string{"acr", "repository", "show", "--name", "myregistry", "--image", "myapp:1.0.0", "--query", "imageSize", "--output", "tsv"}},
+		{Name: "az", Args: []string{"acr", "repository", "show", "--name", "myregistry", "--image", "myapp:1.0.0", "--query", "digest", "--output", "tsv"}},
+		{Name: "az", Args: []string{"acr", "repository", "untag", "--name", "myregistry", "--image", "myapp:1.0.0"}},
+		{Name: "az", Args: []string{"acr", "manifest", "show-metadata", "--registry", "myregistry", "--name", "myapp@" + retired, "--query", "tags", "--output", "tsv"}},
+	})
+}
+
+func TestPushRun_RetireWithoutPreviousVersion(t *testing.T) {
+	runner := &fakeRunner{}
+	run := newTestPushRun(map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "delete_previous_but": 1}, []string{"1.0.0"}, runner)
+
+	result := run.retire(context.Background(), "")
+
+	if len(result.Untagged) != 0 || len(result.Protected) != 0 {
+		t.Errorf("expected nothing retired, got %+v", result)
+	}
+	runner.assertCommands(t, []Command{})
+}
+
+func TestPushRun_ApplyUntaggedRetention(t *testing.T) {
+	tests := []struct {
+		name     string
+		sku      string
+		expected []Command
+	}{
+		{
+			name: "premium",
+			sku:  "Premium",
+			expected: []Command{
+				{Name: "az", Args: []string{"acr", "config", "retention", "update", "--registry", "myregistry", "--status", "enabled", "--days", "30", "--type", "UntaggedManifests"}},
+			},
+		},
+		{name: "basic", sku: "Basic", expected: []Command{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			run := newTestPushRun(map[string]any{"registry": "myregistry", "image": "myapp", "source_image": "myapp:latest", "untagged_retention": 30}, []string{"1.0.0"}, runner)
+
+			// Registry info read earlier in the run is reused
+			run.applyUntaggedRetention(context.Background(), &RegistryInfo{SKU: tt.sku})
+
+			runner.assertCommands(t, tt.expected)
+		})
+	}
+}
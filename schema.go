@@ -0,0 +1,275 @@
+package main
+
+import "encoding/json"
+
+// Schema returns a JSON Schema describing the plugin configuration.
+func (p *ACRPlugin) Schema() map[string]any {
+	return map[string]any{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"title":                "ACR plugin configuration",
+		"type":                 "object",
+		"required":             []string{"registry", "image", "source_image"},
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"registry":           schemaString("ACR registry name, with or without the .azurecr.io suffix"),
+			"allowed_registries": schemaStringArray("Registries pushes are restricted to; ACR_ALLOWED_REGISTRIES (comma-separated) must allow the registry too"),
+			"branch_policy": map[string]any{
+				"type":        "object",
+				"description": "Repository path patterns mapped to the branch patterns allowed to push to them; tag: patterns match the release tag",
+				"additionalProperties": map[string]any{
+					"oneOf": []any{
+						map[string]any{"type": "string"},
+						map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+					},
+				},
+			},
+			"namespace":               schemaString("Path prefix applied to every image"),
+			"repository":              schemaString("Repository within the registry"),
+			"image":                   schemaString("Image name to push"),
+			"path_mode":               schemaEnum("How repository and image form the path: compose (repository/image), image_only or repository_only", []string{"compose", "image_only", "repository_only"}),
+			"derive_path_from_source": schemaBool("Use the source_image repository path, without its host, instead of repository and image"),
+			"strip_library_namespace": schemaBool("Drop the library/ namespace of Docker Hub official images from the derived path"),
+			"normalize_source":        schemaBool("Expand source_image to its fully qualified form, e.g. nginx -> docker.io/library/nginx:latest"),
+			"source_image":            schemaString("Local image to tag and push ([registry/]name[:tag][@digest])"),
+			"pull_source":             schemaBool("Pull the source image before tagging"),
+			"source_stdin":            schemaBool("Load source_image from a docker save stream on stdin"),
+			"source_registry_mirror":  schemaString("Mirror host (and optional path) replacing Docker Hub in source_image"),
+			"source_rewrite": map[string]any{
+				"type":                 "object",
+				"description":          "Reference prefixes rewritten in source_image",
+				"additionalProperties": map[string]any{"type": "string"},
+			},
+			"promote":          schemaBool("Copy source_image server-side from within ACR instead of pushing a local image"),
+			"auto_server_side": schemaBool("Promote a source_image already in the target registry instead of pulling, tagging and pushing it"),
+			"source_registry": schemaObject("Credentials az acr import uses for a promote source in another registry", map[string]any{
+				"username":      schemaString("Source registry username (default: ACR_SOURCE_USERNAME)"),
+				"password":      schemaString("Source registry password (default: ACR_SOURCE_PASSWORD)"),
+				"client_id":     schemaString("Service principal client ID, used when username is unset"),
+				"client_secret": schemaString("Service principal client secret"),
+			}),
+			"auth": schemaObject("Registry authentication", map[string]any{
+				"method":                 schemaEnum("Authentication method", p.SupportedAuthMethods()),
+				"client_id":              schemaString("Service principal or user-assigned identity client ID"),
+				"client_secret":          schemaString("Service principal secret"),
+				"tenant_id":              schemaString("Service principal tenant ID"),
+				"username":               schemaString("Admin username"),
+				"password":               schemaString("Admin password"),
+				"token":                  schemaString("Pre-obtained ACR access token"),
+				"client_secret_file":     schemaString("File containing the service principal secret"),
+				"password_file":          schemaString("File containing the admin password"),
+				"token_file":             schemaString("File containing the ACR access token"),
+				"acknowledge_admin_auth": schemaBool("Silence the admin account warning"),
+				"fail_on_empty":          schemaBool("Fail validation when configured credentials resolve to empty"),
+				"verify_helper":          schemaBool("With credential_helper, check that the docker config names a helper for the registry"),
+			}),
+			"preserve_registry_case":   schemaBool("Keep the registry casing in image references instead of lowercasing the host"),
+			"subscription":             schemaString("Azure subscription ID or name containing the registry"),
+			"azure_config_dir":         schemaString("AZURE_CONFIG_DIR for az commands, or 'isolated' for a temporary one"),
+			"report_registry_info":     schemaBool("Report the registry SKU, location and encryption status"),
+			"verify_anonymous_pull":    schemaEnum("Expected anonymous pull setting of the registry, checked after pushing", []string{"enabled", "disabled"}),
+			"set_anonymous_pull":       schemaBool("Update the registry to the verify_anonymous_pull setting instead of failing on a mismatch"),
+			"anonymous_pull_warn_only": schemaBool("Warn instead of failing when anonymous pull does not match verify_anonymous_pull"),
+			"transcript_file":          schemaString("File receiving one JSON line per external command"),
+			"audit_log":                schemaString("File receiving one JSON line per tag pushed, promoted or deleted"),
+			"suggest_on_not_found":     schemaBool("Suggest similar accessible registry names when the registry is not found"),
+			"proxy": schemaObject("Proxy for HTTP calls and az, docker and oras commands", map[string]any{
+				"http":     schemaString("Proxy URL for http requests"),
+				"https":    schemaString("Proxy URL for https requests"),
+				"no_proxy": schemaString("Comma-separated hosts and domains that bypass the proxy"),
+			}),
+			"pre_push_command":  schemaString("Command template run before pushing, split into arguments after rendering. A failure aborts"),
+			"post_push_command": schemaString("Command run after pushing with ACR_REGISTRY, ACR_TAGS, ACR_PUSHED_IMAGES and ACR_DIGESTS set"),
+			"hooks": schemaObject("Lifecycle hooks the plugin pushes at, and settings for pre_push_command and post_push_command", map[string]any{
+				"enabled": map[string]any{
+					"type":        "array",
+					"description": "Lifecycle hooks to push at (default: [post-publish])",
+					"items":       schemaEnum("Lifecycle hook", []string{"pre-publish", "post-publish"}),
+				},
+				"repositories": map[string]any{
+					"type":                 "object",
+					"description":          "Repository used instead of repository at the named hook",
+					"additionalProperties": map[string]any{"type": "string"},
+				},
+				"required": schemaBool("Fail the run when post_push_command fails"),
+				"timeout":  schemaString("Timeout for each hook command (default: 5m)"),
+			}),
+			"http_transport": schemaObject("Connection reuse for HTTP calls", map[string]any{
+				"max_idle_conns_per_host": schemaInteger("Idle connections kept per host (default: 10)"),
+				"idle_conn_timeout":       schemaString("How long an idle connection is kept (default: 90s)"),
+				"keep_alive":              schemaBool("Reuse connections between requests (default: true)"),
+			}),
+			"tls": schemaObject("TLS settings for HTTP calls", map[string]any{
+				"min_version": schemaEnum("Minimum TLS version (default: 1.2)", []string{"1.2", "1.3"}),
+			}),
+			"user_agent_suffix": schemaString("Identifier appended to the User-Agent of HTTP and az requests"),
+			"notify": schemaObject("Webhook called after a successful push", map[string]any{
+				"url":      schemaString("Webhook URL"),
+				"method":   schemaString("HTTP method"),
+				"headers":  map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+				"body":     schemaString("Go template for the request body"),
+				"secret":   schemaString("HMAC-SHA256 signing secret"),
+				"timeout":  schemaString("Request timeout duration"),
+				"required": schemaBool("Fail the run when the webhook fails"),
+			}),
+			"metrics_pushgateway": schemaObject("Prometheus pushgateway receiving run metrics", map[string]any{
+				"url":     schemaString("Pushgateway URL"),
+				"job":     schemaString("Job name (default: relicta-acr)"),
+				"labels":  map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+				"timeout": schemaString("Request timeout duration"),
+			}),
+			"lock": schemaObject("Host-local lock serializing pushes to the same repository", map[string]any{
+				"enabled": schemaBool("Enable the lock"),
+				"dir":     schemaString("Directory holding lock files"),
+				"timeout": schemaString("How long to wait for the lock"),
+			}),
+			"sbom": schemaObject("SBOM attached to each pushed image", map[string]any{
+				"file":       schemaString("SBOM file path"),
+				"media_type": schemaString("SBOM artifact media type"),
+			}),
+			"provenance": schemaObject("SLSA provenance attested for each pushed image with cosign", map[string]any{
+				"enabled":    schemaBool("Attest provenance"),
+				"builder_id": schemaString("URI identifying the builder, such as the CI workflow"),
+				"build_type": schemaString("SLSA build type URI"),
+				"key":        schemaString("cosign key reference (default: COSIGN_KEY); empty signs keylessly"),
+			}),
+			"archive_oci_layout":    schemaString("Directory receiving an OCI image layout of each pushed image"),
+			"signing_manifest_file": schemaString("JSON file listing each pushed reference and digest, for signing out of band"),
+			"release_notes": schemaObject("Release notes attached to each pushed image", map[string]any{
+				"enabled":    schemaBool("Attach release notes"),
+				"text":       schemaString("Inline notes template; defaults to the generated release notes"),
+				"file":       schemaString("File holding the notes template"),
+				"media_type": schemaString("Release notes artifact media type"),
+			}),
+			"record_base_image": schemaBool("Report the base image named by the source image's org.opencontainers.image.base.name label"),
+			"attach_base_image": schemaBool("Attach the recorded base image to each pushed image as a referring OCI artifact"),
+			"additional_images": map[string]any{
+				"type":        "array",
+				"description": "Further image names that receive every tag",
+				"items": schemaObject("Additional image", map[string]any{
+					"repository": schemaString("Repository within the registry"),
+					"image":      schemaString("Image name"),
+				}),
+			},
+			"tags": map[string]any{
+				"description": "Tag templates, as a list or a comma-separated string",
+				"oneOf": []any{
+					map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+					map[string]any{"type": "string"},
+				},
+			},
+			"tag_metadata":       schemaBool("Inspect each pushed tag and report its metadata in the tag_metadata output"),
+			"report_tag_novelty": schemaBool("Report which tags are new and which overwrite existing ones"),
+			"floating_tags":      schemaStringArray("Tags exempt from no_overwrite"),
+			"template_vars": map[string]any{
+				"type":        "object",
+				"description": "Variables available to tag templates as .Vars",
+				"additionalProperties": map[string]any{
+					"oneOf": []any{
+						map[string]any{"type": "string"},
+						schemaObject("Read the value from an environment variable", map[string]any{
+							"env": schemaString("Environment variable name"),
+						}),
+					},
+				},
+			},
+			"ci_vars": map[string]any{
+				"type":        "object",
+				"description": "Environment variables read for .BuildNumber, .RunID and .PipelineID",
+				"properties": map[string]any{
+					"build_number": schemaEnvNames("Variables for .BuildNumber"),
+					"run_id":       schemaEnvNames("Variables for .RunID"),
+					"pipeline_id":  schemaEnvNames("Variables for .PipelineID"),
+				},
+				"additionalProperties": false,
+			},
+			"success_message": schemaString("Template for the result message, with .Count, .Registry, .Tags and .PushedImages"),
+			"git_describe": schemaObject("Source of {{.GitDescribe}}", map[string]any{
+				"value":   schemaString("Describe string (default: GIT_DESCRIBE)"),
+				"command": schemaString("Command printing the describe string, run without a shell"),
+			}),
+			"provenance_inputs":     schemaStringArray("Templates or literals hashed in order into {{.ProvenanceHash}}"),
+			"latest_policy":         schemaEnum("When a configured latest tag is pushed", []string{"always", "stable_only", "never"}),
+			"bump":                  schemaEnum("Version component bumped for {{.NextVersion}}", []string{"major", "minor", "patch"}),
+			"sequence_pattern":      schemaString("Regular expression selecting the tags {{.NextSequence}} counts from; a capture group holds the number (default: ^\\d+$)"),
+			"disable_default_tag":   schemaBool("Do not push {{.Version}} when no tags are configured"),
+			"require_tags":          schemaBool("Fail when the tag list is empty"),
+			"require_immutable_tag": schemaBool("Fail when every pushed tag is in mutable_tags"),
+			"mutable_tags":          schemaStringArray("Floating tags for require_immutable_tag, plus floating_tags (default: latest, edge, stable, main)"),
+			"tags_limit":            schemaInteger("Maximum number of tags pushed"),
+			"expected_platform":     schemaString("Platform (os/arch[/variant]) the source image must provide"),
+			"max_image_size":        schemaString("Largest source image allowed, such as '2GB' or '512MiB'"),
+			"allow_oversize_image":  schemaBool("Push images above max_image_size with a warning"),
+			"docker_preflight":      schemaBool("Check that the Docker daemon is reachable before starting"),
+			"cleanup_local_tags":    schemaBool("Remove local registry tags after a successful push"),
+			"isolate_local_tags":    schemaBool("Tag and push through a unique intermediate tag, then move the release tag server-side"),
+			"verify_after_push":     schemaBool("Poll until each pushed tag resolves before reporting success"),
+			"verify_timeout":        schemaString("How long to wait for a pushed tag to resolve"),
+			"verify_integrity":      schemaBool("Fail unless each pushed tag's registry digest matches the source digest captured before pushing"),
+			"delete_previous_but":   schemaInteger("Versions to keep before the current release; the next older one is untagged"),
+			"no_overwrite":          schemaBool("Refuse to overwrite existing non-floating tags"),
+			"force":                 schemaBool("Override no_overwrite"),
+			"enabled":               schemaBool("Enable the plugin"),
+			"dry_run":               schemaBool("Simulate the push"),
+			"dry_run_mode":          schemaEnum("Dry-run behavior", []string{"full", "push_skip"}),
+			"max_upload_rate":       schemaInteger("Requested push bandwidth limit in bytes/sec"),
+			"execute_timeout":       schemaString("Timeout for the whole run, such as '10m'"),
+			"efficient_tagging":     schemaBool("Push one tag per image and copy the other tags server-side"),
+			"max_parallel":          schemaInteger("Maximum pushes in flight across all images and tags"),
+			"max_pushes":            schemaInteger("Maximum push attempts per run, successful or failed; 0 is unlimited"),
+			"untagged_retention":    schemaInteger("Days after which the registry purges untagged manifests (Premium only); 0 leaves the policy unchanged"),
+			"protect_labels": map[string]any{
+				"type":                 "object",
+				"description":          "Labels (key: value, or key: '*' for any value) that keep an image from delete_previous_but",
+				"additionalProperties": map[string]any{"type": "string"},
+			},
+		},
+	}
+}
+
+// configSchemaJSON returns the configuration schema encoded as JSON.
+func (p *ACRPlugin) configSchemaJSON() string {
+	data, err := json.Marshal(p.Schema())
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func schemaString(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+func schemaBool(description string) map[string]any {
+	return map[string]any{"type": "boolean", "description": description}
+}
+
+func schemaInteger(description string) map[string]any {
+	return map[string]any{"type": "integer", "minimum": 0, "description": description}
+}
+
+func schemaStringArray(description string) map[string]any {
+	return map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": description}
+}
+
+func schemaEnvNames(description string) map[string]any {
+	return map[string]any{
+		"description": description,
+		"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+}
+
+func schemaEnum(description string, values []string) map[string]any {
+	return map[string]any{"type": "string", "enum": values, "description": description}
+}
+
+func schemaObject(description string, properties map[string]any) map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"description":          description,
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// parsedConfigKeys scans the plugin sources for the keys parseConfig reads,
+// grouped by the parser variable that reads them.
+func parsedConfigKeys(t *testing.T) map[string][]string {
+	t.Helper()
+
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pattern := regexp.MustCompile(`\b(\w*?)(?:Parser|parser)\.Get\w+\("(\w+)"|\braw\["(\w+)"\]`)
+	keys := map[string][]string{}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range pattern.FindAllStringSubmatch(string(src), -1) {
+			if m[3] != "" {
+				keys[""] = append(keys[""], m[3])
+				continue
+			}
+			keys[m[1]] = append(keys[m[1]], m[2])
+		}
+	}
+	return keys
+}
+
+func TestACRPlugin_Schema_MatchesParseConfig(t *testing.T) {
+	p := &ACRPlugin{}
+	schema := p.Schema()
+	properties := schema["properties"].(map[string]any)
+
+	// Nested parsers are named after the block they read
+	nested := map[string]map[string]any{"": properties}
+	for name, prop := range properties {
+		obj, _ := prop.(map[string]any)
+		if props, ok := obj["properties"].(map[string]any); ok {
+			nested[name] = props
+		}
+	}
+	items := properties["additional_images"].(map[string]any)["items"].(map[string]any)
+	nested["entry"] = items["properties"].(map[string]any)
+
+	parsed := parsedConfigKeys(t)
+	for parserName, keys := range parsed {
+		props, ok := nested[snakeCase(parserName)]
+		if !ok {
+			t.Errorf("schema has no block for keys read by %qParser: %v", parserName, keys)
+			continue
+		}
+		for _, key := range keys {
+			if _, ok := props[key]; !ok {
+				t.Errorf("config key %q read by %qParser is missing from the schema", key, parserName)
+			}
+		}
+	}
+
+	for key := range properties {
+		if !slices.Contains(parsed[""], key) {
+			t.Errorf("schema property %q is never read by parseConfig", key)
+		}
+	}
+}
+
+func TestACRPlugin_Schema_MatchesValidate(t *testing.T) {
+	p := &ACRPlugin{}
+	schema := p.Schema()
+
+	resp, err := p.Validate(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var missing []string
+	for _, e := range resp.Errors {
+		missing = append(missing, e.Field)
+	}
+	required := schema["required"].([]string)
+	slices.Sort(missing)
+	if !slices.Equal(slices.Sorted(slices.Values(required)), missing) {
+		t.Errorf("schema requires %v but Validate reports %v for an empty config", required, missing)
+	}
+
+	auth := schema["properties"].(map[string]any)["auth"].(map[string]any)
+	method := auth["properties"].(map[string]any)["method"].(map[string]any)
+	if !slices.Equal(method["enum"].([]string), p.SupportedAuthMethods()) {
+		t.Errorf("auth.method enum %v does not match supported methods", method["enum"])
+	}
+}
+
+func TestACRPlugin_GetInfo_ConfigSchema(t *testing.T) {
+	p := &ACRPlugin{}
+	info := p.GetInfo()
+
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(info.ConfigSchema), &decoded); err != nil {
+		t.Fatalf("ConfigSchema is not valid JSON: %v", err)
+	}
+	if decoded["type"] != "object" {
+		t.Errorf("expected object schema, got %v", decoded["type"])
+	}
+}
+
+// snakeCase maps a parser variable prefix like releaseNotes to its block name.
+func snakeCase(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsUpper(r) {
+			b.WriteByte('_')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
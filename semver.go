@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// semverPattern matches a semantic version with an optional leading "v".
+var semverPattern = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// semver is a parsed semantic version.
+type semver struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Build      string
+}
+
+// parseSemver parses a semantic version, reporting whether it was valid.
+func parseSemver(version string) (semver, bool) {
+	m := semverPattern.FindStringSubmatch(strings.TrimSpace(version))
+	if m == nil {
+		return semver{}, false
+	}
+
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+
+	return semver{
+		Major:      major,
+		Minor:      minor,
+		Patch:      patch,
+		Prerelease: m[4],
+		Build:      m[5],
+	}, true
+}
+
+// String formats the version without a leading "v".
+func (v semver) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// bump increments the major, minor or patch component. A prerelease of the
+// bumped version releases it instead, so 2.0.0-rc.1 bumps to 2.0.0 for major.
+// Prerelease and build metadata are always cleared.
+func (v semver) bump(part string) (semver, bool) {
+	pre := v.Prerelease != ""
+	switch part {
+	case "major":
+		if !pre || v.Minor != 0 || v.Patch != 0 {
+			v.Major++
+		}
+		v.Minor, v.Patch = 0, 0
+	case "minor":
+		if !pre || v.Patch != 0 {
+			v.Minor++
+		}
+		v.Patch = 0
+	case "patch":
+		if !pre {
+			v.Patch++
+		}
+	default:
+		return semver{}, false
+	}
+	v.Prerelease, v.Build = "", ""
+	return v, true
+}
+
+// nextVersion bumps version by part, returning "" when version is not semver.
+func nextVersion(version, part string) string {
+	v, ok := parseSemver(version)
+	if !ok {
+		return ""
+	}
+	next, ok := v.bump(part)
+	if !ok {
+		return ""
+	}
+	return next.String()
+}
+
+// compare returns -1, 0 or 1 by semantic version precedence. Build metadata is ignored.
+func (v semver) compare(o semver) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	// A release ranks above its prereleases
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+
+	a, b := strings.Split(v.Prerelease, "."), strings.Split(o.Prerelease, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := comparePrereleaseIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(a) - len(b))
+}
+
+// comparePrereleaseIdentifier compares dot-separated prerelease identifiers:
+// numeric identifiers compare numerically and rank below alphanumeric ones.
+func comparePrereleaseIdentifier(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return sign(an - bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// sign reduces n to -1, 0 or 1.
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
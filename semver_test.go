@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestSemver_Compare(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "1.2.3", b: "1.2.3", expected: 0},
+		{a: "v1.2.3", b: "1.2.3+build.5", expected: 0},
+		{a: "1.2.3", b: "1.2.4", expected: -1},
+		{a: "2.0.0", b: "1.9.9", expected: 1},
+		{a: "1.0.0-rc.1", b: "1.0.0", expected: -1},
+		{a: "1.0.0-alpha", b: "1.0.0-alpha.1", expected: -1},
+		{a: "1.0.0-alpha.1", b: "1.0.0-alpha.beta", expected: -1},
+		{a: "1.0.0-rc.2", b: "1.0.0-rc.10", expected: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_vs_"+tt.b, func(t *testing.T) {
+			a, _ := parseSemver(tt.a)
+			b, _ := parseSemver(tt.b)
+			if got := a.compare(b); got != tt.expected {
+				t.Errorf("compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		bump     string
+		expected string
+	}{
+		{version: "1.2.3", bump: "patch", expected: "1.2.4"},
+		{version: "1.2.3", bump: "minor", expected: "1.3.0"},
+		{version: "1.2.3", bump: "major", expected: "2.0.0"},
+		{version: "v1.2.3+build.7", bump: "patch", expected: "1.2.4"},
+		{version: "1.2.3-rc.1", bump: "patch", expected: "1.2.3"},
+		{version: "1.2.3-rc.1", bump: "minor", expected: "1.3.0"},
+		{version: "1.3.0-rc.1", bump: "minor", expected: "1.3.0"},
+		{version: "2.0.0-rc.1", bump: "major", expected: "2.0.0"},
+		{version: "2.1.0-rc.1", bump: "major", expected: "3.0.0"},
+		{version: "1.2.3", bump: "build", expected: ""},
+		{version: "latest", bump: "patch", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+"_"+tt.bump, func(t *testing.T) {
+			if got := nextVersion(tt.version, tt.bump); got != tt.expected {
+				t.Errorf("nextVersion(%q, %q) = %q, want %q", tt.version, tt.bump, got, tt.expected)
+			}
+		})
+	}
+}
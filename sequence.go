@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultSequencePattern matches the tags {{.NextSequence}} counts from.
+const defaultSequencePattern = `^\d+$`
+
+// nextSequence returns one more than the highest number among tags matching
+// pattern, or 1 when none match. The number is the pattern's first capture
+// group when it has one, otherwise the whole match.
+func nextSequence(tags []string, pattern *regexp.Regexp) int {
+	highest := 0
+	for _, tag := range tags {
+		m := pattern.FindStringSubmatch(tag)
+		if m == nil {
+			continue
+		}
+		value := m[0]
+		if len(m) > 1 {
+			value = m[1]
+		}
+		if n, err := strconv.Atoi(value); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return highest + 1
+}
+
+// resolveNextSequence lists the tags of repository and returns the next
+// sequence number. A repository that does not exist yet starts at 1.
+func resolveNextSequence(ctx context.Context, listTags func(context.Context, string) ([]string, error), repository, pattern string) (int, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sequence_pattern: %w", err)
+	}
+	tags, err := listTags(ctx, repository)
+	if err != nil && !isRepositoryNotFound(err) {
+		return 0, err
+	}
+	return nextSequence(tags, re), nil
+}
+
+// isRepositoryNotFound reports whether an az acr error is about a repository
+// that does not exist.
+func isRepositoryNotFound(err error) bool {
+	lower := strings.ToLower(err.Error())
+	return strings.Contains(lower, "repositorynotfound") ||
+		(strings.Contains(lower, "repository") && strings.Contains(lower, "not found"))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestNextSequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		pattern  string
+		expected int
+	}{
+		{name: "empty repository", pattern: defaultSequencePattern, expected: 1},
+		{name: "no numeric tags", tags: []string{"latest", "1.0.0"}, pattern: defaultSequencePattern, expected: 1},
+		{name: "highest numeric tag", tags: []string{"3", "latest", "12", "9"}, pattern: defaultSequencePattern, expected: 13},
+		{name: "capture group", tags: []string{"build-7", "build-41", "41", "latest"}, pattern: `^build-(\d+)$`, expected: 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextSequence(tt.tags, regexp.MustCompile(tt.pattern)); got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestResolveNextSequence(t *testing.T) {
+	ctx := context.Background()
+
+	missing := func(context.Context, string) ([]string, error) {
+		return nil, errors.New("az acr repository show-tags failed: exit status 1\n(RepositoryNotFound) The repository 'app' is not found.")
+	}
+	if got, err := resolveNextSequence(ctx, missing, "app", defaultSequencePattern); err != nil || got != 1 {
+		t.Errorf("expected a missing repository to start at 1, got %d (%v)", got, err)
+	}
+
+	unauthorized := func(context.Context, string) ([]string, error) {
+		return nil, errors.New("az acr repository show-tags failed: unauthorized")
+	}
+	if _, err := resolveNextSequence(ctx, unauthorized, "app", defaultSequencePattern); err == nil {
+		t.Error("expected a listing failure to be returned")
+	}
+
+	listed := func(_ context.Context, repository string) ([]string, error) {
+		if repository != "team/app" {
+			t.Errorf("unexpected repository %q", repository)
+		}
+		return []string{"1", "2"}, nil
+	}
+	if got, err := resolveNextSequence(ctx, listed, "team/app", defaultSequencePattern); err != nil || got != 3 {
+		t.Errorf("expected 3, got %d (%v)", got, err)
+	}
+
+	if _, err := resolveNextSequence(ctx, listed, "team/app", "("); err == nil {
+		t.Error("expected an invalid pattern to fail")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SigningManifest lists the pushed images for signing out of band, e.g.
+// cosign sign $(jq -r '.images[].digest_ref' manifest.json | sort -u).
+type SigningManifest struct {
+	Registry string           `json:"registry"`
+	Version  string           `json:"version"`
+	Images   []SigningSubject `json:"images"`
+}
+
+// SigningSubject is one pushed tag and the digest it points to.
+type SigningSubject struct {
+	Reference string `json:"reference"`
+	Digest    string `json:"digest"`
+	DigestRef string `json:"digest_ref"`
+}
+
+// newSigningManifest builds a signing manifest from the pushed references,
+// skipping those whose digest is unknown since they cannot be signed.
+func newSigningManifest(registry, version string, references []ImageReference) SigningManifest {
+	manifest := SigningManifest{Registry: registry, Version: version, Images: []SigningSubject{}}
+	for _, ref := range references {
+		if ref.Digest == "" {
+			continue
+		}
+		manifest.Images = append(manifest.Images, SigningSubject{
+			Reference: ref.TagRef,
+			Digest:    ref.Digest,
+			DigestRef: ref.DigestRef,
+		})
+	}
+	return manifest
+}
+
+// writeSigningManifest writes manifest as JSON to path atomically: readers see
+// either the previous file or the complete new one.
+func writeSigningManifest(path string, manifest SigningManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode signing manifest: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".relicta-acr-signing-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create signing manifest: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write signing manifest: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write signing manifest: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write signing manifest: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to write signing manifest: %w", err)
+	}
+	return nil
+}
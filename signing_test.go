@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewSigningManifest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	manifest := newSigningManifest("myregistry.azurecr.io", "1.0.0", []ImageReference{
+		newImageReference("myregistry.azurecr.io", "myapp", "1.0.0", digest),
+		newImageReference("myregistry.azurecr.io", "myapp", "latest", ""),
+	})
+
+	if len(manifest.Images) != 1 {
+		t.Fatalf("expected references without a digest to be skipped, got %+v", manifest.Images)
+	}
+	expected := SigningSubject{
+		Reference: "myregistry.azurecr.io/myapp:1.0.0",
+		Digest:    digest,
+		DigestRef: "myregistry.azurecr.io/myapp@" + digest,
+	}
+	if manifest.Images[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, manifest.Images[0])
+	}
+}
+
+func TestWriteSigningManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "signing.json")
+	if err := os.WriteFile(path, []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := newSigningManifest("myregistry.azurecr.io", "1.0.0", nil)
+	if err := writeSigningManifest(path, manifest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", data, err)
+	}
+	if images, ok := got["images"].([]any); !ok || len(images) != 0 {
+		t.Errorf("expected an empty images list, got %v", got["images"])
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files left behind, got %d entries", len(entries))
+	}
+}
+
+func TestWriteSigningManifest_MissingDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "signing.json")
+	if err := writeSigningManifest(path, SigningManifest{}); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
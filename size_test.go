@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected int64
+		wantErr  bool
+	}{
+		{raw: "2GB", expected: 2_000_000_000},
+		{raw: "512MiB", expected: 512 << 20},
+		{raw: "1.5 gb", expected: 1_500_000_000},
+		{raw: "1024", expected: 1024},
+		{raw: "", wantErr: true},
+		{raw: "GB", wantErr: true},
+		{raw: "-1GB", wantErr: true},
+		{raw: "2XB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseByteSize(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseByteSize(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.expected {
+				t.Errorf("parseByteSize(%q) = %d, want %d", tt.raw, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := []struct {
+		n        int64
+		expected string
+	}{
+		{n: 512, expected: "512 B"},
+		{n: 2_500_000_000, expected: "2.50 GB"},
+		{n: 1_000_000, expected: "1.00 MB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatByteSize(tt.n); got != tt.expected {
+			t.Errorf("formatByteSize(%d) = %q, want %q", tt.n, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Step statuses recorded in the steps output.
+const (
+	stepCompleted = "completed"
+	stepSkipped   = "skipped"
+	stepSimulated = "simulated"
+	stepFailed    = "failed"
+)
+
+// Step is one recorded phase of an Execute run.
+type Step struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// stepRecorder accumulates the ordered phases of a run. It is safe for
+// concurrent use by push workers.
+type stepRecorder struct {
+	mu    sync.Mutex
+	steps []Step
+}
+
+// begin starts timing a phase; calling the returned function records it with a status.
+func (r *stepRecorder) begin(name string) func(status string) {
+	start := time.Now()
+	return func(status string) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.steps = append(r.steps, Step{
+			Name:       name,
+			Status:     status,
+			StartedAt:  start.UTC(),
+			DurationMS: time.Since(start).Milliseconds(),
+		})
+	}
+}
+
+// Steps returns the recorded phases in order.
+func (r *stepRecorder) Steps() []Step {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.steps == nil {
+		return []Step{}
+	}
+	return r.steps
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestStepRecorder(t *testing.T) {
+	r := &stepRecorder{}
+	if steps := r.Steps(); steps == nil || len(steps) != 0 {
+		t.Fatalf("expected empty non-nil steps, got %v", steps)
+	}
+
+	done := r.begin("authenticating")
+	r.begin("tagging a")(stepSimulated)
+	done(stepCompleted)
+
+	steps := r.Steps()
+	if len(steps) != 2 {
+		t.Fatalf("expected 2 steps, got %v", steps)
+	}
+	if steps[0].Name != "tagging a" || steps[0].Status != stepSimulated {
+		t.Errorf("unexpected first step %+v", steps[0])
+	}
+	if steps[1].Name != "authenticating" || steps[1].Status != stepCompleted {
+		t.Errorf("unexpected second step %+v", steps[1])
+	}
+	if steps[1].StartedAt.IsZero() {
+		t.Error("expected start time to be recorded")
+	}
+}
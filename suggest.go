@@ -0,0 +1,70 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxRegistrySuggestions caps the names offered for a mistyped registry.
+const maxRegistrySuggestions = 3
+
+// isRegistryNotFound reports whether az output indicates that the registry does not exist.
+func isRegistryNotFound(output string) bool {
+	lower := strings.ToLower(output)
+	return strings.Contains(lower, "could not be found") ||
+		strings.Contains(lower, "resourcenotfound") ||
+		strings.Contains(lower, "registry not found")
+}
+
+// suggestRegistries returns up to maxRegistrySuggestions candidates close to
+// name by edit distance, closest first.
+func suggestRegistries(name string, candidates []string) []string {
+	name = strings.ToLower(strings.TrimSuffix(name, ".azurecr.io"))
+	limit := max(2, len(name)/3)
+
+	type match struct {
+		name     string
+		distance int
+	}
+	var matches []match
+	for _, candidate := range candidates {
+		d := levenshtein(name, strings.ToLower(candidate))
+		if d > 0 && d <= limit {
+			matches = append(matches, match{name: candidate, distance: d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	suggestions := []string{}
+	for i := 0; i < len(matches) && i < maxRegistrySuggestions; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "", b: "", expected: 0},
+		{a: "myregistry", b: "myregistry", expected: 0},
+		{a: "myregistyr", b: "myregistry", expected: 2},
+		{a: "myregstry", b: "myregistry", expected: 1},
+		{a: "kitten", b: "sitting", expected: 3},
+		{a: "", b: "abc", expected: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			if got := levenshtein(tt.a, tt.b); got != tt.expected {
+				t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSuggestRegistries(t *testing.T) {
+	candidates := []string{"myregistry", "myregistry2", "prodregistry", "myregistries", "otherthing", "myregistryx"}
+
+	got := suggestRegistries("myregstry.azurecr.io", candidates)
+	if !slices.Equal(got, []string{"myregistry", "myregistry2", "myregistryx"}) {
+		t.Errorf("unexpected suggestions %v", got)
+	}
+
+	if got := suggestRegistries("zzz", candidates); len(got) != 0 {
+		t.Errorf("expected no suggestions, got %v", got)
+	}
+}
+
+func TestIsRegistryNotFound(t *testing.T) {
+	if !isRegistryNotFound("The Resource 'Microsoft.ContainerRegistry/registries/myregstry' under resource group '<null>' was not found. ResourceNotFound") {
+		t.Error("expected ResourceNotFound to be detected")
+	}
+	if isRegistryNotFound("unauthorized: authentication required") {
+		t.Error("expected unauthorized not to be treated as not found")
+	}
+}
+
+func TestACRPlugin_Execute_SuggestOnNotFound(t *testing.T) {
+	runner := &fakeRunner{
+		respond: func(cmd Command) ([]byte, error) {
+			switch {
+			case slices.Equal(cmd.Args[:2], []string{"acr", "login"}):
+				return []byte("(ResourceNotFound) The Resource 'Microsoft.ContainerRegistry/registries/myregstry' was not found."), errors.New("exit status 1")
+			case slices.Equal(cmd.Args[:2], []string{"acr", "list"}):
+				return []byte("myregistry\nprodregistry\n"), nil
+			}
+			return nil, nil
+		},
+	}
+	p := &ACRPlugin{runner: runner}
+
+	_, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"registry":             "myregstry",
+			"image":                "myapp",
+			"source_image":         "myapp:latest",
+			"suggest_on_not_found": true,
+		},
+		Context: plugin.ReleaseContext{Version: "1.0.0"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "did you mean 'myregistry'?") {
+		t.Fatalf("expected a suggestion, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "(ResourceNotFound)") {
+		t.Errorf("expected the az error to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// templateData holds the values available to tag templates.
+type templateData struct {
+	*plugin.ReleaseContext
+
+	// Branch is the release branch with slashes replaced by dashes.
+	Branch string
+
+	// IsPrerelease reports whether the version has a prerelease component
+	// or the release type is "prerelease".
+	IsPrerelease bool
+
+	// Semantic version components parsed from Version; empty when Version is not semver.
+	Major      string
+	Minor      string
+	Patch      string
+	Prerelease string
+	Build      string
+
+	// GitDescribe is the sanitized git describe output; see git_describe.
+	GitDescribe string
+
+	// ProvenanceHash identifies the build inputs; see provenanceHash.
+	ProvenanceHash string
+
+	// NextVersion is Version bumped by the configured bump; empty when Version is not semver.
+	NextVersion string
+
+	// NextSequence is one more than the highest numeric tag; see sequence_pattern.
+	NextSequence string
+
+	// SourceDigest is the hex manifest digest of the source image, without the
+	// algorithm prefix.
+	SourceDigest string
+	// ShortSourceDigest is the first 12 hex characters of SourceDigest.
+	ShortSourceDigest string
+
+	// CI build identifiers resolved from the environment; see ci_vars.
+	BuildNumber string
+	RunID       string
+	PipelineID  string
+
+	// Vars holds user-supplied template variables.
+	Vars map[string]string
+}
+
+// newTemplateData creates template data from the release context.
+func newTemplateData(ctx *plugin.ReleaseContext) *templateData {
+	data := &templateData{
+		ReleaseContext: ctx,
+		Branch:         strings.ReplaceAll(ctx.Branch, "/", "-"),
+		IsPrerelease:   ctx.ReleaseType == "prerelease",
+		Vars:           map[string]string{},
+	}
+
+	if v, ok := parseSemver(ctx.Version); ok {
+		data.Major = strconv.Itoa(v.Major)
+		data.Minor = strconv.Itoa(v.Minor)
+		data.Patch = strconv.Itoa(v.Patch)
+		data.Prerelease = v.Prerelease
+		data.Build = v.Build
+		data.IsPrerelease = data.IsPrerelease || v.Prerelease != ""
+	}
+
+	return data
+}
+
+// referencesSourceDigest reports whether any tag template uses the source digest.
+func referencesSourceDigest(tags []string) bool {
+	for _, tag := range tags {
+		if strings.Contains(tag, "SourceDigest}}") {
+			return true
+		}
+	}
+	return false
+}
+
+// referencesField reports whether any template mentions field, such as ".NextSequence".
+func referencesField(tmpls []string, field string) bool {
+	for _, tmpl := range tmpls {
+		if strings.Contains(tmpl, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// digestHex strips the algorithm prefix from a digest.
+func digestHex(digest string) string {
+	if _, hex, found := strings.Cut(digest, ":"); found {
+		return hex
+	}
+	return digest
+}
+
+// shortDigest returns the first 12 hex characters of a digest.
+func shortDigest(digest string) string {
+	hex := digestHex(digest)
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	return hex
+}
+
+// processTags processes tag templates with release context.
+func (p *ACRPlugin) processTags(tags []string, data *templateData) []string {
+	processed := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		result := p.processTemplate(tag, data)
+		if result != "" {
+			processed = append(processed, result)
+		}
+	}
+
+	return processed
+}
+
+// invalidTagChars matches characters not allowed in a Docker tag.
+var invalidTagChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// sanitizeTag makes s a legal Docker tag: invalid characters become dashes,
+// leading dots and dashes are dropped and the result is capped at 128 characters.
+func sanitizeTag(s string) string {
+	s = invalidTagChars.ReplaceAllString(strings.TrimSpace(s), "-")
+	s = strings.TrimLeft(s, ".-")
+	if len(s) > 128 {
+		s = s[:128]
+	}
+	return s
+}
+
+// invalidPathChars matches characters not allowed in a repository path component.
+var invalidPathChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// renderPath renders a repository or namespace template and sanitizes each
+// path component: lowercased, invalid characters replaced by dashes and
+// separators trimmed from the ends. Literal paths are returned unchanged.
+func renderPath(tmpl string, data *templateData) (string, error) {
+	if !strings.Contains(tmpl, "{{") {
+		return tmpl, nil
+	}
+	t, err := template.New("path").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid path template %q: %w", tmpl, err)
+	}
+	var buf strings.Builder
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render path template %q: %w", tmpl, err)
+	}
+
+	var parts []string
+	for _, part := range strings.Split(strings.ToLower(buf.String()), "/") {
+		part = strings.Trim(invalidPathChars.ReplaceAllString(part, "-"), "-._")
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// dedupeTags removes repeated tags, keeping first-seen order, and returns the
+// values that appeared more than once.
+func dedupeTags(tags []string) (unique, duplicates []string) {
+	seen := make(map[string]int, len(tags))
+	unique = make([]string, 0, len(tags))
+	for _, tag := range tags {
+		seen[tag]++
+		switch seen[tag] {
+		case 1:
+			unique = append(unique, tag)
+		case 2:
+			duplicates = append(duplicates, tag)
+		}
+	}
+	return unique, duplicates
+}
+
+// applyLatestPolicy drops the "latest" tag when policy is "never", or when it
+// is "stable_only" and the release is a prerelease. It reports whether the tag
+// was dropped.
+func applyLatestPolicy(tags []string, policy string, prerelease bool) ([]string, bool) {
+	if policy != "never" && (policy != "stable_only" || !prerelease) {
+		return tags, false
+	}
+	kept := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag != "latest" {
+			kept = append(kept, tag)
+		}
+	}
+	return kept, len(kept) < len(tags)
+}
+
+// provenanceHashLength is the number of hex characters kept of the hash.
+const provenanceHashLength = 12
+
+// provenanceHash renders each input template in order, joins the results with
+// "\n" and returns the first 12 hex characters of their SHA-256. An input that
+// renders empty is an error, since hashing without it would silently change
+// the result.
+func (p *ACRPlugin) provenanceHash(inputs []string, data *templateData) (string, error) {
+	if len(inputs) == 0 {
+		return "", nil
+	}
+	rendered := make([]string, len(inputs))
+	for i, input := range inputs {
+		if rendered[i] = p.processTemplate(input, data); rendered[i] == "" {
+			return "", fmt.Errorf("provenance input %q rendered empty", input)
+		}
+	}
+	sum := sha256.Sum256([]byte(strings.Join(rendered, "\n")))
+	return hex.EncodeToString(sum[:])[:provenanceHashLength], nil
+}
+
+// processTemplate renders a tag template against the template data.
+// Templates that fail to parse or reference unknown values render empty.
+func (p *ACRPlugin) processTemplate(tmpl string, data *templateData) string {
+	// Drop tags whose source digest could not be resolved
+	if referencesSourceDigest([]string{tmpl}) && data.SourceDigest == "" {
+		return ""
+	}
+
+	// Drop tags whose git describe output is unavailable
+	if strings.Contains(tmpl, ".GitDescribe") && data.GitDescribe == "" {
+		return ""
+	}
+
+	// Drop tags whose provenance hash could not be computed
+	if strings.Contains(tmpl, ".ProvenanceHash") && data.ProvenanceHash == "" {
+		return ""
+	}
+
+	// Drop tags whose next version could not be computed
+	if strings.Contains(tmpl, ".NextVersion") && data.NextVersion == "" {
+		return ""
+	}
+
+	// Drop tags whose sequence could not be resolved
+	if strings.Contains(tmpl, ".NextSequence") && data.NextSequence == "" {
+		return ""
+	}
+
+	// Drop tags whose CI variables are unset
+	if referencesEmptyCIVar(tmpl, data) {
+		return ""
+	}
+
+	t, err := template.New("tag").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return ""
+	}
+
+	var buf strings.Builder
+	if err := t.Execute(&buf, data); err != nil {
+		return ""
+	}
+
+	return buf.String()
+}
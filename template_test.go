@@ -0,0 +1,331 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestACRPlugin_ProcessTemplate_Vars(t *testing.T) {
+	p := &ACRPlugin{}
+
+	tests := []struct {
+		name     string
+		tmpl     string
+		vars     map[string]string
+		ctx      *plugin.ReleaseContext
+		expected string
+	}{
+		{
+			name:     "user var with built-in field",
+			tmpl:     "{{.Vars.env}}-{{.Version}}",
+			vars:     map[string]string{"env": "prod"},
+			ctx:      &plugin.ReleaseContext{Version: "1.2.3"},
+			expected: "prod-1.2.3",
+		},
+		{
+			name:     "user var named like a built-in stays separate",
+			tmpl:     "{{.Vars.Version}}-{{.Version}}",
+			vars:     map[string]string{"Version": "custom"},
+			ctx:      &plugin.ReleaseContext{Version: "1.2.3"},
+			expected: "custom-1.2.3",
+		},
+		{
+			name:     "missing user var drops tag",
+			tmpl:     "{{.Vars.team}}-{{.Version}}",
+			vars:     map[string]string{},
+			ctx:      &plugin.ReleaseContext{Version: "1.2.3"},
+			expected: "",
+		},
+		{
+			name:     "release context environment",
+			tmpl:     "{{index .Environment \"TEAM\"}}",
+			ctx:      &plugin.ReleaseContext{Environment: map[string]string{"TEAM": "platform"}},
+			expected: "platform",
+		},
+		{
+			name:     "invalid template drops tag",
+			tmpl:     "{{.Version",
+			ctx:      &plugin.ReleaseContext{Version: "1.2.3"},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newTemplateData(tt.ctx)
+			for k, v := range tt.vars {
+				data.Vars[k] = v
+			}
+
+			if got := p.processTemplate(tt.tmpl, data); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_ParseConfig_TemplateVars(t *testing.T) {
+	t.Setenv("ACR_TEST_TEAM", "platform")
+
+	p := &ACRPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"template_vars": map[string]any{
+			"env":  "prod",
+			"team": map[string]any{"env": "ACR_TEST_TEAM"},
+		},
+	})
+
+	if cfg.TemplateVars["env"] != "prod" {
+		t.Errorf("expected literal var 'prod', got %q", cfg.TemplateVars["env"])
+	}
+	if cfg.TemplateVars["team"] != "platform" {
+		t.Errorf("expected env-sourced var 'platform', got %q", cfg.TemplateVars["team"])
+	}
+}
+
+func TestNewTemplateData_Semver(t *testing.T) {
+	tests := []struct {
+		name         string
+		ctx          *plugin.ReleaseContext
+		major        string
+		minor        string
+		patch        string
+		prerelease   string
+		build        string
+		isPrerelease bool
+	}{
+		{
+			name:  "stable",
+			ctx:   &plugin.ReleaseContext{Version: "1.2.3"},
+			major: "1", minor: "2", patch: "3",
+		},
+		{
+			name:  "prerelease with build metadata",
+			ctx:   &plugin.ReleaseContext{Version: "v2.0.0-rc.1+build.5"},
+			major: "2", minor: "0", patch: "0",
+			prerelease: "rc.1", build: "build.5",
+			isPrerelease: true,
+		},
+		{
+			name:         "non-semver version",
+			ctx:          &plugin.ReleaseContext{Version: "2024.06"},
+			isPrerelease: false,
+		},
+		{
+			name:         "prerelease release type",
+			ctx:          &plugin.ReleaseContext{Version: "nightly", ReleaseType: "prerelease"},
+			isPrerelease: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newTemplateData(tt.ctx)
+
+			got := []string{data.Major, data.Minor, data.Patch, data.Prerelease, data.Build}
+			want := []string{tt.major, tt.minor, tt.patch, tt.prerelease, tt.build}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("expected components %v, got %v", want, got)
+					break
+				}
+			}
+
+			if data.IsPrerelease != tt.isPrerelease {
+				t.Errorf("expected IsPrerelease %v, got %v", tt.isPrerelease, data.IsPrerelease)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_ProcessTags_Semver(t *testing.T) {
+	p := &ACRPlugin{}
+
+	tests := []struct {
+		name     string
+		tags     []string
+		ctx      *plugin.ReleaseContext
+		expected []string
+	}{
+		{
+			name:     "major minor stable",
+			tags:     []string{"{{.Major}}.{{.Minor}}", "{{if not .IsPrerelease}}latest{{end}}"},
+			ctx:      &plugin.ReleaseContext{Version: "1.4.2"},
+			expected: []string{"1.4", "latest"},
+		},
+		{
+			name:     "prerelease conditional",
+			tags:     []string{"{{.Version}}", "{{if .IsPrerelease}}beta{{end}}", "{{if not .IsPrerelease}}latest{{end}}"},
+			ctx:      &plugin.ReleaseContext{Version: "1.5.0-beta.1"},
+			expected: []string{"1.5.0-beta.1", "beta"},
+		},
+		{
+			name:     "non-semver leaves components empty",
+			tags:     []string{"{{.Major}}", "{{.Version}}"},
+			ctx:      &plugin.ReleaseContext{Version: "nightly"},
+			expected: []string{"nightly"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := p.processTags(tt.tags, newTemplateData(tt.ctx))
+
+			if len(result) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, result)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("tag %d: expected %q, got %q", i, tt.expected[i], result[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDedupeTags(t *testing.T) {
+	unique, duplicates := dedupeTags([]string{"v1.0.0", "latest", "v1.0.0", "stable", "latest", "v1.0.0"})
+
+	if !slices.Equal(unique, []string{"v1.0.0", "latest", "stable"}) {
+		t.Errorf("unexpected unique tags %v", unique)
+	}
+	if !slices.Equal(duplicates, []string{"v1.0.0", "latest"}) {
+		t.Errorf("unexpected duplicates %v", duplicates)
+	}
+}
+
+func TestApplyLatestPolicy(t *testing.T) {
+	tags := []string{"1.0.0", "latest"}
+	tests := []struct {
+		policy     string
+		prerelease bool
+		expected   []string
+	}{
+		{policy: "always", prerelease: false, expected: tags},
+		{policy: "always", prerelease: true, expected: tags},
+		{policy: "stable_only", prerelease: false, expected: tags},
+		{policy: "stable_only", prerelease: true, expected: []string{"1.0.0"}},
+		{policy: "never", prerelease: false, expected: []string{"1.0.0"}},
+		{policy: "never", prerelease: true, expected: []string{"1.0.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/prerelease=%t", tt.policy, tt.prerelease), func(t *testing.T) {
+			got, dropped := applyLatestPolicy(tags, tt.policy, tt.prerelease)
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+			if dropped != (len(got) < len(tags)) {
+				t.Errorf("unexpected dropped=%t", dropped)
+			}
+		})
+	}
+}
+
+func TestACRPlugin_ProvenanceHash(t *testing.T) {
+	p := &ACRPlugin{}
+	data := newTemplateData(&plugin.ReleaseContext{Version: "1.0.0", CommitSHA: "abc123"})
+	data.SourceDigest = strings.Repeat("f", 64)
+
+	// SHA-256 of "abc123\n" + 64 f's + "\n" + "base=alpine", first 12 hex characters
+	sum := sha256.Sum256([]byte("abc123\n" + strings.Repeat("f", 64) + "\nbase=alpine"))
+	expected := hex.EncodeToString(sum[:])[:12]
+
+	hash, err := p.provenanceHash([]string{"{{.CommitSHA}}", "{{.SourceDigest}}", "base=alpine"}, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hash != expected {
+		t.Errorf("expected %s, got %s", expected, hash)
+	}
+
+	reordered, _ := p.provenanceHash([]string{"base=alpine", "{{.CommitSHA}}", "{{.SourceDigest}}"}, data)
+	if reordered == hash {
+		t.Errorf("expected input order to change the hash")
+	}
+
+	if _, err := p.provenanceHash([]string{"{{.CommitSHA}}", "{{.Vars.missing}}"}, data); err == nil {
+		t.Errorf("expected an error for an input that renders empty")
+	}
+
+	data.ProvenanceHash = hash
+	if got := p.processTags([]string{"build-{{.ProvenanceHash}}"}, data); !slices.Equal(got, []string{"build-" + hash}) {
+		t.Errorf("unexpected tags %v", got)
+	}
+	data.ProvenanceHash = ""
+	if got := p.processTags([]string{"build-{{.ProvenanceHash}}"}, data); len(got) != 0 {
+		t.Errorf("expected tags referencing an empty hash to be dropped, got %v", got)
+	}
+}
+
+func TestACRPlugin_ProcessTags_NextVersion(t *testing.T) {
+	p := &ACRPlugin{}
+
+	data := newTemplateData(&plugin.ReleaseContext{Version: "1.2.3"})
+	data.NextVersion = nextVersion("1.2.3", "minor")
+	if got := p.processTags([]string{"{{.NextVersion}}-pre"}, data); !slices.Equal(got, []string{"1.3.0-pre"}) {
+		t.Errorf("expected [1.3.0-pre], got %v", got)
+	}
+
+	data = newTemplateData(&plugin.ReleaseContext{Version: "nightly"})
+	data.NextVersion = nextVersion("nightly", "minor")
+	if got := p.processTags([]string{"{{.Version}}", "next-{{.NextVersion}}"}, data); !slices.Equal(got, []string{"nightly"}) {
+		t.Errorf("expected tags referencing NextVersion to be dropped, got %v", got)
+	}
+}
+
+func TestSanitizeTag(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "v1.2.3-14-gabc1234", expected: "v1.2.3-14-gabc1234"},
+		{input: "feature/login+dirty", expected: "feature-login-dirty"},
+		{input: "..hidden", expected: "hidden"},
+		{input: " spaced out \n", expected: "spaced-out"},
+		{input: strings.Repeat("a", 200), expected: strings.Repeat("a", 128)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := sanitizeTag(tt.input); got != tt.expected {
+				t.Errorf("sanitizeTag(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderPath(t *testing.T) {
+	data := newTemplateData(&plugin.ReleaseContext{Version: "1.2.0", Branch: "feature/Login_Page!"})
+
+	tests := []struct {
+		tmpl     string
+		expected string
+		wantErr  bool
+	}{
+		{tmpl: "backend", expected: "backend"},
+		{tmpl: "dev/{{.Branch}}", expected: "dev/feature-login_page"},
+		{tmpl: "dev/{{.Version}}/", expected: "dev/1.2.0"},
+		{tmpl: "{{.Vars.team}}/app", wantErr: true},
+		{tmpl: "dev/{{.Nope}}", wantErr: true},
+		{tmpl: "dev/{{", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			got, err := renderPath(tt.tmpl, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("renderPath(%q) = %q, want %q", tt.tmpl, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// verifyPollInterval is the delay between manifest lookups while verifying a push.
+var verifyPollInterval = time.Second
+
+// waitForManifest polls until image resolves in its registry or timeout elapses.
+// Lookup errors are retried because they are often part of the same eventual
+// consistency window.
+func waitForManifest(ctx context.Context, exists func(context.Context, string) (bool, error), image string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var lastErr error
+	for {
+		found, err := exists(ctx, image)
+		if err == nil && found {
+			return nil
+		}
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("pushed tag %s was not resolvable within %s: %w", image, timeout, lastErr)
+			}
+			return fmt.Errorf("pushed tag %s was not resolvable within %s", image, timeout)
+		case <-time.After(verifyPollInterval):
+		}
+	}
+}
+
+// manifestConfigDigest returns the config digest of an image manifest. It is
+// the image ID of the local image that was pushed, which is the only digest
+// known before a push creates the manifest.
+func manifestConfigDigest(output []byte) (string, error) {
+	var manifest struct {
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(output, &manifest); err != nil {
+		return "", fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if manifest.Config.Digest == "" {
+		return "", fmt.Errorf("manifest has no config digest")
+	}
+	return manifest.Config.Digest, nil
+}
+
+// checkIntegrity fails when the digest found in the registry for image differs
+// from the source digest captured before the push.
+func checkIntegrity(image, source, target string) error {
+	if source != target {
+		return fmt.Errorf("verify_integrity: %s has digest %s in the registry, but the source had %s", image, target, source)
+	}
+	return nil
+}
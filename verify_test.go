@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitForManifest(t *testing.T) {
+	verifyPollInterval = time.Millisecond
+	t.Cleanup(func() { verifyPollInterval = time.Second })
+
+	t.Run("resolves after retries", func(t *testing.T) {
+		calls := 0
+		exists := func(context.Context, string) (bool, error) {
+			calls++
+			if calls == 2 {
+				return false, errors.New("transient")
+			}
+			return calls >= 3, nil
+		}
+
+		if err := waitForManifest(context.Background(), exists, "myregistry.azurecr.io/app:1.0.0", time.Second); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 lookups, got %d", calls)
+		}
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		exists := func(context.Context, string) (bool, error) { return false, nil }
+
+		err := waitForManifest(context.Background(), exists, "myregistry.azurecr.io/app:1.0.0", 20*time.Millisecond)
+		if err == nil {
+			t.Fatal("expected timeout error")
+		}
+	})
+}
+
+func TestManifestConfigDigest(t *testing.T) {
+	digest, err := manifestConfigDigest([]byte(`{"config": {"digest": "sha256:abc", "size": 10}}`))
+	if err != nil || digest != "sha256:abc" {
+		t.Errorf("expected sha256:abc, got %q (%v)", digest, err)
+	}
+	if _, err := manifestConfigDigest([]byte(`{"manifests": []}`)); err == nil {
+		t.Error("expected an error for a manifest without config")
+	}
+	if _, err := manifestConfigDigest([]byte("not json")); err == nil {
+		t.Error("expected an error for invalid output")
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	if err := checkIntegrity("r.azurecr.io/app:1.0.0", "sha256:abc", "sha256:abc"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := checkIntegrity("r.azurecr.io/app:1.0.0", "sha256:abc", "sha256:def")
+	expected := "verify_integrity: r.azurecr.io/app:1.0.0 has digest sha256:def in the registry, but the source had sha256:abc"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}